@@ -1,9 +1,13 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
 
+	"github.com/lmarques/efx-face-manager/internal/daemon"
 	"github.com/lmarques/efx-face-manager/internal/tui"
 	"github.com/spf13/cobra"
 )
@@ -11,38 +15,89 @@ import (
 var version = "1.0.0"
 
 func main() {
+	var output string
+	var format tui.OutputFormat
 	rootCmd := &cobra.Command{
 		Use:     "efx-face",
 		Short:   "MLX Hugging Face Model Manager",
 		Long:    `efx-face is a TUI tool for managing MLX Hugging Face models and launching mlx-openai-server instances.`,
 		Version: version,
+		// Errors are printed once by main, without the usage text
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			format, err = tui.ParseOutputFormat(output)
+			return err
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return tui.Run()
 		},
 	}
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", "table", "output format of non-interactive commands: table, json or yaml")
 
 	// Run command - launch model directly
+	var runOpts tui.RunOptions
 	runCmd := &cobra.Command{
-		Use:   "run [model]",
-		Short: "Run a model directly",
+		Use:   "run <model|template>",
+		Short: "Run a model or template without the TUI",
+		Long:  `Launches an installed model, or the model of a template, with the template's settings and any flags given. The server's output is printed until it exits; Ctrl-C stops it gracefully. With --detach, the server is started in the daemon, which is started if needed, and keeps running.`,
+		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			model := ""
-			if len(args) > 0 {
-				model = args[0]
-			}
-			return tui.RunModel(model)
+			runOpts.Target = args[0]
+			runOpts.Format = format
+			return tui.RunModel(runOpts)
 		},
 	}
+	runFlags := runCmd.Flags()
+	runFlags.BoolVarP(&runOpts.Detach, "detach", "d", false, "start the server in the daemon and return")
+	runFlags.StringVar(&runOpts.Type, "type", "", "model type: lm, multimodal, image-generation, image-edit, embeddings or whisper (default lm)")
+	runFlags.IntVar(&runOpts.Config.Port, "port", 0, "port to listen on (default the next free port from the configured default)")
+	runFlags.StringVar(&runOpts.Config.Host, "host", "", "address to listen on (default from config)")
+	runFlags.IntVar(&runOpts.Config.ContextLength, "context-length", 0, "maximum context length")
+	runFlags.StringVar(&runOpts.Config.ToolCallParser, "tool-call-parser", "", "tool call parser")
+	runFlags.StringVar(&runOpts.Config.ReasoningParser, "reasoning-parser", "", "reasoning parser")
+	runFlags.StringVar(&runOpts.Config.MessageConverter, "message-converter", "", "message converter")
+	runFlags.BoolVar(&runOpts.Config.TrustRemoteCode, "trust-remote-code", false, "allow custom model code")
+	runFlags.BoolVar(&runOpts.Config.Debug, "debug", false, "enable server debug output")
+	runFlags.BoolVar(&runOpts.Config.DisableAutoResize, "disable-auto-resize", false, "do not resize images (multimodal)")
+	runFlags.StringVar(&runOpts.Config.ChatTemplateFile, "chat-template-file", "", "custom chat template file")
+	runFlags.StringVar(&runOpts.Config.LogLevel, "log-level", "", "server log level: DEBUG, INFO, WARNING, ERROR or CRITICAL")
+	runFlags.StringVar(&runOpts.Config.ConfigName, "config-name", "", "image model configuration name")
+	runFlags.IntVar(&runOpts.Config.Quantize, "quantize", 0, "image model quantization bits")
+	runFlags.StringVar(&runOpts.Config.LoraPaths, "lora-paths", "", "comma-separated LoRA adapter paths (image models)")
+	runFlags.StringVar(&runOpts.Config.LoraScales, "lora-scales", "", "comma-separated LoRA scales (image models)")
+	runFlags.IntVar(&runOpts.Config.MaxConcurrency, "max-concurrency", 0, "concurrent requests (embeddings and whisper, default 1)")
+	runFlags.IntVar(&runOpts.Config.QueueTimeout, "queue-timeout", 0, "queued request timeout in seconds (embeddings and whisper, default 300)")
+	runFlags.IntVar(&runOpts.Config.QueueSize, "queue-size", 0, "maximum queued requests (embeddings and whisper, default 100)")
+	runFlags.StringArrayVar(&runOpts.Config.ExtraArgs, "arg", nil, "extra argument for mlx-openai-server, after the template's (repeatable)")
+	runFlags.StringVar(&runOpts.Restart, "restart", "", "restart policy: never, on-failure or always")
+	runFlags.IntVar(&runOpts.Config.MaxRetries, "max-retries", 0, "consecutive restarts before giving up")
 
 	// List command - list installed models
 	listCmd := &cobra.Command{
 		Use:   "list",
 		Short: "List installed models",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return tui.RunList()
+			return tui.RunList(format)
 		},
 	}
 
+	// Templates command - check templates.yaml
+	templatesCmd := &cobra.Command{
+		Use:   "templates",
+		Short: "Check launch templates",
+	}
+	templatesCmd.AddCommand(&cobra.Command{
+		Use:   "validate",
+		Short: "Check templates.yaml and report each problem with its file and line",
+		Long:  `Checks the model type, parsers, model, port and name of every template in templates.yaml, after resolving variables and extends. Exits with status 1 when there are problems.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.RunTemplatesValidate(format)
+		},
+	})
+
 	// Search command - search HuggingFace models
 	searchCmd := &cobra.Command{
 		Use:   "search [query]",
@@ -52,7 +107,7 @@ func main() {
 			if len(args) > 0 {
 				query = args[0]
 			}
-			return tui.RunSearch(query)
+			return tui.RunSearch(query, format)
 		},
 	}
 
@@ -60,29 +115,109 @@ func main() {
 	serversCmd := &cobra.Command{
 		Use:   "servers",
 		Short: "Manage running servers",
+		Long:  `Opens the server manager. With --output json or yaml, prints the running servers instead. The subcommands act on servers hosted by the daemon or run by another efx-face process, and exit with status 3 when no server matches.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return tui.RunServerManager()
+			return tui.RunServerManager(format)
+		},
+	}
+	serversCmd.AddCommand(&cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List running servers",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.RunServersList(format)
+		},
+	})
+	var stopAll bool
+	serversStopCmd := &cobra.Command{
+		Use:   "stop <port|model> | --all",
+		Short: "Stop the server on a port or every server running a model",
+		Args: func(cmd *cobra.Command, args []string) error {
+			if stopAll {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := ""
+			if len(args) > 0 {
+				target = args[0]
+			}
+			return tui.RunServersStop(target, stopAll, format)
 		},
 	}
+	serversStopCmd.Flags().BoolVar(&stopAll, "all", false, "stop every running server")
+	serversCmd.AddCommand(serversStopCmd, &cobra.Command{
+		Use:   "restart <port>",
+		Short: "Restart a server with the same configuration",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.RunServersRestart(args[0], format)
+		},
+	}, &cobra.Command{
+		Use:   "inspect <port>",
+		Short: "Show the command line, process, state and resource use of a server",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.RunServersInspect(args[0], format)
+		},
+	})
 
 	// Config command - configure storage path
 	configCmd := &cobra.Command{
 		Use:   "config",
 		Short: "Configure storage path and settings",
+		Long:  `Opens the storage settings. With --output json or yaml, prints the configuration instead.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return tui.RunConfig()
+			return tui.RunConfig(format)
 		},
 	}
 
 	// Install command - install a model from HuggingFace
+	var queueInstall bool
 	installCmd := &cobra.Command{
 		Use:   "install <repo-id>",
 		Short: "Install a model from HuggingFace",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return tui.RunInstall(args[0])
+			if queueInstall {
+				return tui.RunQueueInstall(args[0], format)
+			}
+			return tui.RunInstall(args[0], format)
 		},
 	}
+	installCmd.Flags().BoolVar(&queueInstall, "queue", false, "add to the download queue instead of downloading now")
+
+	// Downloads command - inspect and control the download queue
+	downloadsCmd := &cobra.Command{
+		Use:   "downloads",
+		Short: "Show the download queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.RunDownloads(format)
+		},
+	}
+	for _, action := range []string{"cancel", "pause", "resume"} {
+		downloadsCmd.AddCommand(&cobra.Command{
+			Use:   action + " <id>",
+			Short: strings.ToUpper(action[:1]) + action[1:] + " a download",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				id, err := strconv.Atoi(args[0])
+				if err != nil {
+					return fmt.Errorf("invalid job id: %s", args[0])
+				}
+				return tui.RunDownloadAction(action, id)
+			},
+		})
+	}
+	downloadsCmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove finished downloads from the queue",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.RunDownloadAction("clear", 0)
+		},
+	})
 
 	// Uninstall command - uninstall a model
 	uninstallCmd := &cobra.Command{
@@ -90,14 +225,151 @@ func main() {
 		Short: "Uninstall a model",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return tui.RunUninstall(args[0])
+			return tui.RunUninstall(args[0], format)
+		},
+	}
+
+	// Logs command - print and follow server output
+	var logsOpts tui.LogsOptions
+	logsCmd := &cobra.Command{
+		Use:   "logs [port|model]",
+		Short: "Print or follow the log of a server",
+		Long:  `Prints the output of the server on a port or running a model: the running server if there is one, otherwise its latest run or the run selected with --run. With --follow, keeps printing new output like tail -f. Without arguments, lists all recorded runs.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				logsOpts.Target = args[0]
+			}
+			if !cmd.Flags().Changed("lines") {
+				logsOpts.Lines = -1
+				if logsOpts.Follow {
+					logsOpts.Lines = 10
+				}
+			}
+			return tui.RunLogs(logsOpts)
+		},
+	}
+	logsCmd.Flags().IntVar(&logsOpts.Run, "run", 0, "print the run with this ID (see --list)")
+	logsCmd.Flags().BoolVar(&logsOpts.List, "list", false, "list the recorded runs instead of printing one")
+	logsCmd.Flags().BoolVarP(&logsOpts.Follow, "follow", "f", false, "keep printing new output")
+	logsCmd.Flags().StringVar(&logsOpts.Since, "since", "", "only output newer than a duration (10m) or an RFC 3339 time")
+	logsCmd.Flags().IntVarP(&logsOpts.Lines, "lines", "n", 0, "earlier lines to print, -1 for all (default all, or 10 with --follow)")
+	logsCmd.Flags().StringVar(&logsOpts.Grep, "grep", "", "only lines matching this regular expression")
+	logsCmd.Flags().BoolVar(&logsOpts.JSON, "json", false, "print one JSON object per line with time, port, source, level and text")
+	logsCmd.Flags().BoolVar(&logsOpts.Structured, "structured", false, "prefix lines with their receive time, source and level")
+
+	// Gateway command - serve all running models on one port
+	var gatewayOpts tui.GatewayOptions
+	gatewayCmd := &cobra.Command{
+		Use:   "gateway",
+		Short: "Serve all running models behind one OpenAI-compatible endpoint",
+		Long:  `The gateway listens on one port, lists every running model under /v1/models and forwards each request to the server whose model matches the request's "model" field.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return tui.RunGateway(gatewayOpts)
+		},
+	}
+	gatewayCmd.Flags().StringVar(&gatewayOpts.Host, "host", "127.0.0.1", "address to listen on")
+	gatewayCmd.Flags().IntVar(&gatewayOpts.Port, "port", 8080, "port to listen on")
+	gatewayCmd.Flags().BoolVar(&gatewayOpts.OnDemand, "on-demand", false, "start template models when they are first requested")
+	gatewayCmd.Flags().DurationVar(&gatewayOpts.IdleTimeout, "idle-timeout", 0, "stop on-demand models unused for this long (e.g. 15m)")
+	gatewayCmd.Flags().IntVar(&gatewayOpts.MaxModels, "max-models", 0, "maximum models running at once, evicting the least recently used")
+	gatewayCmd.Flags().Float64Var(&gatewayOpts.MaxMemoryGB, "max-memory-gb", 0, "maximum total size of running models, evicting the least recently used")
+	gatewayCmd.Flags().BoolVar(&gatewayOpts.Metrics, "metrics", false, "serve Prometheus metrics at /metrics")
+
+	// API command - serve the control API
+	var apiOpts tui.APIOptions
+	apiCmd := &cobra.Command{
+		Use:   "api",
+		Short: "Serve a local HTTP/JSON API for managing servers and models",
+		Long:  `The API lists installed models and templates, starts and stops servers, returns their logs, manages the download queue and streams server events. It manages the daemon's servers when the daemon is running. Clients must send "Authorization: Bearer <token>" when a token is set.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("token") {
+				apiOpts.Token = os.Getenv("EFX_API_TOKEN")
+			}
+			return tui.RunAPI(apiOpts)
+		},
+	}
+	apiCmd.Flags().StringVar(&apiOpts.Listen, "listen", "127.0.0.1:8765", "address to listen on")
+	apiCmd.Flags().StringVar(&apiOpts.Socket, "socket", "", "listen on this Unix socket instead")
+	apiCmd.Flags().StringVar(&apiOpts.Token, "token", "", "bearer token required on every request (default $EFX_API_TOKEN)")
+
+	// Daemon command - host servers in a background process
+	var socketPath string
+	daemonCmd := &cobra.Command{
+		Use:   "daemon",
+		Short: "Run the server daemon in the foreground",
+		Long:  `The daemon owns running servers so they survive the TUI exiting. The TUI and CLI commands attach to it automatically when it is running.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return daemon.Run(socketPath)
+		},
+	}
+	daemonCmd.PersistentFlags().StringVar(&socketPath, "socket", daemon.SocketPath(), "daemon socket path")
+
+	daemonStartCmd := &cobra.Command{
+		Use:   "start",
+		Short: "Start the daemon in the background",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if _, err := daemon.StartDetached(socketPath); err != nil {
+				return err
+			}
+			fmt.Println("Daemon running at", socketPath)
+			return nil
+		},
+	}
+
+	daemonStopCmd := &cobra.Command{
+		Use:   "stop",
+		Short: "Stop the daemon and all of its servers",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := daemon.Dial(socketPath)
+			if err != nil {
+				return fmt.Errorf("daemon is not running")
+			}
+			return client.Shutdown()
+		},
+	}
+
+	daemonStatusCmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show whether the daemon is running",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, err := daemon.Dial(socketPath)
+			if err != nil {
+				fmt.Println("Daemon: not running")
+				return nil
+			}
+			fmt.Println("Daemon: running at", socketPath)
+			servers := client.List()
+			fmt.Println("Servers:", len(servers))
+			for _, inst := range servers {
+				fmt.Printf("  :%-5d %-40s %-10s since %s", inst.Port, inst.Model, inst.State, inst.StateSince.Format("15:04:05"))
+				if inst.Running && !inst.Usage.At.IsZero() {
+					fmt.Printf("  cpu %.0f%%  rss %d MB", inst.Usage.CPU, inst.Usage.RSS>>20)
+				}
+				if inst.Restarts > 0 {
+					fmt.Printf("  restarts: %d", inst.Restarts)
+				}
+				if inst.CrashLoop {
+					fmt.Print("  (crash loop)")
+				}
+				if inst.Adopted {
+					fmt.Print("  (adopted)")
+				}
+				fmt.Println()
+			}
+			return nil
 		},
 	}
+	daemonCmd.AddCommand(daemonStartCmd, daemonStopCmd, daemonStatusCmd)
 
-	rootCmd.AddCommand(runCmd, listCmd, searchCmd, serversCmd, configCmd, installCmd, uninstallCmd)
+	rootCmd.AddCommand(runCmd, listCmd, templatesCmd, searchCmd, serversCmd, configCmd, installCmd, uninstallCmd, downloadsCmd, logsCmd, gatewayCmd, apiCmd, daemonCmd)
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
+		var exitErr interface{ ExitCode() int }
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
 		os.Exit(1)
 	}
 }
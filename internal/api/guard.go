@@ -0,0 +1,66 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// guard rejects the requests a web page open in the user's browser could
+// send to the API: those naming another host, which DNS rebinding produces,
+// those from another origin, and POST requests that are not JSON, which a
+// page can send cross-origin without a preflight
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.allowedHost(r.Host) {
+			writeError(w, http.StatusForbidden, errors.New("unexpected Host header"))
+			return
+		}
+		if origin := r.Header.Get("Origin"); origin != "" {
+			u, err := url.Parse(origin)
+			if err != nil || !strings.EqualFold(u.Host, r.Host) {
+				writeError(w, http.StatusForbidden, errors.New("cross-origin requests are not allowed"))
+				return
+			}
+		}
+		if r.Method == http.MethodPost {
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("POST requests must have Content-Type: application/json"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedHost reports whether the Host header of a request names the API:
+// a loopback name or address, or the address it listens on. Any host is
+// accepted on a Unix socket and on a wildcard address, where a token is
+// required.
+func (s *Server) allowedHost(hostport string) bool {
+	if s.opts.Addr == "" {
+		return true
+	}
+	listenHost, _, err := net.SplitHostPort(s.opts.Addr)
+	if err != nil {
+		return false
+	}
+	if ip := net.ParseIP(listenHost); ip != nil && ip.IsUnspecified() {
+		return true
+	}
+
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") || strings.EqualFold(host, listenHost) {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && (ip.IsLoopback() || ip.Equal(net.ParseIP(listenHost)))
+}
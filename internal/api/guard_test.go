@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestGuard(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		name        string
+		addr        string
+		method      string
+		host        string
+		origin      string
+		contentType string
+		want        int
+	}{
+		{"read", "127.0.0.1:8765", "GET", "127.0.0.1:8765", "", "", http.StatusNoContent},
+		{"localhost", "127.0.0.1:8765", "GET", "localhost:8765", "", "", http.StatusNoContent},
+		{"ipv6 loopback", "[::1]:8765", "GET", "[::1]:8765", "", "", http.StatusNoContent},
+		{"rebound host", "127.0.0.1:8765", "GET", "evil.example:8765", "", "", http.StatusForbidden},
+		{"json post", "127.0.0.1:8765", "POST", "127.0.0.1:8765", "", "application/json; charset=utf-8", http.StatusNoContent},
+		{"same origin", "127.0.0.1:8765", "POST", "127.0.0.1:8765", "http://127.0.0.1:8765", "application/json", http.StatusNoContent},
+		{"foreign origin", "127.0.0.1:8765", "POST", "127.0.0.1:8765", "https://evil.example", "application/json", http.StatusForbidden},
+		{"null origin", "127.0.0.1:8765", "POST", "127.0.0.1:8765", "null", "application/json", http.StatusForbidden},
+		{"form post", "127.0.0.1:8765", "POST", "127.0.0.1:8765", "", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"text post", "127.0.0.1:8765", "POST", "127.0.0.1:8765", "", "text/plain", http.StatusUnsupportedMediaType},
+		{"post without type", "127.0.0.1:8765", "POST", "127.0.0.1:8765", "", "", http.StatusUnsupportedMediaType},
+		{"delete", "127.0.0.1:8765", "DELETE", "127.0.0.1:8765", "", "", http.StatusNoContent},
+		{"wildcard address", "0.0.0.0:8765", "GET", "gpu-box.lan:8765", "", "", http.StatusNoContent},
+		{"unix socket", "", "GET", "anything", "", "", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Server{opts: Options{Addr: tt.addr}}
+			req := httptest.NewRequest(tt.method, "/api/servers", strings.NewReader("{}"))
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			s.guard(ok).ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status %d, want %d: %s", rec.Code, tt.want, rec.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Run serves s on listener until interrupted
+func Run(listener net.Listener, s *Server) error {
+	httpServer := &http.Server{Handler: s.Handler()}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	// Event streams only end when their clients leave, so they are
+	// canceled rather than waited for
+	baseCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	httpServer.BaseContext = func(net.Listener) context.Context { return baseCtx }
+
+	go func() {
+		<-sigCh
+		cancel()
+		ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		httpServer.Shutdown(ctx)
+	}()
+
+	err := httpServer.Serve(listener)
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
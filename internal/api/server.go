@@ -0,0 +1,406 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/downloads"
+	"github.com/lmarques/efx-face-manager/internal/model"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// Options configures a Server
+type Options struct {
+	ModelDir    string
+	DefaultHost string // host of servers started without one
+	DefaultPort int    // first port tried for servers started without one
+	Token       string // bearer token required on every request, if set
+	Addr        string // TCP address the API is served on, empty on a Unix socket
+}
+
+// Server serves the control API for a set of servers and a download queue
+type Server struct {
+	servers   server.Backend
+	downloads downloads.Backend
+	store     *model.Store
+	opts      Options
+
+	mu          sync.Mutex
+	subscribers map[chan server.Update]struct{}
+}
+
+// NewServer creates an API server. It consumes the backend's updates to
+// stream them to clients.
+func NewServer(servers server.Backend, dl downloads.Backend, opts Options) *Server {
+	s := &Server{
+		servers:     servers,
+		downloads:   dl,
+		store:       model.NewStore(opts.ModelDir),
+		opts:        opts,
+		subscribers: make(map[chan server.Update]struct{}),
+	}
+	go s.broadcast()
+	return s
+}
+
+// Handler returns the HTTP handler serving the API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/models", s.handleModels)
+	mux.HandleFunc("GET /api/templates", s.handleTemplates)
+	mux.HandleFunc("GET /api/servers", s.handleListServers)
+	mux.HandleFunc("POST /api/servers", s.handleStart)
+	mux.HandleFunc("DELETE /api/servers", s.handleStopAll)
+	mux.HandleFunc("GET /api/servers/{port}", s.handleGetServer)
+	mux.HandleFunc("DELETE /api/servers/{port}", s.handleStop)
+	mux.HandleFunc("POST /api/servers/{port}/restart", s.handleRestart)
+	mux.HandleFunc("GET /api/servers/{port}/logs", s.handleLogs)
+	mux.HandleFunc("GET /api/downloads", s.handleDownloads)
+	mux.HandleFunc("POST /api/downloads", s.handleAddDownload)
+	mux.HandleFunc("DELETE /api/downloads", s.handleClearDownloads)
+	mux.HandleFunc("POST /api/downloads/{id}/{action}", s.handleDownloadAction)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	if s.opts.Token == "" {
+		return s.guard(mux)
+	}
+	return s.guard(s.authenticate(mux))
+}
+
+// authenticate rejects requests without the bearer token
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	want := []byte("Bearer " + s.opts.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), want) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// subscribe registers a new update listener
+func (s *Server) subscribe() (chan server.Update, func()) {
+	ch := make(chan server.Update, 100)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}
+}
+
+// broadcast fans backend updates out to every subscriber. Slow subscribers
+// drop updates rather than blocking the backend.
+func (s *Server) broadcast() {
+	for update := range s.servers.UpdateChan() {
+		s.mu.Lock()
+		for ch := range s.subscribers {
+			select {
+			case ch <- update:
+			default:
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) handleModels(w http.ResponseWriter, r *http.Request) {
+	models, err := s.store.List()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, NewModels(models))
+}
+
+func (s *Server) handleTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := model.LoadTemplates()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to load templates: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, NewTemplates(templates, s.store))
+}
+
+func (s *Server) handleListServers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, NewInstances(s.servers.List()))
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	var req StartRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+
+	cfg, status, err := s.startConfig(req)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	inst, err := s.servers.Start(cfg)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, inst.Info())
+}
+
+// startConfig builds the config of a start request, filling in the model
+// path, type, host and port when they are not given. It returns the status
+// to respond with when the request cannot be served.
+func (s *Server) startConfig(req StartRequest) (server.Config, int, error) {
+	cfg := req.Config
+	if req.Template != "" {
+		templates, err := model.LoadTemplates()
+		if err != nil {
+			return cfg, http.StatusInternalServerError, fmt.Errorf("failed to load templates: %w", err)
+		}
+		var tmpl *model.Template
+		for i := range templates {
+			if templates[i].Name == req.Template {
+				tmpl = &templates[i]
+				break
+			}
+		}
+		if tmpl == nil {
+			return cfg, http.StatusNotFound, fmt.Errorf("no template named %q", req.Template)
+		}
+		cfg = server.FromTemplate(tmpl, s.opts.ModelDir)
+		if req.Port != 0 {
+			cfg.Port = req.Port
+		}
+		if req.Host != "" {
+			cfg.Host = req.Host
+		}
+	}
+
+	if cfg.Model == "" {
+		return cfg, http.StatusBadRequest, errors.New("model or template is required")
+	}
+	if cfg.ModelPath == "" {
+		cfg.ModelPath = filepath.Join(s.opts.ModelDir, cfg.Model)
+	}
+	if _, err := os.Stat(cfg.ModelPath); err != nil {
+		return cfg, http.StatusNotFound, fmt.Errorf("model %s is not installed", cfg.Model)
+	}
+	if cfg.Type == "" {
+		cfg.Type = model.TypeLM
+	}
+	if cfg.Host == "" {
+		cfg.Host = s.opts.DefaultHost
+	}
+	if cfg.Port == 0 {
+		cfg.Port = s.servers.NextAvailablePort(s.opts.DefaultPort)
+	}
+	return cfg, 0, nil
+}
+
+func (s *Server) handleStopAll(w http.ResponseWriter, r *http.Request) {
+	if err := s.servers.StopAll(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGetServer(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, inst.Info())
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if err := s.servers.Stop(inst.Port); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	inst, err := s.servers.Restart(inst.Port)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inst.Info())
+}
+
+// handleLogs returns the complete lines of a server's output, optionally
+// only the last ?lines=N or those newer than ?since=, a duration or an
+// RFC 3339 time
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+
+	query := r.URL.Query()
+	var since time.Time
+	if v := query.Get("since"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil {
+			since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, v); err == nil {
+			since = t
+		} else {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid since %q: use a duration like 10m or an RFC 3339 time", v))
+			return
+		}
+	}
+	limit := 0
+	if v := query.Get("lines"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid lines: %s", v))
+			return
+		}
+		limit = n
+	}
+
+	lines := []LogLine{}
+	for _, entry := range s.servers.GetLogEntries(inst.Port) {
+		if entry.Partial || (!since.IsZero() && !entry.Time.IsZero() && entry.Time.Before(since)) {
+			continue
+		}
+		lines = append(lines, NewLogLine(inst.Port, entry))
+	}
+	if limit > 0 && len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	writeJSON(w, http.StatusOK, lines)
+}
+
+func (s *Server) handleDownloads(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.downloads.List())
+}
+
+func (s *Server) handleAddDownload(w http.ResponseWriter, r *http.Request) {
+	var req DownloadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.RepoID) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("repo_id is required"))
+		return
+	}
+	job, err := s.downloads.Add(strings.TrimSpace(req.RepoID))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, job)
+}
+
+func (s *Server) handleClearDownloads(w http.ResponseWriter, r *http.Request) {
+	if err := s.downloads.Clear(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDownloadAction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid job id: %s", r.PathValue("id")))
+		return
+	}
+
+	switch r.PathValue("action") {
+	case "cancel":
+		err = s.downloads.Cancel(id)
+	case "pause":
+		err = s.downloads.Pause(id)
+	case "resume":
+		err = s.downloads.Resume(id)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown action: %s", r.PathValue("action")))
+		return
+	}
+
+	if errors.Is(err, downloads.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleEvents streams server updates as Server-Sent Events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+
+	ch, cancel := s.subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update := <-ch:
+			data, err := json.Marshal(update)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", update.Type, data)
+			flusher.Flush()
+		}
+	}
+}
+
+// lookup resolves the {port} path parameter to a server
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (*server.Instance, bool) {
+	port, err := strconv.Atoi(r.PathValue("port"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid port: %s", r.PathValue("port")))
+		return nil, false
+	}
+	inst := s.servers.Get(port)
+	if inst == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no server on port %d", port))
+		return nil, false
+	}
+	return inst, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, Error{Error: err.Error()})
+}
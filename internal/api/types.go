@@ -0,0 +1,223 @@
+// Package api serves efx-face over HTTP for scripts and editor integrations.
+// Its types are also what the CLI prints as JSON, so a command's --json
+// output and the matching API response have the same fields.
+package api
+
+import (
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/downloads"
+	"github.com/lmarques/efx-face-manager/internal/hf"
+	"github.com/lmarques/efx-face-manager/internal/model"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// Model is an installed model
+type Model struct {
+	Name      string `json:"name"`
+	Path      string `json:"path"`
+	Target    string `json:"target,omitempty"` // cache snapshot the model links to
+	IsSymlink bool   `json:"is_symlink"`
+	Size      int64  `json:"size"` // bytes on disk
+}
+
+// NewModel converts a model listed by a model.Store
+func NewModel(m model.Model) Model {
+	return Model{
+		Name:      m.Name,
+		Path:      m.Path,
+		Target:    m.TargetPath,
+		IsSymlink: m.IsSymlink,
+		Size:      model.DiskSize(m.Path),
+	}
+}
+
+// NewModels converts models listed by a model.Store
+func NewModels(models []model.Model) []Model {
+	result := make([]Model, 0, len(models))
+	for _, m := range models {
+		result = append(result, NewModel(m))
+	}
+	return result
+}
+
+// SearchResult is a model found on the Hugging Face Hub
+type SearchResult struct {
+	ID           string `json:"id"`
+	Author       string `json:"author,omitempty"`
+	Downloads    int    `json:"downloads"`
+	Likes        int    `json:"likes"`
+	PipelineTag  string `json:"pipeline_tag,omitempty"`
+	Library      string `json:"library,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+	Private      bool   `json:"private"`
+}
+
+// NewSearchResults converts models returned by a Hub search
+func NewSearchResults(models []hf.Model) []SearchResult {
+	result := make([]SearchResult, 0, len(models))
+	for _, m := range models {
+		result = append(result, SearchResult{
+			ID:           m.ID,
+			Author:       m.Author,
+			Downloads:    m.Downloads,
+			Likes:        m.Likes,
+			PipelineTag:  m.PipelineTag,
+			Library:      m.LibraryName,
+			LastModified: m.LastModified,
+			Private:      m.Private,
+		})
+	}
+	return result
+}
+
+// InstallResult is a model installed from the Hub
+type InstallResult struct {
+	RepoID   string `json:"repo_id"`
+	Name     string `json:"name"`
+	Path     string `json:"path"`
+	Snapshot string `json:"snapshot"` // cache directory the model links to
+}
+
+// Template is a launch template from templates.yaml
+type Template struct {
+	Name             string          `json:"name"`
+	Extends          string          `json:"extends,omitempty"`
+	ModelName        string          `json:"model_name"`
+	ModelType        model.ModelType `json:"model_type"`
+	ReasoningParser  string          `json:"reasoning_parser,omitempty"`
+	ToolCallParser   string          `json:"tool_call_parser,omitempty"`
+	MessageConverter string          `json:"message_converter,omitempty"`
+	TrustRemoteCode  bool            `json:"trust_remote_code,omitempty"`
+	Debug            bool            `json:"debug,omitempty"`
+	Port             int             `json:"port,omitempty"`
+	Host             string          `json:"host,omitempty"`
+	Description      string          `json:"description,omitempty"`
+	Restart          string          `json:"restart,omitempty"`
+	MaxRetries       int             `json:"max_retries,omitempty"`
+
+	ContextLength     int      `json:"context_length,omitempty"`
+	DisableAutoResize bool     `json:"disable_auto_resize,omitempty"`
+	ChatTemplateFile  string   `json:"chat_template_file,omitempty"`
+	LogLevel          string   `json:"log_level,omitempty"`
+	ConfigName        string   `json:"config_name,omitempty"`
+	Quantize          int      `json:"quantize,omitempty"`
+	LoraPaths         string   `json:"lora_paths,omitempty"`
+	LoraScales        string   `json:"lora_scales,omitempty"`
+	MaxConcurrency    int      `json:"max_concurrency,omitempty"`
+	QueueTimeout      int      `json:"queue_timeout,omitempty"`
+	QueueSize         int      `json:"queue_size,omitempty"`
+	ExtraArgs         []string `json:"extra_args,omitempty"`
+
+	Installed bool `json:"installed"` // whether the template's model is installed
+}
+
+// NewTemplates converts templates, checking their models against store
+func NewTemplates(templates []model.Template, store *model.Store) []Template {
+	installed := make(map[string]bool)
+	if models, err := store.List(); err == nil {
+		for _, m := range models {
+			installed[m.Name] = true
+		}
+	}
+
+	result := make([]Template, 0, len(templates))
+	for _, t := range templates {
+		result = append(result, Template{
+			Name:             t.Name,
+			Extends:          t.Extends,
+			ModelName:        t.ModelName,
+			ModelType:        t.ModelType,
+			ReasoningParser:  t.ReasoningParser,
+			ToolCallParser:   t.ToolCallParser,
+			MessageConverter: t.MessageConverter,
+			TrustRemoteCode:  t.TrustRemoteCode,
+			Debug:            t.Debug,
+			Port:             t.Port,
+			Host:             t.Host,
+			Description:      t.Description,
+			Restart:          t.Restart,
+			MaxRetries:       t.MaxRetries,
+
+			ContextLength:     t.ContextLength,
+			DisableAutoResize: t.DisableAutoResize,
+			ChatTemplateFile:  t.ChatTemplateFile,
+			LogLevel:          t.LogLevel,
+			ConfigName:        t.ConfigName,
+			Quantize:          t.Quantize,
+			LoraPaths:         t.LoraPaths,
+			LoraScales:        t.LoraScales,
+			MaxConcurrency:    t.MaxConcurrency,
+			QueueTimeout:      t.QueueTimeout,
+			QueueSize:         t.QueueSize,
+			ExtraArgs:         t.ExtraArgs,
+
+			Installed: installed[t.ModelName],
+		})
+	}
+	return result
+}
+
+// Instance is a server instance and its state
+type Instance = server.InstanceInfo
+
+// NewInstances converts instances listed by a server.Backend
+func NewInstances(instances []*server.Instance) []Instance {
+	result := make([]Instance, 0, len(instances))
+	for _, inst := range instances {
+		result = append(result, inst.Info())
+	}
+	return result
+}
+
+// ServerDetails is an instance with its command line and endpoint, as shown
+// by efx-face servers inspect
+type ServerDetails struct {
+	Instance
+	Command  string `json:"command"`
+	URL      string `json:"url"`
+	Daemon   bool   `json:"daemon"`              // hosted by the efx-face daemon
+	OwnerPID int    `json:"owner_pid,omitempty"` // efx-face process running it otherwise
+}
+
+// Job is a download job
+type Job = downloads.Job
+
+// Update is a server event, streamed by GET /api/events
+type Update = server.Update
+
+// StartRequest starts a server from a template, from a full config or from
+// a template with some fields overridden: with a template, the non-zero
+// port and host of the config replace the template's.
+type StartRequest struct {
+	Template string `json:"template,omitempty"`
+	server.Config
+}
+
+// DownloadRequest queues the download of a Hugging Face repository
+type DownloadRequest struct {
+	RepoID string `json:"repo_id"`
+}
+
+// LogLine is a line of server output
+type LogLine struct {
+	Time   string `json:"time,omitempty"` // RFC 3339, empty for lines from old log files
+	Port   int    `json:"port"`
+	Source string `json:"source,omitempty"`
+	Level  string `json:"level,omitempty"`
+	Text   string `json:"text"`
+}
+
+// NewLogLine converts an entry of the server on port
+func NewLogLine(port int, entry server.LogEntry) LogLine {
+	line := LogLine{Port: port, Source: entry.Source, Level: entry.Level, Text: entry.Text}
+	if !entry.Time.IsZero() {
+		line.Time = entry.Time.Format(time.RFC3339Nano)
+	}
+	return line
+}
+
+// Error is the body of error responses
+type Error struct {
+	Error string `json:"error"`
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 const (
@@ -20,6 +21,22 @@ type Config struct {
 	DefaultPort    int               `json:"defaultPort"`
 	DefaultHost    string            `json:"defaultHost"`
 	LastUsed       LastUsedConfig    `json:"lastUsed"`
+
+	// DownloadParallelism is how many queued downloads run at once
+	DownloadParallelism int `json:"downloadParallelism,omitempty"`
+
+	// StopGraceSeconds is how long a server gets to exit before SIGKILL
+	StopGraceSeconds int `json:"stopGraceSeconds,omitempty"`
+
+	// Server log files: size at which a run's log rotates, rotated files
+	// kept per run and runs kept before the oldest are deleted
+	LogMaxSizeMB  int `json:"logMaxSizeMB,omitempty"`
+	LogMaxFiles   int `json:"logMaxFiles,omitempty"`
+	LogRetainRuns int `json:"logRetainRuns,omitempty"`
+
+	// MetricsAddr is the address the daemon serves Prometheus metrics on,
+	// such as 127.0.0.1:9464. Metrics are disabled when empty.
+	MetricsAddr string `json:"metricsAddr,omitempty"`
 }
 
 type LastUsedConfig struct {
@@ -30,18 +47,33 @@ type LastUsedConfig struct {
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		Version:        "1.0.0",
-		ModelDir:       DetectDefaultPath(),
-		AutoDetectPath: true,
-		DefaultPort:    8000,
-		DefaultHost:    "0.0.0.0",
+		Version:             "1.0.0",
+		ModelDir:            DetectDefaultPath(),
+		AutoDetectPath:      true,
+		DefaultPort:         8000,
+		DefaultHost:         "0.0.0.0",
+		DownloadParallelism: 2,
+		StopGraceSeconds:    10,
+		LogMaxSizeMB:        10,
+		LogMaxFiles:         3,
+		LogRetainRuns:       50,
 	}
 }
 
+// StopGracePeriod returns the configured server shutdown grace period
+func (c *Config) StopGracePeriod() time.Duration {
+	return time.Duration(c.StopGraceSeconds) * time.Second
+}
+
+// ConfigDir returns the directory holding efx-face configuration and state
+func ConfigDir() string {
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".config", "efx-face-manager")
+}
+
 // ConfigPath returns the path to the config file
 func ConfigPath() string {
-	home, _ := os.UserHomeDir()
-	return filepath.Join(home, ".config", "efx-face-manager", "config.json")
+	return filepath.Join(ConfigDir(), "config.json")
 }
 
 // LegacyConfigPath returns the path to the legacy config file
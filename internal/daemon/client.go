@@ -0,0 +1,303 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/downloads"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// Client talks to a running daemon over its Unix socket. It implements
+// server.Backend so the TUI and CLI can use it in place of a local Manager.
+type Client struct {
+	socketPath string
+	httpClient *http.Client
+	streamOnce sync.Once
+	updates    chan server.Update
+}
+
+// NewClient creates a client for the daemon listening on socketPath
+func NewClient(socketPath string) *Client {
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socketPath)
+		},
+	}
+	return &Client{
+		socketPath: socketPath,
+		httpClient: &http.Client{Transport: transport},
+		updates:    make(chan server.Update, 100),
+	}
+}
+
+// Dial connects to the daemon and verifies that it is responding
+func Dial(socketPath string) (*Client, error) {
+	c := NewClient(socketPath)
+	if err := c.Ping(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Ping checks that the daemon is alive
+func (c *Client) Ping() error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return c.do(ctx, http.MethodGet, "/ping", nil, nil)
+}
+
+// Shutdown asks the daemon to stop all servers and exit
+func (c *Client) Shutdown() error {
+	return c.do(context.Background(), http.MethodPost, "/shutdown", nil, nil)
+}
+
+// Start starts a new server instance on the daemon
+func (c *Client) Start(config server.Config) (*server.Instance, error) {
+	var info server.InstanceInfo
+	if err := c.do(context.Background(), http.MethodPost, "/servers", config, &info); err != nil {
+		return nil, err
+	}
+	return info.Instance(), nil
+}
+
+// Stop stops a server instance on the daemon
+func (c *Client) Stop(port int) error {
+	return c.do(context.Background(), http.MethodDelete, fmt.Sprintf("/servers/%d", port), nil, nil)
+}
+
+// StopAll stops every server instance on the daemon
+func (c *Client) StopAll() error {
+	return c.do(context.Background(), http.MethodDelete, "/servers", nil, nil)
+}
+
+// Restart restarts a server instance on the daemon
+func (c *Client) Restart(port int) (*server.Instance, error) {
+	var info server.InstanceInfo
+	if err := c.do(context.Background(), http.MethodPost, fmt.Sprintf("/servers/%d/restart", port), nil, &info); err != nil {
+		return nil, err
+	}
+	return info.Instance(), nil
+}
+
+// Get returns a snapshot of the server on port, or nil if there is none
+func (c *Client) Get(port int) *server.Instance {
+	var info server.InstanceInfo
+	if err := c.do(context.Background(), http.MethodGet, fmt.Sprintf("/servers/%d", port), nil, &info); err != nil {
+		return nil
+	}
+	return info.Instance()
+}
+
+// GetLogs returns the captured output of a server
+func (c *Client) GetLogs(port int) string {
+	resp, err := c.request(context.Background(), http.MethodGet, fmt.Sprintf("/servers/%d/logs", port), nil)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return string(data)
+}
+
+// GetLogEntries returns the captured output of a server with receive times
+func (c *Client) GetLogEntries(port int) []server.LogEntry {
+	var entries []server.LogEntry
+	if err := c.do(context.Background(), http.MethodGet, fmt.Sprintf("/servers/%d/logs?format=json", port), nil, &entries); err != nil {
+		return nil
+	}
+	return entries
+}
+
+// ClearLogs clears the captured output of a server
+func (c *Client) ClearLogs(port int) {
+	c.do(context.Background(), http.MethodDelete, fmt.Sprintf("/servers/%d/logs", port), nil, nil)
+}
+
+// List returns snapshots of all servers sorted by port
+func (c *Client) List() []*server.Instance {
+	var infos []server.InstanceInfo
+	if err := c.do(context.Background(), http.MethodGet, "/servers", nil, &infos); err != nil {
+		return nil
+	}
+	list := make([]*server.Instance, 0, len(infos))
+	for _, info := range infos {
+		list = append(list, info.Instance())
+	}
+	return list
+}
+
+// Count returns the number of servers on the daemon
+func (c *Client) Count() int {
+	return len(c.List())
+}
+
+// IsPortInUse checks if the daemon has a server on port or another process
+// holds it. The daemon runs on this machine, so the bind check is local.
+func (c *Client) IsPortInUse(port int) bool {
+	return c.Get(port) != nil || !server.PortFree("", port)
+}
+
+// CheckPort returns a *server.PortConflict if a server cannot listen on
+// host:port
+func (c *Client) CheckPort(host string, port int) error {
+	if inst := c.Get(port); inst != nil {
+		return &server.PortConflict{Port: port, Model: inst.Model}
+	}
+	return server.CheckPort(host, port)
+}
+
+// NextAvailablePort returns the next port not used by the daemon or
+// another process
+func (c *Client) NextAvailablePort(startPort int) int {
+	var result struct {
+		Port int `json:"port"`
+	}
+	if err := c.do(context.Background(), http.MethodGet, fmt.Sprintf("/ports/next?start=%d", startPort), nil, &result); err != nil {
+		return startPort
+	}
+	return result.Port
+}
+
+// UpdateChan returns the channel of updates streamed from the daemon. The
+// stream is opened on first use and reconnects if the connection drops.
+func (c *Client) UpdateChan() <-chan server.Update {
+	c.streamOnce.Do(func() {
+		go c.streamEvents()
+	})
+	return c.updates
+}
+
+// streamEvents reads the daemon's event stream into the updates channel
+func (c *Client) streamEvents() {
+	for {
+		resp, err := c.request(context.Background(), http.MethodGet, "/events", nil)
+		if err == nil {
+			scanner := bufio.NewScanner(resp.Body)
+			scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+			for scanner.Scan() {
+				line := scanner.Text()
+				if !strings.HasPrefix(line, "data: ") {
+					continue
+				}
+				var update server.Update
+				if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &update) == nil {
+					c.updates <- update
+				}
+			}
+			resp.Body.Close()
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// request performs an HTTP request against the daemon and returns the
+// response when its status indicates success
+func (c *Client) request(ctx context.Context, method, path string, body any) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, "http://efx-face"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("daemon not reachable at %s: %w", c.socketPath, err)
+	}
+
+	if resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("%s", apiErr.Error)
+		}
+		return nil, fmt.Errorf("daemon error: %s", resp.Status)
+	}
+
+	return resp, nil
+}
+
+// do performs a request and decodes the JSON response into out when non-nil
+func (c *Client) do(ctx context.Context, method, path string, body, out any) error {
+	resp, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// Downloads returns a view of the daemon's download queue
+func (c *Client) Downloads() downloads.Backend {
+	return &downloadsClient{c: c}
+}
+
+// downloadsClient implements downloads.Backend against the daemon
+type downloadsClient struct {
+	c *Client
+}
+
+func (d *downloadsClient) Add(repoID string) (downloads.Job, error) {
+	var job downloads.Job
+	body := map[string]string{"repo_id": repoID}
+	err := d.c.do(context.Background(), http.MethodPost, "/downloads", body, &job)
+	return job, err
+}
+
+func (d *downloadsClient) List() []downloads.Job {
+	var jobs []downloads.Job
+	if err := d.c.do(context.Background(), http.MethodGet, "/downloads", nil, &jobs); err != nil {
+		return nil
+	}
+	return jobs
+}
+
+func (d *downloadsClient) Cancel(id int) error {
+	return d.action(id, "cancel")
+}
+
+func (d *downloadsClient) Pause(id int) error {
+	return d.action(id, "pause")
+}
+
+func (d *downloadsClient) Resume(id int) error {
+	return d.action(id, "resume")
+}
+
+func (d *downloadsClient) Clear() error {
+	return d.c.do(context.Background(), http.MethodDelete, "/downloads", nil, nil)
+}
+
+func (d *downloadsClient) action(id int, action string) error {
+	return d.c.do(context.Background(), http.MethodPost, fmt.Sprintf("/downloads/%d/%s", id, action), nil, nil)
+}
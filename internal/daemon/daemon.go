@@ -0,0 +1,430 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/downloads"
+	"github.com/lmarques/efx-face-manager/internal/hf"
+	"github.com/lmarques/efx-face-manager/internal/installer"
+	"github.com/lmarques/efx-face-manager/internal/metrics"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// SocketPath returns the default location of the daemon socket
+func SocketPath() string {
+	return filepath.Join(config.ConfigDir(), "daemon.sock")
+}
+
+// LogPath returns the file the detached daemon writes its output to
+func LogPath() string {
+	return filepath.Join(config.ConfigDir(), "daemon.log")
+}
+
+// Server exposes a server.Manager over HTTP so that several clients can
+// share the same fleet of running servers
+type Server struct {
+	mgr         server.Backend
+	downloads   downloads.Backend
+	mu          sync.Mutex
+	subscribers map[chan server.Update]struct{}
+	shutdown    chan struct{}
+	once        sync.Once
+}
+
+// NewServer creates a daemon server around an existing server manager
+// and download queue
+func NewServer(mgr server.Backend, dl downloads.Backend) *Server {
+	s := &Server{
+		mgr:         mgr,
+		downloads:   dl,
+		subscribers: make(map[chan server.Update]struct{}),
+		shutdown:    make(chan struct{}),
+	}
+	go s.broadcast()
+	return s
+}
+
+// Manager returns the servers the daemon exposes
+func (s *Server) Manager() server.Backend {
+	return s.mgr
+}
+
+// Handler returns the HTTP handler serving the daemon API
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ping", s.handlePing)
+	mux.HandleFunc("POST /shutdown", s.handleShutdown)
+	mux.HandleFunc("GET /servers", s.handleList)
+	mux.HandleFunc("POST /servers", s.handleStart)
+	mux.HandleFunc("DELETE /servers", s.handleStopAll)
+	mux.HandleFunc("GET /servers/{port}", s.handleGet)
+	mux.HandleFunc("DELETE /servers/{port}", s.handleStop)
+	mux.HandleFunc("POST /servers/{port}/restart", s.handleRestart)
+	mux.HandleFunc("GET /servers/{port}/logs", s.handleLogs)
+	mux.HandleFunc("DELETE /servers/{port}/logs", s.handleClearLogs)
+	mux.HandleFunc("GET /ports/next", s.handleNextPort)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	mux.HandleFunc("GET /downloads", s.handleDownloads)
+	mux.HandleFunc("POST /downloads", s.handleAddDownload)
+	mux.HandleFunc("DELETE /downloads", s.handleClearDownloads)
+	mux.HandleFunc("POST /downloads/{id}/{action}", s.handleDownloadAction)
+	return mux
+}
+
+// Done is closed once a client has requested the daemon to shut down
+func (s *Server) Done() <-chan struct{} {
+	return s.shutdown
+}
+
+// subscribe registers a new update listener
+func (s *Server) subscribe() (chan server.Update, func()) {
+	ch := make(chan server.Update, 100)
+	s.mu.Lock()
+	s.subscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch, func() {
+		s.mu.Lock()
+		delete(s.subscribers, ch)
+		s.mu.Unlock()
+	}
+}
+
+// broadcast fans manager updates out to every subscriber. Slow subscribers
+// drop updates rather than blocking the manager.
+func (s *Server) broadcast() {
+	for update := range s.mgr.UpdateChan() {
+		s.mu.Lock()
+		for ch := range s.subscribers {
+			select {
+			case ch <- update:
+			default:
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]int{"pid": os.Getpid(), "servers": s.mgr.Count()})
+}
+
+func (s *Server) handleShutdown(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+	s.once.Do(func() { close(s.shutdown) })
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	list := s.mgr.List()
+	infos := make([]server.InstanceInfo, 0, len(list))
+	for _, inst := range list {
+		infos = append(infos, inst.Info())
+	}
+	writeJSON(w, http.StatusOK, infos)
+}
+
+func (s *Server) handleStart(w http.ResponseWriter, r *http.Request) {
+	var cfg server.Config
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid config: %w", err))
+		return
+	}
+	inst, err := s.mgr.Start(cfg)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, inst.Info())
+}
+
+func (s *Server) handleStopAll(w http.ResponseWriter, r *http.Request) {
+	if err := s.mgr.StopAll(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, inst.Info())
+}
+
+func (s *Server) handleStop(w http.ResponseWriter, r *http.Request) {
+	port, ok := portParam(w, r)
+	if !ok {
+		return
+	}
+	if err := s.mgr.Stop(port); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleRestart(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	inst, err := s.mgr.Restart(inst.Port)
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, inst.Info())
+}
+
+func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if r.URL.Query().Get("format") == "json" {
+		writeJSON(w, http.StatusOK, s.mgr.GetLogEntries(inst.Port))
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte(s.mgr.GetLogs(inst.Port)))
+}
+
+func (s *Server) handleClearLogs(w http.ResponseWriter, r *http.Request) {
+	inst, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	s.mgr.ClearLogs(inst.Port)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleNextPort(w http.ResponseWriter, r *http.Request) {
+	start, err := strconv.Atoi(r.URL.Query().Get("start"))
+	if err != nil || start <= 0 {
+		start = 8000
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"port": s.mgr.NextAvailablePort(start)})
+}
+
+// handleEvents streams manager updates as Server-Sent Events
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming not supported"))
+		return
+	}
+
+	ch, cancel := s.subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case update := <-ch:
+			data, err := json.Marshal(update)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) handleDownloads(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.downloads.List())
+}
+
+func (s *Server) handleAddDownload(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RepoID string `json:"repo_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RepoID == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("repo_id is required"))
+		return
+	}
+	job, err := s.downloads.Add(req.RepoID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, job)
+}
+
+func (s *Server) handleClearDownloads(w http.ResponseWriter, r *http.Request) {
+	if err := s.downloads.Clear(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleDownloadAction(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid job id: %s", r.PathValue("id")))
+		return
+	}
+
+	switch r.PathValue("action") {
+	case "cancel":
+		err = s.downloads.Cancel(id)
+	case "pause":
+		err = s.downloads.Pause(id)
+	case "resume":
+		err = s.downloads.Resume(id)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown action: %s", r.PathValue("action")))
+		return
+	}
+
+	if errors.Is(err, downloads.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lookup resolves the {port} path parameter to a running instance
+func (s *Server) lookup(w http.ResponseWriter, r *http.Request) (*server.Instance, bool) {
+	port, ok := portParam(w, r)
+	if !ok {
+		return nil, false
+	}
+	inst := s.mgr.Get(port)
+	if inst == nil {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no server on port %d", port))
+		return nil, false
+	}
+	return inst, true
+}
+
+func portParam(w http.ResponseWriter, r *http.Request) (int, bool) {
+	port, err := strconv.Atoi(r.PathValue("port"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid port: %s", r.PathValue("port")))
+		return 0, false
+	}
+	return port, true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// Run starts the daemon on socketPath and blocks until it receives SIGINT or
+// SIGTERM or a shutdown request. All servers are stopped on exit.
+func Run(socketPath string) error {
+	if _, err := Dial(socketPath); err == nil {
+		return fmt.Errorf("daemon already running at %s", socketPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socketPath), 0755); err != nil {
+		return err
+	}
+	// Remove a stale socket left behind by a daemon that did not exit cleanly
+	os.Remove(socketPath)
+
+	listener, err := listenUnix(socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	defer os.Remove(socketPath)
+
+	cfg, _ := config.Load()
+	mgr := server.NewManager()
+	mgr.SetGracePeriod(cfg.StopGracePeriod())
+	mgr.Logs = server.NewLogStore(server.LogDir(), cfg)
+	hfClient := hf.NewClient()
+	dl := downloads.NewManager(installer.New(hfClient, cfg.ModelDir), downloads.QueuePath(), cfg.DownloadParallelism)
+	srv := NewServer(mgr, dl)
+
+	var metricsServer *http.Server
+	if cfg.MetricsAddr != "" {
+		metricsListener, err := net.Listen("tcp", cfg.MetricsAddr)
+		if err != nil {
+			dl.Close()
+			return fmt.Errorf("failed to listen on metrics address %s: %w", cfg.MetricsAddr, err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler(mgr, dl, hfClient))
+		metricsServer = &http.Server{Handler: mux}
+		go metricsServer.Serve(metricsListener)
+		fmt.Printf("serving metrics on http://%s/metrics\n", metricsListener.Addr())
+	}
+
+	// Servers left running by an efx-face process that died are taken over
+	mgr.TrackState(server.StateDir())
+	for _, orphan := range mgr.FindOrphans() {
+		if _, err := mgr.Adopt(orphan); err != nil {
+			fmt.Printf("could not adopt %s (pid %d): %v\n", orphan.Model, orphan.PID, err)
+			continue
+		}
+		fmt.Printf("adopted %s on port %d (pid %d)\n", orphan.Model, orphan.Port, orphan.PID)
+	}
+
+	// Cancel long-lived event streams when shutting down
+	baseCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	httpServer := &http.Server{
+		Handler:     srv.Handler(),
+		BaseContext: func(net.Listener) context.Context { return baseCtx },
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	go func() {
+		select {
+		case <-sigCh:
+		case <-srv.Done():
+		}
+		cancel()
+		ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		httpServer.Shutdown(ctx)
+		if metricsServer != nil {
+			metricsServer.Shutdown(ctx)
+		}
+	}()
+
+	fmt.Printf("efx-face daemon listening on %s (pid %d)\n", socketPath, os.Getpid())
+	err = httpServer.Serve(listener)
+	dl.Close()
+	mgr.StopAll()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/lmarques/efx-face-manager/internal/downloads"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// fakeBackend runs no processes and returns a new snapshot of an instance
+// on every call, like the real manager's Info
+type fakeBackend struct {
+	server.Backend // methods the tests do not use
+
+	mu      sync.Mutex
+	servers map[int]server.Config
+	updates chan server.Update
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{servers: make(map[int]server.Config), updates: make(chan server.Update)}
+}
+
+func (b *fakeBackend) Start(cfg server.Config) (*server.Instance, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.servers[cfg.Port]; ok {
+		return nil, fmt.Errorf("port %d is already in use", cfg.Port)
+	}
+	b.servers[cfg.Port] = cfg
+	return b.instance(cfg.Port), nil
+}
+
+func (b *fakeBackend) Stop(port int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.servers[port]; !ok {
+		return fmt.Errorf("no server on port %d", port)
+	}
+	delete(b.servers, port)
+	return nil
+}
+
+func (b *fakeBackend) Get(port int) *server.Instance {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.instance(port)
+}
+
+func (b *fakeBackend) List() []*server.Instance {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var list []*server.Instance
+	for port := range b.servers {
+		list = append(list, b.instance(port))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Port < list[j].Port })
+	return list
+}
+
+func (b *fakeBackend) Count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.servers)
+}
+
+func (b *fakeBackend) UpdateChan() <-chan server.Update {
+	return b.updates
+}
+
+func (b *fakeBackend) instance(port int) *server.Instance {
+	cfg, ok := b.servers[port]
+	if !ok {
+		return nil
+	}
+	return &server.Instance{Model: cfg.Model, Host: cfg.Host, Port: port, PID: 1000 + port, Running: true, State: server.StateReady}
+}
+
+// startDaemon serves backend on a socket in a temporary directory and
+// returns the socket path
+func startDaemon(t *testing.T, backend server.Backend) string {
+	t.Helper()
+	// t.TempDir can be longer than a socket path may be
+	dir, err := os.MkdirTemp("", "efx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "daemon.sock")
+
+	listener, err := listenUnix(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(backend, downloads.NewFileQueue(filepath.Join(dir, "downloads.json")))
+	httpServer := &http.Server{Handler: srv.Handler()}
+	go httpServer.Serve(listener)
+	t.Cleanup(func() { httpServer.Close() })
+	return socketPath
+}
+
+func TestDaemonSocketMode(t *testing.T) {
+	socketPath := startDaemon(t, newFakeBackend())
+	info, err := os.Stat(socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("socket mode %v, want only the owner to connect", perm)
+	}
+}
+
+func TestClient(t *testing.T) {
+	backend := newFakeBackend()
+	client, err := Dial(startDaemon(t, backend))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, port := range []int{8001, 8000} {
+		inst, err := client.Start(server.Config{Model: fmt.Sprintf("model-%d", port), Port: port, Host: "127.0.0.1"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if inst.Port != port || inst.PID != 1000+port || !inst.Running || inst.State != server.StateReady {
+			t.Errorf("started %+v", inst)
+		}
+	}
+	if _, err := client.Start(server.Config{Model: "other", Port: 8000}); err == nil {
+		t.Error("started a second server on port 8000")
+	}
+
+	list := client.List()
+	if len(list) != 2 || list[0].Port != 8000 || list[0].Model != "model-8000" || list[1].Port != 8001 {
+		t.Errorf("listed %+v, want the servers on 8000 and 8001", list)
+	}
+	if inst := client.Get(8001); inst == nil || inst.Model != "model-8001" {
+		t.Errorf("got %+v for port 8001", inst)
+	}
+
+	if err := client.Stop(8000); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Stop(8000); err == nil {
+		t.Error("stopped the server on 8000 twice")
+	}
+	if list := client.List(); len(list) != 1 || list[0].Port != 8001 {
+		t.Errorf("listed %+v after the stop, want only 8001", list)
+	}
+	if client.Get(8000) != nil {
+		t.Error("stopped server still found")
+	}
+}
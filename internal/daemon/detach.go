@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+)
+
+// StartDetached launches the daemon as a background process that outlives
+// the calling terminal and waits until it accepts connections
+func StartDetached(socketPath string) (*Client, error) {
+	if c, err := Dial(socketPath); err == nil {
+		return c, nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(LogPath()), 0755); err != nil {
+		return nil, err
+	}
+	logFile, err := os.OpenFile(LogPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, "daemon", "--socket", socketPath)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = detachAttr()
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start daemon: %w", err)
+	}
+	cmd.Process.Release()
+
+	// Wait for the socket to come up
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if c, err := Dial(socketPath); err == nil {
+			return c, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil, fmt.Errorf("daemon did not start, see %s", LogPath())
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package daemon
+
+import "syscall"
+
+// detachAttr starts the daemon in a new process group so console signals
+// sent to the launching terminal do not reach it
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"net"
+	"syscall"
+)
+
+// listenUnix listens on a socket only the current user can connect to. The
+// socket is created with that mode, since changing it after Listen leaves
+// a moment where other users can connect. The umask is set for the whole
+// process, so this runs before the daemon starts anything else.
+func listenUnix(path string) (net.Listener, error) {
+	old := syscall.Umask(0o177)
+	defer syscall.Umask(old)
+	return net.Listen("unix", path)
+}
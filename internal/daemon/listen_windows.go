@@ -0,0 +1,11 @@
+//go:build windows
+
+package daemon
+
+import "net"
+
+// listenUnix listens on a socket, which Windows protects with the access
+// rights of its directory
+func listenUnix(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
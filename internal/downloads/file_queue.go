@@ -0,0 +1,113 @@
+package downloads
+
+import (
+	"fmt"
+	"time"
+)
+
+// FileQueue edits the persisted queue directly. The CLI uses it when no
+// daemon is running. The process owning the queue, a TUI or the daemon,
+// picks up the changes within a second; without one, queued jobs start the
+// next time the TUI or the daemon loads the queue.
+type FileQueue struct {
+	path string
+}
+
+// NewFileQueue creates a queue backed by the file at path
+func NewFileQueue(path string) *FileQueue {
+	return &FileQueue{path: path}
+}
+
+// Add appends a queued job for repoID unless one is already pending
+func (q *FileQueue) Add(repoID string) (Job, error) {
+	var job Job
+	err := editQueue(q.path, func(jobs []Job) ([]Job, error) {
+		nextID := 1
+		for _, existing := range jobs {
+			if existing.RepoID == repoID && !existing.Finished() {
+				job = existing
+				return jobs, nil
+			}
+			if existing.ID >= nextID {
+				nextID = existing.ID + 1
+			}
+		}
+
+		job = Job{
+			ID:        nextID,
+			RepoID:    repoID,
+			Status:    StatusQueued,
+			CreatedAt: time.Now(),
+		}
+		return append(jobs, job), nil
+	})
+	return job, err
+}
+
+// List returns the persisted jobs
+func (q *FileQueue) List() []Job {
+	jobs, _ := LoadQueue(q.path)
+	return jobs
+}
+
+// Cancel marks a job canceled
+func (q *FileQueue) Cancel(id int) error {
+	return q.update(id, func(job *Job) error {
+		if job.Finished() {
+			return fmt.Errorf("job %d already %s", id, job.Status)
+		}
+		job.Status = StatusCanceled
+		job.Speed = 0
+		job.FinishedAt = time.Now()
+		return nil
+	})
+}
+
+// Pause marks a job paused
+func (q *FileQueue) Pause(id int) error {
+	return q.update(id, func(job *Job) error {
+		if job.Finished() {
+			return fmt.Errorf("job %d already %s", id, job.Status)
+		}
+		job.Status = StatusPaused
+		job.Speed = 0
+		return nil
+	})
+}
+
+// Resume queues a paused, failed or canceled job again
+func (q *FileQueue) Resume(id int) error {
+	return q.update(id, func(job *Job) error {
+		if job.Status == StatusQueued || job.Status == StatusRunning || job.Status == StatusCompleted {
+			return nil
+		}
+		job.Status = StatusQueued
+		job.Error = ""
+		job.FinishedAt = time.Time{}
+		return nil
+	})
+}
+
+// Clear removes finished jobs
+func (q *FileQueue) Clear() error {
+	return editQueue(q.path, func(jobs []Job) ([]Job, error) {
+		kept := jobs[:0]
+		for _, job := range jobs {
+			if !job.Finished() {
+				kept = append(kept, job)
+			}
+		}
+		return kept, nil
+	})
+}
+
+func (q *FileQueue) update(id int, fn func(job *Job) error) error {
+	return editQueue(q.path, func(jobs []Job) ([]Job, error) {
+		for i := range jobs {
+			if jobs[i].ID == id {
+				return jobs, fn(&jobs[i])
+			}
+		}
+		return nil, ErrNotFound
+	})
+}
@@ -0,0 +1,77 @@
+package downloads
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// The queue file has a single owner, the process that runs its jobs. It
+// holds the owner lock for as long as it runs. Every process, the owner
+// included, edits the file while holding the edit lock, so changes made by
+// the CLI or a second TUI are not lost and the owner picks them up.
+
+// errLocked is returned by lockFile when another process holds the lock
+var errLocked = errors.New("locked by another process")
+
+// ownerLockPath returns the lock file held by the owner of a queue file
+func ownerLockPath(path string) string {
+	return path + ".owner"
+}
+
+// editLockPath returns the lock file held while editing a queue file
+func editLockPath(path string) string {
+	return path + ".lock"
+}
+
+// acquireLock opens the lock file at path and locks it
+func acquireLock(path string, block bool) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, block); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// releaseLock unlocks and closes a lock file
+func releaseLock(f *os.File) {
+	unlockFile(f)
+	f.Close()
+}
+
+// editQueue replaces the jobs of the queue file at path with those fn
+// returns, holding the edit lock
+func editQueue(path string, fn func(jobs []Job) ([]Job, error)) error {
+	lock, err := acquireLock(editLockPath(path), true)
+	if err != nil {
+		return err
+	}
+	defer releaseLock(lock)
+
+	jobs, err := LoadQueue(path)
+	if err != nil {
+		return err
+	}
+	if jobs, err = fn(jobs); err != nil {
+		return err
+	}
+	return SaveQueue(path, jobs)
+}
+
+// QueueOwned reports whether a process is running the jobs of the queue
+// file at path
+func QueueOwned(path string) bool {
+	lock, err := acquireLock(ownerLockPath(path), false)
+	if err != nil {
+		return errors.Is(err, errLocked)
+	}
+	releaseLock(lock)
+	return false
+}
@@ -0,0 +1,617 @@
+package downloads
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/hf"
+	"github.com/lmarques/efx-face-manager/internal/installer"
+)
+
+// Status is the lifecycle state of a download job
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusPaused    Status = "paused"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+// DefaultParallelism is the number of jobs downloaded at once when not configured
+const DefaultParallelism = 2
+
+// Job is a queued model download
+type Job struct {
+	ID         int       `json:"id"`
+	RepoID     string    `json:"repo_id"`
+	Status     Status    `json:"status"`
+	File       string    `json:"file,omitempty"`
+	Bytes      int64     `json:"bytes"`
+	TotalBytes int64     `json:"total_bytes"`
+	FilesDone  int       `json:"files_done"`
+	FilesTotal int       `json:"files_total"`
+	Speed      float64   `json:"speed"` // Bytes per second
+	Error      string    `json:"error,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	StartedAt  time.Time `json:"started_at,omitempty"`
+	FinishedAt time.Time `json:"finished_at,omitempty"`
+
+	lastSample      time.Time
+	lastSampleBytes int64
+}
+
+// Finished reports whether the job will not run again without being resumed
+func (j Job) Finished() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed || j.Status == StatusCanceled
+}
+
+// ETA estimates the remaining download time, or 0 when unknown
+func (j Job) ETA() time.Duration {
+	if j.Status != StatusRunning || j.Speed <= 0 || j.TotalBytes <= j.Bytes {
+		return 0
+	}
+	return time.Duration(float64(j.TotalBytes-j.Bytes) / j.Speed * float64(time.Second))
+}
+
+// Percent returns download progress from 0 to 100
+func (j Job) Percent() float64 {
+	if j.TotalBytes <= 0 {
+		if j.Status == StatusCompleted {
+			return 100
+		}
+		return 0
+	}
+	return float64(j.Bytes) / float64(j.TotalBytes) * 100
+}
+
+// Backend is the set of queue operations used by the TUI and CLI. It is
+// implemented by Manager and by the daemon client.
+type Backend interface {
+	Add(repoID string) (Job, error)
+	List() []Job
+	Cancel(id int) error
+	Pause(id int) error
+	Resume(id int) error
+	Clear() error
+}
+
+// Installer downloads and installs a model. It is implemented by
+// installer.Installer.
+type Installer interface {
+	Install(ctx context.Context, repoID string, onProgress func(hf.Progress)) (*installer.Result, error)
+}
+
+// ErrNotFound is returned for unknown job IDs
+var ErrNotFound = errors.New("download job not found")
+
+// QueuePath returns the file the download queue is persisted to
+func QueuePath() string {
+	return filepath.Join(config.ConfigDir(), "downloads.json")
+}
+
+// Manager runs queued downloads in the background with a parallelism
+// limit. The queue is persisted so unfinished jobs survive a restart and
+// resume from their partially downloaded files.
+//
+// Only the Manager owning the queue file runs jobs. One that does not edits
+// the file for the owner like FileQueue, and takes over when the owner
+// exits. The owner merges the edits of other processes into its queue.
+type Manager struct {
+	installer   Installer
+	path        string
+	parallelism int
+	file        *FileQueue
+	stop        chan struct{}
+	closeOnce   sync.Once
+
+	mu       sync.Mutex
+	owner    *os.File // owner lock, nil while another process owns the queue
+	jobs     []*Job
+	nextID   int
+	running  map[int]*jobRun // runs that have not returned yet, by job ID
+	lastSave time.Time
+	written  map[int]Status // job statuses as last saved
+
+	// Totals since the manager was created, for metrics
+	finished    map[Status]int
+	transferred int64
+}
+
+// jobRun is one run of a job. A paused or canceled run stays in
+// Manager.running until it returns, so the job does not run twice at once.
+type jobRun struct {
+	cancel  context.CancelFunc
+	stopped bool // canceled, and no longer owning the job's status
+}
+
+// ownerPollInterval is how often the owner of the queue checks the file for
+// the edits of other processes, and other managers try to become the owner
+const ownerPollInterval = time.Second
+
+// NewManager creates a manager for the queue persisted at path. When no
+// other process owns the queue, it loads it and starts any queued jobs.
+func NewManager(inst Installer, path string, parallelism int) *Manager {
+	if parallelism <= 0 {
+		parallelism = DefaultParallelism
+	}
+	m := &Manager{
+		installer:   inst,
+		path:        path,
+		parallelism: parallelism,
+		file:        NewFileQueue(path),
+		stop:        make(chan struct{}),
+		nextID:      1,
+		running:     make(map[int]*jobRun),
+		written:     make(map[int]Status),
+		finished:    make(map[Status]int),
+	}
+
+	m.mu.Lock()
+	m.takeOwnership()
+	m.mu.Unlock()
+	go m.watch()
+	return m
+}
+
+// takeOwnership becomes the owner of the queue if no other process is, and
+// loads and schedules it. Callers hold m.mu.
+func (m *Manager) takeOwnership() {
+	lock, err := acquireLock(ownerLockPath(m.path), false)
+	if err != nil {
+		return
+	}
+	jobs, err := LoadQueue(m.path)
+	if err != nil {
+		releaseLock(lock)
+		return
+	}
+	m.owner = lock
+
+	m.jobs = nil
+	for _, job := range jobs {
+		m.written[job.ID] = job.Status
+		// Jobs interrupted by the previous owner are picked up again
+		if job.Status == StatusRunning {
+			job.Status = StatusQueued
+			job.Speed = 0
+		}
+		j := job
+		m.jobs = append(m.jobs, &j)
+		if job.ID >= m.nextID {
+			m.nextID = job.ID + 1
+		}
+	}
+	m.schedule()
+	m.save()
+}
+
+// watch merges the edits of other processes while this manager owns the
+// queue, and tries to take it over while it does not
+func (m *Manager) watch() {
+	ticker := time.NewTicker(ownerPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+		}
+
+		m.mu.Lock()
+		if m.owner == nil {
+			m.takeOwnership()
+		} else {
+			m.sync()
+		}
+		m.mu.Unlock()
+	}
+}
+
+// LoadQueue reads a persisted queue without running it
+func LoadQueue(path string) ([]Job, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Job{}, nil
+		}
+		return nil, err
+	}
+	var jobs []Job
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		return nil, fmt.Errorf("invalid download queue %s: %w", path, err)
+	}
+	return jobs, nil
+}
+
+// SaveQueue writes a queue to path
+func SaveQueue(path string, jobs []Job) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Add queues a download of repoID. A job already queued or running for the
+// same repository is returned instead of adding a duplicate.
+func (m *Manager) Add(repoID string) (Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owner == nil {
+		return m.file.Add(repoID)
+	}
+
+	// Jobs added by other processes take their IDs first
+	m.sync()
+	for _, job := range m.jobs {
+		if job.RepoID == repoID && !job.Finished() {
+			return *job, nil
+		}
+	}
+
+	job := &Job{
+		ID:        m.nextID,
+		RepoID:    repoID,
+		Status:    StatusQueued,
+		CreatedAt: time.Now(),
+	}
+	m.nextID++
+	m.jobs = append(m.jobs, job)
+	m.schedule()
+	if err := m.save(); err != nil {
+		return *job, err
+	}
+	return *job, nil
+}
+
+// List returns a snapshot of all jobs ordered by ID
+func (m *Manager) List() []Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	list := m.snapshot()
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Cancel stops a job and marks it canceled. Partially downloaded files are
+// kept in the cache so a later install resumes them.
+func (m *Manager) Cancel(id int) error {
+	return m.interrupt(id, StatusCanceled)
+}
+
+// Pause stops a running or queued job until it is resumed
+func (m *Manager) Pause(id int) error {
+	return m.interrupt(id, StatusPaused)
+}
+
+// Resume queues a paused, failed or canceled job again
+func (m *Manager) Resume(id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owner == nil {
+		return m.file.Resume(id)
+	}
+
+	job := m.find(id)
+	if job == nil {
+		return ErrNotFound
+	}
+	if job.Status == StatusQueued || job.Status == StatusRunning || job.Status == StatusCompleted {
+		return nil
+	}
+	job.Status = StatusQueued
+	job.Error = ""
+	job.FinishedAt = time.Time{}
+	m.schedule()
+	return m.save()
+}
+
+// Clear removes finished jobs from the queue
+func (m *Manager) Clear() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owner == nil {
+		return m.file.Clear()
+	}
+
+	kept := m.jobs[:0]
+	for _, job := range m.jobs {
+		if !job.Finished() {
+			kept = append(kept, job)
+		}
+	}
+	m.jobs = kept
+	return m.save()
+}
+
+// Close pauses running jobs so they resume on the next start, and gives up
+// the ownership of the queue
+func (m *Manager) Close() {
+	m.closeOnce.Do(func() { close(m.stop) })
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.owner == nil {
+		return
+	}
+
+	for id, run := range m.running {
+		if run.stopped {
+			continue
+		}
+		run.cancel()
+		run.stopped = true
+		if job := m.find(id); job != nil {
+			job.Status = StatusQueued
+			job.Speed = 0
+		}
+	}
+	m.save()
+	releaseLock(m.owner)
+	m.owner = nil
+}
+
+func (m *Manager) interrupt(id int, status Status) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.owner == nil {
+		if status == StatusCanceled {
+			return m.file.Cancel(id)
+		}
+		return m.file.Pause(id)
+	}
+
+	job := m.find(id)
+	if job == nil {
+		return ErrNotFound
+	}
+	if job.Finished() {
+		return fmt.Errorf("job %d already %s", id, job.Status)
+	}
+	m.stopJob(job, status)
+	m.schedule()
+	return m.save()
+}
+
+// stopJob stops a running or queued job with a paused or canceled status.
+// Callers hold m.mu.
+func (m *Manager) stopJob(job *Job, status Status) {
+	if run := m.running[job.ID]; run != nil && !run.stopped {
+		run.cancel()
+		run.stopped = true
+	}
+	job.Status = status
+	job.Speed = 0
+	if status == StatusCanceled {
+		job.FinishedAt = time.Now()
+		m.finished[status]++
+	}
+}
+
+// schedule starts queued jobs up to the parallelism limit. Stopped runs
+// that have not returned yet count toward the limit, and a job resumed
+// before its last run returned waits for it. Callers hold m.mu.
+func (m *Manager) schedule() {
+	for _, job := range m.jobs {
+		if len(m.running) >= m.parallelism {
+			return
+		}
+		if job.Status != StatusQueued || m.running[job.ID] != nil {
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		run := &jobRun{cancel: cancel}
+		m.running[job.ID] = run
+		job.Status = StatusRunning
+		job.Error = ""
+		job.StartedAt = time.Now()
+		job.lastSample = job.StartedAt
+		job.lastSampleBytes = job.Bytes
+		go m.run(ctx, run, job.ID, job.RepoID)
+	}
+}
+
+// run performs a job through the shared installer
+func (m *Manager) run(ctx context.Context, run *jobRun, id int, repoID string) {
+	_, err := m.installer.Install(ctx, repoID, func(p hf.Progress) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		// Progress of a stopped run no longer describes the job
+		if run.stopped {
+			return
+		}
+		if job := m.find(id); job != nil && job.Status == StatusRunning {
+			before := job.Bytes
+			job.observe(p)
+			if job.Bytes > before {
+				m.transferred += job.Bytes - before
+			}
+			if time.Since(m.lastSave) > 2*time.Second {
+				m.save()
+			}
+		}
+	})
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	defer run.cancel()
+	delete(m.running, id)
+	if m.owner == nil {
+		return
+	}
+
+	// A paused or canceled job has already been updated by interrupt
+	if job := m.find(id); job != nil && !run.stopped {
+		job.Speed = 0
+		job.FinishedAt = time.Now()
+		if err != nil {
+			job.Status = StatusFailed
+			job.Error = err.Error()
+		} else {
+			job.Status = StatusCompleted
+			job.Bytes = job.TotalBytes
+			job.FilesDone = job.FilesTotal
+		}
+		m.finished[job.Status]++
+	}
+	m.save()
+	m.schedule()
+}
+
+// observe records installer progress and updates the speed estimate
+func (j *Job) observe(p hf.Progress) {
+	j.File = p.File
+	j.Bytes = p.Bytes
+	j.TotalBytes = p.TotalBytes
+	j.FilesDone = p.FilesDone
+	j.FilesTotal = p.FilesTotal
+
+	now := time.Now()
+	elapsed := now.Sub(j.lastSample).Seconds()
+	if elapsed >= 1 {
+		rate := float64(j.Bytes-j.lastSampleBytes) / elapsed
+		if rate < 0 {
+			rate = 0
+		}
+		// Smooth the rate so the ETA does not jump around
+		if j.Speed == 0 {
+			j.Speed = rate
+		} else {
+			j.Speed = 0.7*j.Speed + 0.3*rate
+		}
+		j.lastSample = now
+		j.lastSampleBytes = j.Bytes
+	}
+}
+
+func (m *Manager) find(id int) *Job {
+	for _, job := range m.jobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
+// snapshot returns copies of the jobs, read from the file when another
+// process owns the queue. Callers hold m.mu.
+func (m *Manager) snapshot() []Job {
+	if m.owner == nil {
+		return m.file.List()
+	}
+	list := make([]Job, 0, len(m.jobs))
+	for _, job := range m.jobs {
+		list = append(list, *job)
+	}
+	return list
+}
+
+// save merges the edits other processes made to the queue file and writes
+// the queue. Callers hold m.mu and own the queue.
+func (m *Manager) save() error {
+	m.lastSave = time.Now()
+	return editQueue(m.path, func(onDisk []Job) ([]Job, error) {
+		if m.merge(onDisk) {
+			m.schedule()
+		}
+		return m.record(), nil
+	})
+}
+
+// sync merges the edits other processes made to the queue file, and writes
+// the queue when there were any. Callers hold m.mu and own the queue.
+func (m *Manager) sync() error {
+	changed := false
+	err := editQueue(m.path, func(onDisk []Job) ([]Job, error) {
+		if !m.merge(onDisk) {
+			return onDisk, nil
+		}
+		changed = true
+		m.schedule()
+		return m.record(), nil
+	})
+	if changed {
+		m.lastSave = time.Now()
+	}
+	return err
+}
+
+// record returns the jobs to write and remembers their statuses, to tell
+// the edits of other processes apart. Callers hold m.mu.
+func (m *Manager) record() []Job {
+	list := m.snapshot()
+	m.written = make(map[int]Status, len(list))
+	for _, job := range list {
+		m.written[job.ID] = job.Status
+	}
+	return list
+}
+
+// merge applies the edits other processes made to the queue file since it
+// was last written: added jobs, status changes by pause, cancel and resume,
+// and jobs removed by clear. It reports whether there were any. Callers
+// hold m.mu.
+func (m *Manager) merge(onDisk []Job) bool {
+	changed := false
+	present := make(map[int]bool, len(onDisk))
+	for _, disk := range onDisk {
+		present[disk.ID] = true
+		job := m.find(disk.ID)
+		written, known := m.written[disk.ID]
+		switch {
+		case job == nil && !known:
+			j := disk
+			m.jobs = append(m.jobs, &j)
+			if j.ID >= m.nextID {
+				m.nextID = j.ID + 1
+			}
+			changed = true
+		case job == nil, disk.Status == written, disk.Status == job.Status:
+			// Removed here, or not edited
+		case disk.Status == StatusPaused || disk.Status == StatusCanceled:
+			if !job.Finished() {
+				m.stopJob(job, disk.Status)
+				changed = true
+			}
+		case disk.Status == StatusQueued:
+			if job.Status != StatusRunning && job.Status != StatusCompleted {
+				job.Status = StatusQueued
+				job.Error = ""
+				job.FinishedAt = time.Time{}
+				changed = true
+			}
+		}
+	}
+
+	kept := m.jobs[:0]
+	for _, job := range m.jobs {
+		if _, known := m.written[job.ID]; known && !present[job.ID] && job.Finished() {
+			changed = true
+			continue
+		}
+		kept = append(kept, job)
+	}
+	m.jobs = kept
+	return changed
+}
@@ -0,0 +1,221 @@
+package downloads
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/hf"
+	"github.com/lmarques/efx-face-manager/internal/installer"
+)
+
+// stalledHub returns a Hub whose requests hang until the client gives up,
+// so jobs stay running until they are paused or canceled
+func stalledHub(t *testing.T) *hf.Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(srv.Close)
+	return hf.NewClientWithEndpoint(srv.URL)
+}
+
+// slowInstaller hands every install to the test, which reports its
+// progress and decides when it returns, however long after it was canceled
+type slowInstaller struct {
+	installs chan *slowInstall
+}
+
+type slowInstall struct {
+	repoID   string
+	progress func(hf.Progress)
+	finish   chan error
+}
+
+func (s *slowInstaller) Install(ctx context.Context, repoID string, onProgress func(hf.Progress)) (*installer.Result, error) {
+	install := &slowInstall{repoID: repoID, progress: onProgress, finish: make(chan error)}
+	s.installs <- install
+	return nil, <-install.finish
+}
+
+// next returns the next install started, or nil when none starts soon
+func (s *slowInstaller) next(wait time.Duration) *slowInstall {
+	select {
+	case install := <-s.installs:
+		return install
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+func newTestManager(t *testing.T, client *hf.Client, path string) *Manager {
+	t.Helper()
+	m := NewManager(installer.New(client, t.TempDir()), path, 1)
+	t.Cleanup(m.Close)
+	return m
+}
+
+func owns(m *Manager) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.owner != nil
+}
+
+// jobStatus returns the status of job id as m sees it
+func jobStatus(m *Manager, id int) Status {
+	for _, job := range m.List() {
+		if job.ID == id {
+			return job.Status
+		}
+	}
+	return ""
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestManagerSingleOwner(t *testing.T) {
+	client := stalledHub(t)
+	path := filepath.Join(t.TempDir(), "downloads.json")
+
+	owner := newTestManager(t, client, path)
+	other := newTestManager(t, client, path)
+	if !owns(owner) || owns(other) {
+		t.Fatalf("owner: %v, other: %v; want only the first manager to own the queue", owns(owner), owns(other))
+	}
+	if !QueueOwned(path) {
+		t.Fatal("QueueOwned = false with an owner running")
+	}
+
+	// Jobs added by a manager that does not own the queue run in the owner
+	first, err := other.Add("org/first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the owner to run the first job", func() bool { return jobStatus(owner, first.ID) == StatusRunning })
+
+	// So do the edits of the CLI
+	second, err := NewFileQueue(path).Add("org/second")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID == first.ID {
+		t.Fatalf("second job reused ID %d", first.ID)
+	}
+	waitFor(t, "the owner to queue the second job", func() bool { return jobStatus(owner, second.ID) == StatusQueued })
+
+	if err := other.Pause(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the owner to pause the first job", func() bool { return jobStatus(owner, first.ID) == StatusPaused })
+	waitFor(t, "the second job to start", func() bool { return jobStatus(owner, second.ID) == StatusRunning })
+
+	if err := NewFileQueue(path).Cancel(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the owner to cancel the second job", func() bool { return jobStatus(owner, second.ID) == StatusCanceled })
+
+	if err := NewFileQueue(path).Resume(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the owner to resume the first job", func() bool { return jobStatus(owner, first.ID) == StatusRunning })
+
+	// The other manager takes over when the owner exits
+	owner.Close()
+	waitFor(t, "the other manager to take over", func() bool { return owns(other) })
+	waitFor(t, "the new owner to run the first job", func() bool { return jobStatus(other, first.ID) == StatusRunning })
+	if status := jobStatus(other, second.ID); status != StatusCanceled {
+		t.Errorf("second job is %s after the takeover, want canceled", status)
+	}
+}
+
+func TestManagerClearFromOtherProcess(t *testing.T) {
+	client := stalledHub(t)
+	path := filepath.Join(t.TempDir(), "downloads.json")
+	owner := newTestManager(t, client, path)
+
+	job, err := owner.Add("org/model")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := owner.Cancel(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewFileQueue(path).Clear(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, "the owner to drop the cleared job", func() bool { return len(owner.List()) == 0 })
+}
+
+func TestManagerResumeWaitsForPausedRun(t *testing.T) {
+	slow := &slowInstaller{installs: make(chan *slowInstall)}
+	m := NewManager(slow, filepath.Join(t.TempDir(), "downloads.json"), 1)
+	t.Cleanup(m.Close)
+
+	job, err := m.Add("org/first")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := slow.next(5 * time.Second)
+	if first == nil {
+		t.Fatal("the job did not start")
+	}
+	if _, err := m.Add("org/second"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first run keeps writing after it is paused
+	if err := m.Pause(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Resume(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if install := slow.next(100 * time.Millisecond); install != nil {
+		t.Fatalf("%s started while the paused run had not returned", install.repoID)
+	}
+	first.progress(hf.Progress{Bytes: 500, TotalBytes: 1000})
+	for _, j := range m.List() {
+		if j.Bytes != 0 {
+			t.Errorf("job %d took %d bytes from the paused run", j.ID, j.Bytes)
+		}
+	}
+	if status := jobStatus(m, job.ID); status != StatusQueued {
+		t.Errorf("resumed job is %s while the paused run returns, want queued", status)
+	}
+
+	// The job runs again once the paused run returns, and still alone
+	first.finish <- context.Canceled
+	again := slow.next(5 * time.Second)
+	if again == nil || again.repoID != "org/first" {
+		t.Fatalf("started %+v after the paused run returned, want the resumed job", again)
+	}
+	if install := slow.next(100 * time.Millisecond); install != nil {
+		t.Fatalf("%s started beyond the parallelism limit", install.repoID)
+	}
+	again.progress(hf.Progress{Bytes: 100, TotalBytes: 1000})
+	for _, j := range m.List() {
+		if j.ID == job.ID && (j.Status != StatusRunning || j.Bytes != 100) {
+			t.Errorf("resumed job %s with %d bytes, want running with the progress of the new run", j.Status, j.Bytes)
+		}
+	}
+
+	again.finish <- nil
+	waitFor(t, "the job to complete", func() bool { return jobStatus(m, job.ID) == StatusCompleted })
+	second := slow.next(5 * time.Second)
+	if second == nil || second.repoID != "org/second" {
+		t.Fatalf("started %+v after the first job, want the second", second)
+	}
+	second.finish <- nil
+}
@@ -0,0 +1,38 @@
+package downloads
+
+import (
+	"strconv"
+
+	"github.com/lmarques/efx-face-manager/internal/metrics"
+)
+
+// CollectMetrics writes the number of jobs in each state, the progress of
+// unfinished jobs and the totals since the manager was created
+func (m *Manager) CollectMetrics(w *metrics.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	jobs := m.snapshot()
+	counts := make(map[Status]int)
+	for _, job := range jobs {
+		counts[job.Status]++
+	}
+	for _, status := range []Status{StatusQueued, StatusRunning, StatusPaused, StatusCompleted, StatusFailed, StatusCanceled} {
+		w.Gauge("efx_download_jobs", "Download jobs in the queue by status", float64(counts[status]), "status", string(status))
+	}
+
+	for _, job := range jobs {
+		if job.Finished() {
+			continue
+		}
+		labels := []string{"id", strconv.Itoa(job.ID), "repo", job.RepoID}
+		w.Gauge("efx_download_bytes", "Bytes downloaded by an unfinished job", float64(job.Bytes), labels...)
+		w.Gauge("efx_download_size_bytes", "Total size of an unfinished job, 0 until known", float64(job.TotalBytes), labels...)
+		w.Gauge("efx_download_speed_bytes_per_second", "Current download speed of a job", job.Speed, labels...)
+	}
+
+	for _, status := range []Status{StatusCompleted, StatusFailed, StatusCanceled} {
+		w.Counter("efx_downloads_finished_total", "Download jobs finished by this process by status", float64(m.finished[status]), "status", string(status))
+	}
+	w.Counter("efx_download_transferred_bytes_total", "Bytes downloaded by this process", float64(m.transferred))
+}
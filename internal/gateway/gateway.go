@@ -0,0 +1,339 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/metrics"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// maxBodySize caps request bodies buffered to read the model field
+const maxBodySize = 512 << 20
+
+// Gateway is an OpenAI-compatible reverse proxy that serves every running
+// instance on one address and routes requests by their model field
+type Gateway struct {
+	servers    server.Backend
+	loader     *Loader // starts stopped template models on demand, may be nil
+	httpClient *http.Client
+	requests   *metrics.RequestMetrics
+	metrics    http.Handler // serves /metrics when enabled
+
+	mu      sync.Mutex
+	aliases map[string]int // upstream model IDs by port
+}
+
+// New creates a gateway routing to the servers of backend. With a loader,
+// requests for stopped template models start them first.
+func New(servers server.Backend, loader *Loader) *Gateway {
+	return &Gateway{
+		servers:    servers,
+		loader:     loader,
+		httpClient: &http.Client{Timeout: 3 * time.Second},
+		requests:   metrics.NewRequestMetrics(),
+		aliases:    make(map[string]int),
+	}
+}
+
+// Handler returns the HTTP handler serving the OpenAI routes
+func (g *Gateway) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("GET /v1/models", g.handleModels)
+	mux.HandleFunc("POST /v1/chat/completions", g.handleProxy)
+	mux.HandleFunc("POST /v1/completions", g.handleProxy)
+	mux.HandleFunc("POST /v1/embeddings", g.handleProxy)
+	mux.HandleFunc("POST /v1/images/", g.handleProxy)
+	mux.HandleFunc("POST /v1/audio/", g.handleProxy)
+	if g.metrics != nil {
+		mux.Handle("GET /metrics", g.metrics)
+	}
+	return mux
+}
+
+// modelEntry is an item of the OpenAI model list
+type modelEntry struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// handleModels merges the model lists of all running instances
+func (g *Gateway) handleModels(w http.ResponseWriter, r *http.Request) {
+	data := []modelEntry{}
+	seen := make(map[string]bool)
+	add := func(entry modelEntry) {
+		if !seen[entry.ID] {
+			seen[entry.ID] = true
+			data = append(data, entry)
+		}
+	}
+
+	for _, inst := range g.running() {
+		add(modelEntry{ID: inst.Model, Object: "model", Created: inst.StartedAt.Unix(), OwnedBy: "efx-face"})
+		for _, entry := range g.fetchModels(r.Context(), inst) {
+			if entry.Created == 0 {
+				entry.Created = inst.StartedAt.Unix()
+			}
+			if entry.OwnedBy == "" {
+				entry.OwnedBy = "efx-face"
+			}
+			entry.Object = "model"
+			add(entry)
+		}
+	}
+
+	// Templates that would be loaded on first request
+	if g.loader != nil {
+		for _, t := range g.loader.Available() {
+			add(modelEntry{ID: t.ModelName, Object: "model", OwnedBy: "efx-face"})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+// handleProxy forwards a request to the instance serving its model and
+// records its status and duration
+func (g *Gateway) handleProxy(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	modelName := g.forward(rec, r)
+	g.requests.Observe(modelName, strconv.Itoa(rec.status), time.Since(start))
+}
+
+// forward routes a request and returns the model it was routed to, or ""
+// when no model could be found
+func (g *Gateway) forward(w http.ResponseWriter, r *http.Request) string {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusRequestEntityTooLarge, "request body too large", "")
+		return ""
+	}
+
+	modelName, err := requestModel(r.Header.Get("Content-Type"), body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error(), "")
+		return ""
+	}
+
+	inst := g.resolve(r.Context(), modelName)
+	acquired := false
+	if inst == nil && g.loader != nil && g.loader.Template(modelName) != nil {
+		inst, err = g.loader.Load(r.Context(), modelName)
+		acquired = err == nil
+		if err != nil {
+			if errors.Is(err, context.Canceled) {
+				return modelName
+			}
+			writeError(w, http.StatusServiceUnavailable, fmt.Sprintf("failed to load %s: %v", modelName, err), "model_load_failed")
+			return modelName
+		}
+	}
+	if inst == nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("model %q is not running", modelName), "model_not_found")
+		return ""
+	}
+
+	if g.loader != nil {
+		if !acquired {
+			g.loader.Acquire(inst)
+		}
+		defer g.loader.Release(inst)
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	g.proxy(inst).ServeHTTP(w, r)
+	return inst.Model
+}
+
+// proxy returns a reverse proxy to inst that flushes streamed responses
+// immediately so SSE chunks reach the client as they are produced
+func (g *Gateway) proxy(inst *server.Instance) *httputil.ReverseProxy {
+	target := upstreamURL(inst)
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.SetXForwarded()
+		},
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			writeError(w, http.StatusBadGateway, fmt.Sprintf("upstream %s: %v", inst.Model, err), "upstream_error")
+		},
+	}
+}
+
+// resolve finds the running instance serving modelName. Names match the
+// instance model, the last path element of a repo ID, or any model ID the
+// upstream server reports.
+func (g *Gateway) resolve(ctx context.Context, modelName string) *server.Instance {
+	instances := g.running()
+	if inst := matchInstance(instances, modelName); inst != nil {
+		return inst
+	}
+
+	if inst := g.matchAlias(instances, modelName); inst != nil {
+		return inst
+	}
+
+	// Unknown name: refresh the upstream model IDs and try again
+	for _, inst := range instances {
+		g.fetchModels(ctx, inst)
+	}
+	return g.matchAlias(instances, modelName)
+}
+
+func (g *Gateway) matchAlias(instances []*server.Instance, modelName string) *server.Instance {
+	g.mu.Lock()
+	port, ok := g.aliases[modelName]
+	g.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	for _, inst := range instances {
+		if inst.Port == port {
+			return inst
+		}
+	}
+	return nil
+}
+
+// matchInstance matches modelName against the instance model names
+func matchInstance(instances []*server.Instance, modelName string) *server.Instance {
+	base := modelName
+	if i := strings.LastIndex(base, "/"); i >= 0 {
+		base = base[i+1:]
+	}
+	for _, inst := range instances {
+		if inst.Model == modelName {
+			return inst
+		}
+	}
+	for _, inst := range instances {
+		if strings.EqualFold(inst.Model, base) {
+			return inst
+		}
+	}
+	return nil
+}
+
+// fetchModels queries the model list of inst and records its IDs as aliases
+func (g *Gateway) fetchModels(ctx context.Context, inst *server.Instance) []modelEntry {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, upstreamURL(inst).String()+"/v1/models", nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil
+	}
+
+	var list struct {
+		Data []modelEntry `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil
+	}
+
+	g.mu.Lock()
+	for _, entry := range list.Data {
+		g.aliases[entry.ID] = inst.Port
+	}
+	g.mu.Unlock()
+	return list.Data
+}
+
+// running returns the instances whose process is alive
+func (g *Gateway) running() []*server.Instance {
+	var running []*server.Instance
+	for _, inst := range g.servers.List() {
+		if inst.Running {
+			running = append(running, inst)
+		}
+	}
+	return running
+}
+
+// upstreamURL returns the base URL of an instance, dialing loopback when
+// the server listens on all interfaces
+func upstreamURL(inst *server.Instance) *url.URL {
+	host := inst.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	return &url.URL{Scheme: "http", Host: net.JoinHostPort(host, strconv.Itoa(inst.Port))}
+}
+
+// requestModel extracts the model field from a JSON or multipart body
+func requestModel(contentType string, body []byte) (string, error) {
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType == "multipart/form-data" {
+		form := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := form.NextPart()
+			if err != nil {
+				break
+			}
+			if part.FormName() == "model" {
+				value, _ := io.ReadAll(io.LimitReader(part, 1024))
+				if name := strings.TrimSpace(string(value)); name != "" {
+					return name, nil
+				}
+			}
+		}
+		return "", fmt.Errorf("missing model field")
+	}
+
+	var req struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", fmt.Errorf("invalid JSON body: %v", err)
+	}
+	if req.Model == "" {
+		return "", fmt.Errorf("missing model field")
+	}
+	return req.Model, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error in the OpenAI error format
+func writeError(w http.ResponseWriter, status int, message, code string) {
+	errType := "invalid_request_error"
+	if status >= 500 {
+		errType = "server_error"
+	}
+	apiErr := map[string]any{"message": message, "type": errType, "code": nil}
+	if code != "" {
+		apiErr["code"] = code
+	}
+	writeJSON(w, status, map[string]any{"error": apiErr})
+}
@@ -0,0 +1,275 @@
+package gateway
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/model"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// fakeBackend runs no processes. Like the daemon client, it returns a new
+// snapshot of an instance on every call.
+type fakeBackend struct {
+	server.Backend // methods the tests do not use
+
+	mu      sync.Mutex
+	servers map[int]*fakeServer
+	pid     int
+	ready   bool // whether started servers are ready at once
+}
+
+type fakeServer struct {
+	model, host string
+	pid         int
+	ready       bool
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{servers: make(map[int]*fakeServer), ready: true}
+}
+
+func (b *fakeBackend) Start(cfg server.Config) (*server.Instance, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.pid++
+	b.servers[cfg.Port] = &fakeServer{model: cfg.Model, host: cfg.Host, pid: b.pid, ready: b.ready}
+	return b.instance(cfg.Port), nil
+}
+
+func (b *fakeBackend) Stop(port int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.servers, port)
+	return nil
+}
+
+func (b *fakeBackend) Get(port int) *server.Instance {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.instance(port)
+}
+
+func (b *fakeBackend) List() []*server.Instance {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var list []*server.Instance
+	for port := range b.servers {
+		list = append(list, b.instance(port))
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Port < list[j].Port })
+	return list
+}
+
+func (b *fakeBackend) IsPortInUse(port int) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.servers[port] != nil
+}
+
+func (b *fakeBackend) NextAvailablePort(port int) int {
+	for b.IsPortInUse(port) {
+		port++
+	}
+	return port
+}
+
+// setReady marks the servers as ready
+func (b *fakeBackend) setReady() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ready = true
+	for _, s := range b.servers {
+		s.ready = true
+	}
+}
+
+func (b *fakeBackend) instance(port int) *server.Instance {
+	s := b.servers[port]
+	if s == nil {
+		return nil
+	}
+	state := server.StateStarting
+	if s.ready {
+		state = server.StateReady
+	}
+	return &server.Instance{Model: s.model, Host: s.host, Port: port, PID: s.pid, Running: true, State: state}
+}
+
+// startUpstream runs handler as the server of modelName in servers and
+// returns its port
+func startUpstream(t *testing.T, servers *fakeBackend, modelName string, handler http.Handler) int {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	if _, err := servers.Start(server.Config{Model: modelName, Port: port, Host: "127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	return port
+}
+
+// echoUpstream answers completions with its name and lists ids as its models
+func echoUpstream(name string, ids ...string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/models", func(w http.ResponseWriter, r *http.Request) {
+		var data []modelEntry
+		for _, id := range ids {
+			data = append(data, modelEntry{ID: id})
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"data": data})
+	})
+	mux.HandleFunc("POST /v1/chat/completions", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, name)
+	})
+	return mux
+}
+
+func post(t *testing.T, url, body string) (int, string) {
+	t.Helper()
+	resp, err := http.Post(url+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	data, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(data)
+}
+
+func TestGatewayRouting(t *testing.T) {
+	servers := newFakeBackend()
+	startUpstream(t, servers, "alpha", echoUpstream("alpha"))
+	startUpstream(t, servers, "beta", echoUpstream("beta", "org/beta-4bit"))
+	gw := httptest.NewServer(New(servers, nil).Handler())
+	defer gw.Close()
+
+	for _, tt := range []struct {
+		model, want string
+	}{
+		{"alpha", "alpha"},
+		{"mlx-community/ALPHA", "alpha"}, // last element of a repo ID
+		{"beta", "beta"},
+		{"org/beta-4bit", "beta"}, // ID reported by the upstream
+	} {
+		status, body := post(t, gw.URL, fmt.Sprintf(`{"model": %q}`, tt.model))
+		if status != http.StatusOK || body != tt.want {
+			t.Errorf("%s: %d %q, want it routed to %s", tt.model, status, body, tt.want)
+		}
+	}
+}
+
+func TestGatewayErrors(t *testing.T) {
+	servers := newFakeBackend()
+	down := httptest.NewServer(http.NotFoundHandler())
+	downURL, _ := url.Parse(down.URL)
+	down.Close()
+	downPort, _ := strconv.Atoi(downURL.Port())
+	servers.Start(server.Config{Model: "epsilon", Port: downPort, Host: "127.0.0.1"})
+
+	loader := NewLoader(servers, LoaderOptions{
+		ModelDir:  t.TempDir(),
+		Templates: []model.Template{{Name: "gamma", ModelName: "gamma", ModelType: model.TypeLM}},
+	})
+	defer loader.Close()
+	gw := httptest.NewServer(New(servers, loader).Handler())
+	defer gw.Close()
+
+	for _, tt := range []struct {
+		name, body string
+		status     int
+		code       any
+	}{
+		{"unknown model", `{"model": "delta"}`, http.StatusNotFound, "model_not_found"},
+		{"upstream down", `{"model": "epsilon"}`, http.StatusBadGateway, "upstream_error"},
+		{"load failure", `{"model": "gamma"}`, http.StatusServiceUnavailable, "model_load_failed"},
+		{"missing model", `{"messages": []}`, http.StatusBadRequest, nil},
+		{"invalid body", `{`, http.StatusBadRequest, nil},
+	} {
+		status, body := post(t, gw.URL, tt.body)
+		var resp struct {
+			Error struct {
+				Message string `json:"message"`
+				Code    any    `json:"code"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(body), &resp); err != nil {
+			t.Errorf("%s: %v in %q", tt.name, err, body)
+			continue
+		}
+		if status != tt.status || resp.Error.Code != tt.code {
+			t.Errorf("%s: %d %v (%s), want %d %v", tt.name, status, resp.Error.Code, resp.Error.Message, tt.status, tt.code)
+		}
+	}
+}
+
+func TestGatewayStreamsEvents(t *testing.T) {
+	next := make(chan struct{})
+	finished := make(chan struct{}) // lets the upstream return when the test fails
+	servers := newFakeBackend()
+	startUpstream(t, servers, "alpha", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for i := range 3 {
+			fmt.Fprintf(w, "data: %d\n\n", i)
+			w.(http.Flusher).Flush()
+			select {
+			case <-next:
+			case <-finished:
+				return
+			}
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	gw := httptest.NewServer(New(servers, nil).Handler())
+	defer gw.Close()
+	defer close(finished)
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(gw.URL+"/v1/chat/completions", "application/json", strings.NewReader(`{"model": "alpha", "stream": true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Each event must arrive while the upstream is still holding the
+	// stream open, which only happens if the gateway flushes it
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			if line := scanner.Text(); line != "" {
+				select {
+				case lines <- line:
+				case <-finished:
+					return
+				}
+			}
+		}
+	}()
+	for i := range 3 {
+		select {
+		case line := <-lines:
+			if want := fmt.Sprintf("data: %d", i); line != want {
+				t.Fatalf("got %q, want %q", line, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %d was not flushed", i)
+		}
+		next <- struct{}{}
+	}
+	if line := <-lines; line != "data: [DONE]" {
+		t.Errorf("got %q after the events, want the end of the stream", line)
+	}
+}
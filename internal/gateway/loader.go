@@ -0,0 +1,435 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/model"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// ErrNoCapacity is returned when a model cannot be loaded without exceeding
+// the limits and no idle instance can be evicted
+var ErrNoCapacity = errors.New("no capacity to load model")
+
+// LoaderOptions configures on-demand loading
+type LoaderOptions struct {
+	ModelDir     string
+	Templates    []model.Template
+	IdleTimeout  time.Duration // stop loaded instances unused for this long, 0 disables
+	MaxModels    int           // maximum running instances, 0 means unlimited
+	MaxMemory    int64         // maximum total size in bytes of running models, 0 means unlimited
+	ReadyTimeout time.Duration // how long a request waits for a model to load
+	BasePort     int           // first port tried for templates without a port
+}
+
+// Loader starts template models when the gateway receives a request for
+// them and stops instances it started once they sit idle or must make room
+// for another model
+type Loader struct {
+	servers server.Backend
+	opts    LoaderOptions
+	startMu sync.Mutex // serializes eviction and start so limits hold
+
+	mu      sync.Mutex
+	loading map[string]*loadCall         // in-flight loads by template name
+	loaded  map[instanceKey]*loadedState // instances started by the loader
+	sizes   map[string]int64
+
+	done chan struct{}
+	once sync.Once
+}
+
+// instanceKey identifies a server process. The port alone is not enough:
+// an instance the loader started can exit or be stopped elsewhere and
+// another one take its port.
+type instanceKey struct {
+	port int
+	pid  int
+}
+
+func keyOf(inst *server.Instance) instanceKey {
+	return instanceKey{port: inst.Port, pid: inst.PID}
+}
+
+// loadedState is the bookkeeping of an instance started by the loader
+type loadedState struct {
+	lastUsed time.Time
+	active   int // requests in flight
+}
+
+// loadCall lets concurrent requests for the same model share one start
+type loadCall struct {
+	done     chan struct{}
+	inst     *server.Instance
+	err      error
+	waiters  int  // callers that get a reference when the load finishes
+	finished bool // inst and err are set and the waiters hold their reference
+}
+
+// NewLoader creates a loader starting servers through backend
+func NewLoader(servers server.Backend, opts LoaderOptions) *Loader {
+	if opts.ReadyTimeout == 0 {
+		opts.ReadyTimeout = 10 * time.Minute
+	}
+	if opts.BasePort == 0 {
+		opts.BasePort = 8000
+	}
+	l := &Loader{
+		servers: servers,
+		opts:    opts,
+		loading: make(map[string]*loadCall),
+		loaded:  make(map[instanceKey]*loadedState),
+		sizes:   make(map[string]int64),
+		done:    make(chan struct{}),
+	}
+	if opts.IdleTimeout > 0 {
+		go l.reapIdle()
+	}
+	return l
+}
+
+// Close stops the idle reaper. Instances keep running.
+func (l *Loader) Close() {
+	l.once.Do(func() { close(l.done) })
+}
+
+// Template returns the template serving modelName, or nil if there is none
+func (l *Loader) Template(modelName string) *model.Template {
+	base := modelName
+	if i := strings.LastIndex(base, "/"); i >= 0 {
+		base = base[i+1:]
+	}
+	for i, t := range l.opts.Templates {
+		if t.Name == modelName || t.ModelName == modelName || strings.EqualFold(t.ModelName, base) {
+			return &l.opts.Templates[i]
+		}
+	}
+	return nil
+}
+
+// Available returns the templates whose models are installed
+func (l *Loader) Available() []model.Template {
+	store := model.NewStore(l.opts.ModelDir)
+	var available []model.Template
+	for _, t := range l.opts.Templates {
+		if store.Exists(t.ModelName) {
+			available = append(available, t)
+		}
+	}
+	return available
+}
+
+// Load starts the template serving modelName and waits until it answers
+// requests. Concurrent calls for the same model share one start. The
+// instance is returned acquired, so it cannot be evicted before the caller
+// uses it; the caller must Release it.
+func (l *Loader) Load(ctx context.Context, modelName string) (*server.Instance, error) {
+	tmpl := l.Template(modelName)
+	if tmpl == nil {
+		return nil, fmt.Errorf("no template for model %q", modelName)
+	}
+
+	l.mu.Lock()
+	call, ok := l.loading[tmpl.Name]
+	if !ok {
+		call = &loadCall{done: make(chan struct{})}
+		l.loading[tmpl.Name] = call
+		go l.finish(call, tmpl)
+	}
+	call.waiters++
+	l.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.inst, call.err
+	case <-ctx.Done():
+		l.mu.Lock()
+		if !call.finished {
+			call.waiters--
+		} else if call.err == nil {
+			l.release(call.inst) // the reference taken for this caller
+		}
+		l.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// finish runs the start of a shared load and hands a reference to the
+// instance to each caller still waiting for it
+func (l *Loader) finish(call *loadCall, tmpl *model.Template) {
+	inst, err := l.start(tmpl)
+
+	l.mu.Lock()
+	call.inst, call.err = inst, err
+	call.finished = true
+	delete(l.loading, tmpl.Name)
+	if err == nil {
+		if state := l.loaded[keyOf(inst)]; state != nil {
+			// The reference of start goes to the first caller
+			state.active += call.waiters - 1
+			state.lastUsed = time.Now()
+		}
+	}
+	l.mu.Unlock()
+	close(call.done)
+}
+
+// start makes room for tmpl, starts it and waits for readiness. The
+// instance is returned with one reference held.
+func (l *Loader) start(tmpl *model.Template) (*server.Instance, error) {
+	if !model.NewStore(l.opts.ModelDir).Exists(tmpl.ModelName) {
+		return nil, fmt.Errorf("model %s is not installed", tmpl.ModelName)
+	}
+	inst, err := l.startInstance(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := l.waitReady(inst); err != nil {
+		l.stop(inst)
+		return nil, err
+	}
+	return inst, nil
+}
+
+// startInstance evicts as needed and launches the server for tmpl
+func (l *Loader) startInstance(tmpl *model.Template) (*server.Instance, error) {
+	l.startMu.Lock()
+	defer l.startMu.Unlock()
+
+	if err := l.makeRoom(tmpl.ModelName); err != nil {
+		return nil, err
+	}
+
+	cfg := server.FromTemplate(tmpl, l.opts.ModelDir)
+	if cfg.Host == "" {
+		cfg.Host = "127.0.0.1"
+	}
+	if cfg.Port == 0 || l.servers.IsPortInUse(cfg.Port) {
+		cfg.Port = l.servers.NextAvailablePort(l.opts.BasePort)
+	}
+
+	inst, err := l.servers.Start(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	l.loaded[keyOf(inst)] = &loadedState{lastUsed: time.Now(), active: 1}
+	l.mu.Unlock()
+	return inst, nil
+}
+
+// waitReady waits until the instance reports ready, exits or the ready
+// timeout passes
+func (l *Loader) waitReady(inst *server.Instance) error {
+	deadline := time.Now().Add(l.opts.ReadyTimeout)
+	for time.Now().Before(deadline) {
+		current := l.servers.Get(inst.Port)
+		if current == nil || !current.Running || current.CurrentState() == server.StateStopped {
+			return fmt.Errorf("%s exited while loading", inst.Model)
+		}
+		if current.CurrentState() == server.StateReady {
+			return nil
+		}
+		time.Sleep(500 * time.Millisecond)
+	}
+	return fmt.Errorf("%s did not become ready within %s", inst.Model, l.opts.ReadyTimeout)
+}
+
+// makeRoom evicts least recently used loaded instances until modelName fits
+// within the model count and memory limits
+func (l *Loader) makeRoom(modelName string) error {
+	need := l.modelSize(modelName)
+	for {
+		running := l.running()
+		count := len(running)
+		var used int64
+		for _, inst := range running {
+			used += l.modelSize(inst.Model)
+		}
+
+		fitsCount := l.opts.MaxModels == 0 || count < l.opts.MaxModels
+		fitsMemory := l.opts.MaxMemory == 0 || used+need <= l.opts.MaxMemory
+		if fitsCount && fitsMemory {
+			return nil
+		}
+
+		victim := l.evictionCandidate(running)
+		if victim == nil {
+			if count == 0 {
+				// A single model larger than the limit still gets to run
+				return nil
+			}
+			return fmt.Errorf("%w: %d models running, %s of %s in use", ErrNoCapacity,
+				count, formatGB(used), formatGB(l.opts.MaxMemory))
+		}
+		l.stop(victim)
+		l.waitReleased(victim)
+	}
+}
+
+// waitReleased waits for a stopped instance to stop accepting connections so
+// its memory and port are free before another model starts
+func (l *Loader) waitReleased(inst *server.Instance) {
+	addr := upstreamURL(inst).Host
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err != nil {
+			return
+		}
+		conn.Close()
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// evictionCandidate returns the least recently used idle instance started
+// by the loader
+func (l *Loader) evictionCandidate(running []*server.Instance) *server.Instance {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.prune(running)
+
+	var candidates []*server.Instance
+	for _, inst := range running {
+		if state := l.loaded[keyOf(inst)]; state != nil && state.active == 0 {
+			candidates = append(candidates, inst)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return l.loaded[keyOf(candidates[i])].lastUsed.Before(l.loaded[keyOf(candidates[j])].lastUsed)
+	})
+	return candidates[0]
+}
+
+// prune forgets the instances that are no longer running, so a server that
+// later takes their port is not mistaken for one the loader started. l.mu
+// must be held.
+func (l *Loader) prune(running []*server.Instance) {
+	alive := make(map[instanceKey]bool, len(running))
+	for _, inst := range running {
+		alive[keyOf(inst)] = true
+	}
+	for key := range l.loaded {
+		if !alive[key] {
+			delete(l.loaded, key)
+		}
+	}
+}
+
+// Acquire marks a request to inst as in flight
+func (l *Loader) Acquire(inst *server.Instance) {
+	l.mu.Lock()
+	if state := l.loaded[keyOf(inst)]; state != nil {
+		state.active++
+		state.lastUsed = time.Now()
+	}
+	l.mu.Unlock()
+}
+
+// Release marks a request to inst as finished
+func (l *Loader) Release(inst *server.Instance) {
+	l.mu.Lock()
+	l.release(inst)
+	l.mu.Unlock()
+}
+
+// release is Release with l.mu held
+func (l *Loader) release(inst *server.Instance) {
+	if state := l.loaded[keyOf(inst)]; state != nil {
+		if state.active > 0 {
+			state.active--
+		}
+		state.lastUsed = time.Now()
+	}
+}
+
+// reapIdle stops loaded instances that have been idle past the timeout
+func (l *Loader) reapIdle() {
+	interval := l.opts.IdleTimeout / 4
+	if interval > time.Minute {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+		}
+
+		running := l.running()
+		var idle []*server.Instance
+		l.mu.Lock()
+		l.prune(running)
+		for _, inst := range running {
+			state := l.loaded[keyOf(inst)]
+			if state != nil && state.active == 0 && time.Since(state.lastUsed) > l.opts.IdleTimeout {
+				idle = append(idle, inst)
+			}
+		}
+		l.mu.Unlock()
+
+		for _, inst := range idle {
+			l.stop(inst)
+		}
+	}
+}
+
+// stop stops a loaded instance and forgets its bookkeeping. A server that
+// has since taken its port is left running.
+func (l *Loader) stop(inst *server.Instance) {
+	if current := l.servers.Get(inst.Port); current != nil && current.PID == inst.PID {
+		l.servers.Stop(inst.Port)
+	}
+	l.mu.Lock()
+	delete(l.loaded, keyOf(inst))
+	l.mu.Unlock()
+}
+
+// running returns the instances whose process is alive
+func (l *Loader) running() []*server.Instance {
+	var running []*server.Instance
+	for _, inst := range l.servers.List() {
+		if inst.Running {
+			running = append(running, inst)
+		}
+	}
+	return running
+}
+
+// modelSize returns the on-disk size of an installed model, which is a
+// close estimate of the memory it needs once loaded
+func (l *Loader) modelSize(name string) int64 {
+	l.mu.Lock()
+	size, ok := l.sizes[name]
+	l.mu.Unlock()
+	if ok {
+		return size
+	}
+
+	size = model.DiskSize(filepath.Join(l.opts.ModelDir, name))
+
+	l.mu.Lock()
+	l.sizes[name] = size
+	l.mu.Unlock()
+	return size
+}
+
+func formatGB(bytes int64) string {
+	return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+}
@@ -0,0 +1,134 @@
+package gateway
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/model"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// newTestLoader returns a loader for a template of the installed model alpha
+func newTestLoader(t *testing.T, servers server.Backend) *Loader {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "alpha"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	l := NewLoader(servers, LoaderOptions{
+		ModelDir:     dir,
+		Templates:    []model.Template{{Name: "alpha", ModelName: "alpha", ModelType: model.TypeLM}},
+		ReadyTimeout: 10 * time.Second,
+		BasePort:     18300,
+	})
+	t.Cleanup(l.Close)
+	return l
+}
+
+// references returns the requests the loader counts as in flight to inst,
+// or -1 when it does not track inst
+func references(l *Loader, inst *server.Instance) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if state := l.loaded[keyOf(inst)]; state != nil {
+		return state.active
+	}
+	return -1
+}
+
+func TestLoaderLoadHoldsReference(t *testing.T) {
+	servers := newFakeBackend()
+	servers.ready = false
+	l := newTestLoader(t, servers)
+
+	// Three requests share one load; one of them gives up while it loads
+	ctx, cancel := context.WithCancel(context.Background())
+	results := make(chan *server.Instance, 3)
+	for i := range 3 {
+		go func() {
+			reqCtx := context.Background()
+			if i == 0 {
+				reqCtx = ctx
+			}
+			inst, _ := l.Load(reqCtx, "alpha")
+			results <- inst
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		l.mu.Lock()
+		call := l.loading["alpha"]
+		waiting := call != nil && call.waiters == 3
+		l.mu.Unlock()
+		if waiting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the requests to share the load")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	servers.setReady()
+
+	var loaded []*server.Instance
+	for range 3 {
+		if inst := <-results; inst != nil {
+			loaded = append(loaded, inst)
+		}
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("%d requests got an instance, want 2", len(loaded))
+	}
+	if n := len(servers.List()); n != 1 {
+		t.Fatalf("%d servers started, want 1", n)
+	}
+	inst := loaded[0]
+
+	if n := references(l, inst); n != 2 {
+		t.Fatalf("%d references after the load, want one for each waiting request", n)
+	}
+	if victim := l.evictionCandidate(l.running()); victim != nil {
+		t.Fatal("an instance in use was picked for eviction")
+	}
+	for _, inst := range loaded {
+		l.Release(inst)
+	}
+	if victim := l.evictionCandidate(l.running()); victim == nil || victim.PID != inst.PID {
+		t.Fatalf("eviction candidate %v, want the released instance", victim)
+	}
+}
+
+func TestLoaderForgetsReplacedInstance(t *testing.T) {
+	servers := newFakeBackend()
+	l := newTestLoader(t, servers)
+
+	inst, err := l.Load(context.Background(), "alpha")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Release(inst)
+
+	// The instance is stopped elsewhere and another server takes its port
+	servers.Stop(inst.Port)
+	other, _ := servers.Start(server.Config{Model: "beta", Port: inst.Port, Host: "127.0.0.1"})
+
+	if victim := l.evictionCandidate(l.running()); victim != nil {
+		t.Fatalf("evicting %s on port %d, which the loader did not start", victim.Model, victim.Port)
+	}
+	if n := references(l, inst); n != -1 {
+		t.Errorf("the stopped instance is still tracked with %d references", n)
+	}
+	l.Acquire(other)
+	if n := references(l, other); n != -1 {
+		t.Errorf("the other server is tracked with %d references", n)
+	}
+
+	l.stop(inst)
+	if servers.Get(other.Port) == nil {
+		t.Error("stopping the old instance stopped the server that took its port")
+	}
+}
@@ -0,0 +1,44 @@
+package gateway
+
+import (
+	"net/http"
+
+	"github.com/lmarques/efx-face-manager/internal/metrics"
+)
+
+// EnableMetrics serves the gateway's request metrics, followed by those of
+// collectors, at /metrics. It must be called before Handler.
+func (g *Gateway) EnableMetrics(collectors ...metrics.Collector) {
+	g.metrics = metrics.Handler(append([]metrics.Collector{g}, collectors...)...)
+}
+
+// CollectMetrics writes the count and latency of proxied requests by model.
+// Requests that matched no model have an empty model label.
+func (g *Gateway) CollectMetrics(w *metrics.Writer) {
+	g.requests.Write(w, "efx_gateway", "model", "Gateway")
+}
+
+// statusRecorder remembers the status code written to a response
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	wrote  bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wrote {
+		r.status = status
+		r.wrote = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wrote = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController flush streamed responses
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
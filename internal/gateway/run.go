@@ -0,0 +1,40 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Run serves the gateway on addr until interrupted
+func Run(addr string, g *Gateway) error {
+	if g.loader != nil {
+		defer g.loader.Close()
+	}
+	httpServer := &http.Server{
+		Addr:    addr,
+		Handler: g.Handler(),
+	}
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	go func() {
+		<-sigCh
+		ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+		defer done()
+		httpServer.Shutdown(ctx)
+	}()
+
+	fmt.Printf("efx-face gateway listening on http://%s/v1\n", addr)
+	err := httpServer.ListenAndServe()
+	if err == http.ErrServerClosed {
+		return nil
+	}
+	return err
+}
@@ -1,18 +1,22 @@
 package hf
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/metrics"
 )
 
 const (
-	baseURL = "https://huggingface.co/api/models"
+	defaultEndpoint = "https://huggingface.co"
 )
 
 // Model represents a HuggingFace model
@@ -30,15 +34,33 @@ type Model struct {
 
 // Client is the HuggingFace API client
 type Client struct {
-	httpClient *http.Client
+	endpoint       string
+	token          string
+	httpClient     *http.Client
+	downloadClient *http.Client
+	requests       *metrics.RequestMetrics
 }
 
-// NewClient creates a new HuggingFace API client
+// NewClient creates a new HuggingFace API client. The HF_ENDPOINT
+// environment variable overrides the Hub URL.
 func NewClient() *Client {
+	endpoint := os.Getenv("HF_ENDPOINT")
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	return NewClientWithEndpoint(endpoint)
+}
+
+// NewClientWithEndpoint creates a client for a Hub-compatible server at endpoint
+func NewClientWithEndpoint(endpoint string) *Client {
 	return &Client{
+		endpoint: strings.TrimRight(endpoint, "/"),
+		token:    readToken(),
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		downloadClient: newDownloadClient(),
+		requests:       metrics.NewRequestMetrics(),
 	}
 }
 
@@ -58,9 +80,9 @@ func (c *Client) Search(query string, author string, limit int) ([]Model, error)
 	// Filter for MLX models
 	params.Set("library", "mlx")
 	
-	reqURL := baseURL + "?" + params.Encode()
+	reqURL := c.endpoint + "/api/models?" + params.Encode()
 	
-	resp, err := c.httpClient.Get(reqURL)
+	resp, err := c.get(c.httpClient, reqURL, "search")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch models: %w", err)
 	}
@@ -81,9 +103,9 @@ func (c *Client) Search(query string, author string, limit int) ([]Model, error)
 
 // GetModel gets a specific model by ID
 func (c *Client) GetModel(modelID string) (*Model, error) {
-	reqURL := baseURL + "/" + url.PathEscape(modelID)
+	reqURL := c.endpoint + "/api/models/" + escapePath(modelID)
 	
-	resp, err := c.httpClient.Get(reqURL)
+	resp, err := c.get(c.httpClient, reqURL, "model")
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch model: %w", err)
 	}
@@ -101,36 +123,17 @@ func (c *Client) GetModel(modelID string) (*Model, error) {
 	return &model, nil
 }
 
-// Download downloads a model using the hf CLI
-func (c *Client) Download(modelID string, cacheDir string) error {
-	// Check if hf CLI is available (preferred)
-	hfCmd := "hf"
-	if _, err := exec.LookPath("hf"); err != nil {
-		// Try huggingface-cli as fallback
-		if _, err := exec.LookPath("huggingface-cli"); err != nil {
-			return fmt.Errorf("hf CLI not found. Install with: pip install huggingface_hub")
-		}
-		hfCmd = "huggingface-cli"
-	}
-
-	// Use hf download with cache-dir
-	var cmd *exec.Cmd
-	if hfCmd == "hf" {
-		cmd = exec.Command(hfCmd, "download", modelID, "--cache-dir", cacheDir+"/cache", "--no-quiet")
-	} else {
-		cmd = exec.Command(hfCmd, "download", modelID, "--cache-dir", cacheDir+"/cache")
-	}
-
-	output, err := cmd.CombinedOutput()
+// Download downloads a model into the cache directory under modelDir
+func (c *Client) Download(modelID string, modelDir string) error {
+	_, err := c.DownloadSnapshot(context.Background(), modelID, "main", filepath.Join(modelDir, "cache"), nil)
 	if err != nil {
-		return fmt.Errorf("download failed: %w\n%s", err, string(output))
+		return fmt.Errorf("download failed: %w", err)
 	}
-
 	return nil
 }
 
 // DownloadWithProgress downloads a model and returns progress updates via channel
-func (c *Client) DownloadWithProgress(modelID string, cacheDir string) (<-chan string, <-chan error) {
+func (c *Client) DownloadWithProgress(modelID string, modelDir string) (<-chan string, <-chan error) {
 	progressCh := make(chan string, 100)
 	errCh := make(chan error, 1)
 
@@ -140,69 +143,15 @@ func (c *Client) DownloadWithProgress(modelID string, cacheDir string) (<-chan s
 
 		progressCh <- fmt.Sprintf("Starting download: %s", modelID)
 
-		// Check if hf CLI is available (preferred)
-		hfCmd := "hf"
-		if _, err := exec.LookPath("hf"); err != nil {
-			if _, err := exec.LookPath("huggingface-cli"); err != nil {
-				errCh <- fmt.Errorf("hf CLI not found. Install with: pip install huggingface_hub")
+		lastFile := ""
+		_, err := c.DownloadSnapshot(context.Background(), modelID, "main", filepath.Join(modelDir, "cache"), func(p Progress) {
+			if p.File == lastFile {
 				return
 			}
-			hfCmd = "huggingface-cli"
-		}
-
-		// Use hf download with cache-dir
-		var cmd *exec.Cmd
-		if hfCmd == "hf" {
-			cmd = exec.Command(hfCmd, "download", modelID, "--cache-dir", cacheDir+"/cache", "--no-quiet")
-		} else {
-			cmd = exec.Command(hfCmd, "download", modelID, "--cache-dir", cacheDir+"/cache")
-		}
-
-		stdout, err := cmd.StdoutPipe()
+			lastFile = p.File
+			progressCh <- fmt.Sprintf("[%d/%d] %s (%s)", p.FilesDone+1, p.FilesTotal, p.File, FormatBytes(p.FileSize))
+		})
 		if err != nil {
-			errCh <- err
-			return
-		}
-
-		stderr, err := cmd.StderrPipe()
-		if err != nil {
-			errCh <- err
-			return
-		}
-
-		if err := cmd.Start(); err != nil {
-			errCh <- err
-			return
-		}
-
-		// Read output
-		go func() {
-			buf := make([]byte, 1024)
-			for {
-				n, err := stdout.Read(buf)
-				if n > 0 {
-					progressCh <- strings.TrimSpace(string(buf[:n]))
-				}
-				if err != nil {
-					break
-				}
-			}
-		}()
-
-		go func() {
-			buf := make([]byte, 1024)
-			for {
-				n, err := stderr.Read(buf)
-				if n > 0 {
-					progressCh <- strings.TrimSpace(string(buf[:n]))
-				}
-				if err != nil {
-					break
-				}
-			}
-		}()
-
-		if err := cmd.Wait(); err != nil {
 			errCh <- fmt.Errorf("download failed: %w", err)
 			return
 		}
@@ -223,3 +172,17 @@ func FormatDownloads(downloads int) string {
 	}
 	return fmt.Sprintf("%d", downloads)
 }
+
+// FormatBytes formats a byte count for display
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,432 @@
+package hf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// RepoFile describes a single file of a repository revision
+type RepoFile struct {
+	Path   string `json:"rfilename"`
+	Size   int64  `json:"size"`
+	BlobID string `json:"blobId"`
+	LFS    *struct {
+		SHA256 string `json:"sha256"`
+		Size   int64  `json:"size"`
+	} `json:"lfs,omitempty"`
+}
+
+// ETag returns the identifier used to name the file's blob in the cache.
+// LFS files use their sha256, regular files their git blob id.
+func (f RepoFile) ETag() string {
+	if f.LFS != nil && f.LFS.SHA256 != "" {
+		return f.LFS.SHA256
+	}
+	return f.BlobID
+}
+
+// FileSize returns the size of the file content
+func (f RepoFile) FileSize() int64 {
+	if f.LFS != nil && f.LFS.Size > 0 {
+		return f.LFS.Size
+	}
+	return f.Size
+}
+
+// RepoInfo is a repository revision with its file list
+type RepoInfo struct {
+	ID    string     `json:"id"`
+	SHA   string     `json:"sha"`
+	Files []RepoFile `json:"siblings"`
+}
+
+// TotalSize returns the sum of all file sizes
+func (r *RepoInfo) TotalSize() int64 {
+	var total int64
+	for _, f := range r.Files {
+		total += f.FileSize()
+	}
+	return total
+}
+
+// Progress reports the state of a snapshot download
+type Progress struct {
+	File       string
+	FileBytes  int64
+	FileSize   int64
+	Bytes      int64 // Bytes present for the whole snapshot, including resumed data
+	TotalBytes int64
+	FilesDone  int
+	FilesTotal int
+}
+
+// ChecksumError is returned when a downloaded file does not match the
+// checksum published by the Hub
+type ChecksumError struct {
+	File     string
+	Expected string
+	Actual   string
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch for %s: expected %s, got %s", e.File, e.Expected, e.Actual)
+}
+
+// RepoFolderName returns the cache folder name for a model repository
+func RepoFolderName(repoID string) string {
+	return "models--" + strings.ReplaceAll(repoID, "/", "--")
+}
+
+// GetRepoInfo resolves a repository revision and lists its files
+func (c *Client) GetRepoInfo(ctx context.Context, repoID, revision string) (*RepoInfo, error) {
+	if revision == "" {
+		revision = "main"
+	}
+	reqURL := fmt.Sprintf("%s/api/models/%s/revision/%s?blobs=true", c.endpoint, escapePath(repoID), url.PathEscape(revision))
+
+	req, err := c.newRequest(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.do(c.httpClient, req, "repo_info")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch repo info: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+	}
+
+	var info RepoInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	if info.SHA == "" {
+		return nil, fmt.Errorf("repo info for %s has no commit sha", repoID)
+	}
+	return &info, nil
+}
+
+// DownloadSnapshot downloads every file of a repository revision into the
+// Hugging Face cache layout under cacheDir:
+//
+//	models--org--name/blobs/<etag>
+//	models--org--name/snapshots/<sha>/<file> -> ../../blobs/<etag>
+//	models--org--name/refs/<revision>
+//
+// Partially downloaded blobs are resumed with HTTP Range requests and LFS
+// files are verified against their sha256. It returns the snapshot path.
+func (c *Client) DownloadSnapshot(ctx context.Context, repoID, revision, cacheDir string, onProgress func(Progress)) (string, error) {
+	if revision == "" {
+		revision = "main"
+	}
+	info, err := c.GetRepoInfo(ctx, repoID, revision)
+	if err != nil {
+		return "", err
+	}
+
+	repoDir := filepath.Join(cacheDir, RepoFolderName(repoID))
+	blobsDir := filepath.Join(repoDir, "blobs")
+	snapshotDir, err := cachePath(filepath.Join(repoDir, "snapshots"), info.SHA)
+	if err != nil {
+		return "", fmt.Errorf("invalid commit sha: %w", err)
+	}
+	// The listing names paths in the cache, so check every entry before
+	// downloading anything
+	for _, file := range info.Files {
+		if _, err := cachePath(blobsDir, file.ETag()); err != nil {
+			return "", fmt.Errorf("invalid blob name for %s: %w", file.Path, err)
+		}
+		if _, err := cachePath(snapshotDir, file.Path); err != nil {
+			return "", fmt.Errorf("invalid file name: %w", err)
+		}
+	}
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(snapshotDir, 0755); err != nil {
+		return "", err
+	}
+
+	progress := Progress{
+		TotalBytes: info.TotalSize(),
+		FilesTotal: len(info.Files),
+	}
+	report := func() {
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+
+	for _, file := range info.Files {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		progress.File = file.Path
+		progress.FileSize = file.FileSize()
+		progress.FileBytes = 0
+		report()
+
+		blobPath, _ := cachePath(blobsDir, file.ETag())
+		base := progress.Bytes
+		err := c.downloadBlob(ctx, repoID, info.SHA, file, blobPath, func(n int64) {
+			progress.FileBytes = n
+			progress.Bytes = base + n
+			report()
+		})
+		if err != nil {
+			return "", err
+		}
+
+		if err := linkSnapshotFile(snapshotDir, file.Path, blobPath); err != nil {
+			return "", fmt.Errorf("failed to link %s: %w", file.Path, err)
+		}
+
+		progress.FilesDone++
+		progress.FileBytes = progress.FileSize
+		progress.Bytes = base + progress.FileSize
+		report()
+	}
+
+	// Record which commit the revision points to, like huggingface_hub does
+	refsDir := filepath.Join(repoDir, "refs")
+	if err := os.MkdirAll(refsDir, 0755); err == nil {
+		os.WriteFile(filepath.Join(refsDir, revision), []byte(info.SHA), 0644)
+	}
+
+	return snapshotDir, nil
+}
+
+// downloadBlob fetches a single file into blobPath, resuming from a
+// previous ".incomplete" file when present
+func (c *Client) downloadBlob(ctx context.Context, repoID, sha string, file RepoFile, blobPath string, onBytes func(int64)) error {
+	size := file.FileSize()
+
+	// Already downloaded
+	if st, err := os.Stat(blobPath); err == nil && st.Size() == size {
+		onBytes(size)
+		return nil
+	}
+
+	tmpPath := blobPath + ".incomplete"
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	offset, err := out.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if offset > size {
+		// Stale data from a different file version
+		out.Truncate(0)
+		offset, _ = out.Seek(0, io.SeekStart)
+	}
+
+	if offset < size || size == 0 {
+		fileURL := fmt.Sprintf("%s/%s/resolve/%s/%s", c.endpoint, escapePath(repoID), sha, escapePath(file.Path))
+		req, err := c.newRequest(ctx, fileURL)
+		if err != nil {
+			return err
+		}
+		if offset > 0 {
+			req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		}
+
+		resp, err := c.do(c.downloadClient, req, "download")
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", file.Path, err)
+		}
+		defer resp.Body.Close()
+
+		switch resp.StatusCode {
+		case http.StatusPartialContent:
+			// Resuming from offset
+		case http.StatusOK:
+			// Server ignored the range, start over
+			if offset > 0 {
+				if err := out.Truncate(0); err != nil {
+					return err
+				}
+				if offset, err = out.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+			}
+		case http.StatusRequestedRangeNotSatisfiable:
+			// Nothing left to fetch
+		default:
+			return fmt.Errorf("failed to download %s: %s", file.Path, resp.Status)
+		}
+
+		if resp.StatusCode != http.StatusRequestedRangeNotSatisfiable {
+			written := offset
+			onBytes(written)
+			buf := make([]byte, 256*1024)
+			for {
+				n, readErr := resp.Body.Read(buf)
+				if n > 0 {
+					if _, err := out.Write(buf[:n]); err != nil {
+						return err
+					}
+					written += int64(n)
+					onBytes(written)
+				}
+				if readErr == io.EOF {
+					break
+				}
+				if readErr != nil {
+					return fmt.Errorf("failed to download %s: %w", file.Path, readErr)
+				}
+			}
+		}
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	st, err := os.Stat(tmpPath)
+	if err != nil {
+		return err
+	}
+	if st.Size() != size {
+		return fmt.Errorf("incomplete download for %s: got %d of %d bytes", file.Path, st.Size(), size)
+	}
+
+	if file.LFS != nil && file.LFS.SHA256 != "" {
+		sum, err := fileSHA256(tmpPath)
+		if err != nil {
+			return err
+		}
+		if sum != file.LFS.SHA256 {
+			// Corrupt data cannot be resumed, start from scratch next time
+			os.Remove(tmpPath)
+			return &ChecksumError{File: file.Path, Expected: file.LFS.SHA256, Actual: sum}
+		}
+	}
+
+	return os.Rename(tmpPath, blobPath)
+}
+
+// linkSnapshotFile points snapshots/<sha>/<path> at the blob with a relative
+// symlink, falling back to a hard link where symlinks are unavailable
+func linkSnapshotFile(snapshotDir, path, blobPath string) error {
+	linkPath, err := cachePath(snapshotDir, path)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(linkPath), 0755); err != nil {
+		return err
+	}
+
+	if target, err := os.Readlink(linkPath); err == nil {
+		if filepath.Join(filepath.Dir(linkPath), target) == blobPath {
+			return nil
+		}
+	}
+	os.Remove(linkPath)
+
+	rel, err := filepath.Rel(filepath.Dir(linkPath), blobPath)
+	if err != nil {
+		rel = blobPath
+	}
+	if err := os.Symlink(rel, linkPath); err != nil {
+		return os.Link(blobPath, linkPath)
+	}
+	return nil
+}
+
+// cachePath joins name, a slash-separated path listed by the Hub, to dir.
+// It fails for names that would resolve to dir itself or outside it.
+func cachePath(dir, name string) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if name == "" || filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || escapesDir(clean) {
+		return "", fmt.Errorf("path %q is outside the cache", name)
+	}
+	path := filepath.Join(dir, clean)
+	if rel, err := filepath.Rel(dir, path); err != nil || rel == "." || escapesDir(rel) {
+		return "", fmt.Errorf("path %q is outside the cache", name)
+	}
+	return path, nil
+}
+
+// escapesDir reports whether a cleaned relative path leads to a parent
+func escapesDir(path string) bool {
+	return path == ".." || strings.HasPrefix(path, ".."+string(filepath.Separator))
+}
+
+// newRequest builds a GET request carrying the user's token, if any
+func (c *Client) newRequest(ctx context.Context, reqURL string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// escapePath escapes each segment of a repo ID or file path
+func escapePath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
+
+func fileSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// readToken returns the Hugging Face token from HF_TOKEN or the token file
+// written by `hf auth login`
+func readToken() string {
+	if token := os.Getenv("HF_TOKEN"); token != "" {
+		return token
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(filepath.Join(home, ".cache", "huggingface", "token"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// newDownloadClient returns an HTTP client without an overall timeout so
+// multi-gigabyte files are not cut off, but which still fails fast when
+// the server stops responding
+func newDownloadClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 60 * time.Second
+	return &http.Client{Transport: transport}
+}
@@ -0,0 +1,235 @@
+package hf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSHA = "0123456789abcdef"
+
+// fakeHub serves one repository revision like the Hub: its file list from
+// the revision API and its files, with Range support, from resolve URLs
+type fakeHub struct {
+	*httptest.Server
+	repoID string
+	files  map[string][]byte
+	info   RepoInfo
+
+	mu     sync.Mutex
+	ranges map[string]string // Range header of the last request by file
+}
+
+func newFakeHub(t *testing.T, repoID string, files map[string][]byte) *fakeHub {
+	t.Helper()
+	t.Setenv("HF_TOKEN", "")
+	t.Setenv("HOME", t.TempDir())
+
+	h := &fakeHub{repoID: repoID, files: files, ranges: make(map[string]string)}
+	h.info = RepoInfo{ID: repoID, SHA: testSHA}
+	for path, content := range files {
+		sum := sha256.Sum256(content)
+		file := RepoFile{Path: path, Size: int64(len(content)), BlobID: "blob-" + strings.ReplaceAll(path, "/", "-")}
+		if strings.HasSuffix(path, ".safetensors") {
+			file.LFS = &struct {
+				SHA256 string `json:"sha256"`
+				Size   int64  `json:"size"`
+			}{hex.EncodeToString(sum[:]), int64(len(content))}
+		}
+		h.info.Files = append(h.info.Files, file)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/models/"+repoID+"/revision/main", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(h.info)
+	})
+	mux.HandleFunc("GET /"+repoID+"/resolve/"+testSHA+"/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		path := r.PathValue("path")
+		content, ok := h.files[path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		h.mu.Lock()
+		h.ranges[path] = r.Header.Get("Range")
+		h.mu.Unlock()
+		http.ServeContent(w, r, path, time.Time{}, bytes.NewReader(content))
+	})
+	h.Server = httptest.NewServer(mux)
+	t.Cleanup(h.Close)
+	return h
+}
+
+// file returns the listing of path
+func (h *fakeHub) file(path string) RepoFile {
+	for _, f := range h.info.Files {
+		if f.Path == path {
+			return f
+		}
+	}
+	panic("no file " + path)
+}
+
+func (h *fakeHub) rangeOf(path string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.ranges[path]
+}
+
+func TestDownloadSnapshot(t *testing.T) {
+	weights := bytes.Repeat([]byte("0123456789"), 100_000)
+	hub := newFakeHub(t, "org/model", map[string][]byte{
+		"config.json":               []byte(`{"model_type": "llama"}`),
+		"weights/model.safetensors": weights,
+	})
+	cacheDir := t.TempDir()
+	repoDir := filepath.Join(cacheDir, "models--org--model")
+
+	// A previous attempt stopped halfway through the weights
+	blob := filepath.Join(repoDir, "blobs", hub.file("weights/model.safetensors").ETag())
+	if err := os.MkdirAll(filepath.Dir(blob), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(blob+".incomplete", weights[:400_000], 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var last Progress
+	snapshot, err := NewClientWithEndpoint(hub.URL).DownloadSnapshot(context.Background(), "org/model", "main", cacheDir, func(p Progress) {
+		last = p
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := filepath.Join(repoDir, "snapshots", testSHA); snapshot != want {
+		t.Errorf("snapshot %s, want %s", snapshot, want)
+	}
+	if r := hub.rangeOf("weights/model.safetensors"); r != "bytes=400000-" {
+		t.Errorf("weights requested with Range %q, want the rest after the partial file", r)
+	}
+	if r := hub.rangeOf("config.json"); r != "" {
+		t.Errorf("config.json requested with Range %q", r)
+	}
+	if last.FilesDone != 2 || last.Bytes != last.TotalBytes {
+		t.Errorf("last progress %+v, want every file and byte done", last)
+	}
+
+	for path, content := range hub.files {
+		link := filepath.Join(snapshot, filepath.FromSlash(path))
+		target, err := os.Readlink(link)
+		if err != nil {
+			t.Fatalf("%s is not a symlink: %v", path, err)
+		}
+		blob := filepath.Join(repoDir, "blobs", hub.file(path).ETag())
+		if got := filepath.Join(filepath.Dir(link), target); got != blob {
+			t.Errorf("%s links to %s, want %s", path, got, blob)
+		}
+		data, err := os.ReadFile(link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, content) {
+			t.Errorf("%s has %d bytes, want the %d served", path, len(data), len(content))
+		}
+		if _, err := os.Stat(blob + ".incomplete"); !os.IsNotExist(err) {
+			t.Errorf("%s.incomplete left behind", path)
+		}
+	}
+
+	ref, err := os.ReadFile(filepath.Join(repoDir, "refs", "main"))
+	if err != nil || string(ref) != testSHA {
+		t.Errorf("refs/main = %q (%v), want %s", ref, err, testSHA)
+	}
+}
+
+func TestDownloadSnapshotChecksumMismatch(t *testing.T) {
+	hub := newFakeHub(t, "org/model", map[string][]byte{
+		"model.safetensors": []byte("corrupted weights"),
+	})
+	hub.info.Files[0].LFS.SHA256 = fmt.Sprintf("%064x", 0)
+	cacheDir := t.TempDir()
+
+	_, err := NewClientWithEndpoint(hub.URL).DownloadSnapshot(context.Background(), "org/model", "main", cacheDir, nil)
+	var checksumErr *ChecksumError
+	if !errors.As(err, &checksumErr) {
+		t.Fatalf("error %v, want a ChecksumError", err)
+	}
+	if checksumErr.File != "model.safetensors" {
+		t.Errorf("checksum error for %s, want model.safetensors", checksumErr.File)
+	}
+
+	blob := filepath.Join(cacheDir, "models--org--model", "blobs", hub.info.Files[0].ETag())
+	for _, path := range []string{blob, blob + ".incomplete"} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("%s kept after the checksum mismatch", filepath.Base(path))
+		}
+	}
+}
+
+func TestGetModelEscapesRepoID(t *testing.T) {
+	t.Setenv("HF_TOKEN", "")
+	t.Setenv("HOME", t.TempDir())
+	var path string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.EscapedPath()
+		fmt.Fprint(w, `{"id": "org/model"}`)
+	}))
+	defer srv.Close()
+
+	if _, err := NewClientWithEndpoint(srv.URL).GetModel("org/model?x=1#y"); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/api/models/org/model%3Fx=1%23y"; path != want {
+		t.Errorf("requested %s, want %s", path, want)
+	}
+}
+
+func TestDownloadSnapshotRejectsEscapingPaths(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		edit  func(h *fakeHub)
+		files map[string][]byte
+	}{
+		{"file name", nil, map[string][]byte{"../evil": []byte("x")}},
+		{"file name leaving the cache", nil, map[string][]byte{"../../../../evil": []byte("x")}},
+		{"parent", nil, map[string][]byte{"..": []byte("x")}},
+		{"absolute file name", func(h *fakeHub) { h.info.Files[0].Path = "/evil" }, map[string][]byte{"config.json": []byte("{}")}},
+		{"blob id", func(h *fakeHub) { h.info.Files[0].BlobID = "../../../evil" }, map[string][]byte{"config.json": []byte("{}")}},
+		{"commit sha", func(h *fakeHub) { h.info.SHA = "../../../evil" }, map[string][]byte{"config.json": []byte("{}")}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := newFakeHub(t, "org/model", tt.files)
+			if tt.edit != nil {
+				tt.edit(hub)
+			}
+			root := t.TempDir()
+			cacheDir := filepath.Join(root, "cache")
+
+			_, err := NewClientWithEndpoint(hub.URL).DownloadSnapshot(context.Background(), "org/model", "main", cacheDir, nil)
+			if err == nil || !strings.Contains(err.Error(), "outside the cache") {
+				t.Fatalf("error %v, want the path rejected", err)
+			}
+			if _, err := os.Lstat(filepath.Join(root, "evil")); !os.IsNotExist(err) {
+				t.Error("wrote outside the cache")
+			}
+			hub.mu.Lock()
+			defer hub.mu.Unlock()
+			if len(hub.ranges) != 0 {
+				t.Errorf("downloaded %v before rejecting the listing", hub.ranges)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package hf
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/metrics"
+)
+
+// CollectMetrics writes the count and latency of the client's Hub requests
+// by operation: search, model, repo_info or download
+func (c *Client) CollectMetrics(w *metrics.Writer) {
+	c.requests.Write(w, "efx_hf", "operation", "Hugging Face API")
+}
+
+// get sends an unauthenticated GET request, recording it under operation
+func (c *Client) get(client *http.Client, reqURL, operation string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(client, req, operation)
+}
+
+// do sends req with client and records its status and the time until the
+// response headers arrived. Redirects count as part of the request.
+func (c *Client) do(client *http.Client, req *http.Request, operation string) (*http.Response, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	c.requests.Observe(operation, status, time.Since(start))
+	return resp, err
+}
@@ -0,0 +1,52 @@
+package installer
+
+import "fmt"
+
+// DownloadError is returned when fetching files from the Hub fails
+type DownloadError struct {
+	RepoID string
+	Err    error
+}
+
+func (e *DownloadError) Error() string {
+	return fmt.Sprintf("download of %s failed: %v", e.RepoID, e.Err)
+}
+
+func (e *DownloadError) Unwrap() error { return e.Err }
+
+// SnapshotError is returned when the downloaded snapshot cannot be located
+type SnapshotError struct {
+	RepoID string
+	Err    error
+}
+
+func (e *SnapshotError) Error() string {
+	return fmt.Sprintf("snapshot of %s not found: %v", e.RepoID, e.Err)
+}
+
+func (e *SnapshotError) Unwrap() error { return e.Err }
+
+// LinkError is returned when the model symlink cannot be created
+type LinkError struct {
+	RepoID string
+	Path   string
+	Err    error
+}
+
+func (e *LinkError) Error() string {
+	return fmt.Sprintf("failed to link %s at %s: %v", e.RepoID, e.Path, e.Err)
+}
+
+func (e *LinkError) Unwrap() error { return e.Err }
+
+// ValidationError is returned when the installed model fails post-install checks
+type ValidationError struct {
+	RepoID string
+	Err    error
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("installed model %s is invalid: %v", e.RepoID, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
@@ -0,0 +1,159 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/lmarques/efx-face-manager/internal/hf"
+	"github.com/lmarques/efx-face-manager/internal/model"
+)
+
+// Result describes a completed install
+type Result struct {
+	RepoID       string
+	Name         string // Name of the model in the store
+	SnapshotPath string // Snapshot directory in the cache
+	LinkPath     string // Symlink in the model directory
+}
+
+// Installer downloads models from the Hub and makes them visible to
+// model.Store. Every install entry point (CLI, search view, details view)
+// goes through it so models always land in the same place.
+type Installer struct {
+	client   *hf.Client
+	modelDir string
+}
+
+// New creates an installer storing models under modelDir
+func New(client *hf.Client, modelDir string) *Installer {
+	return &Installer{client: client, modelDir: modelDir}
+}
+
+// CacheDir returns the Hugging Face cache directory used for downloads
+func (i *Installer) CacheDir() string {
+	return filepath.Join(i.modelDir, "cache")
+}
+
+// ModelName returns the store name for a repository ID (org/name -> name)
+func ModelName(repoID string) string {
+	parts := strings.Split(repoID, "/")
+	return parts[len(parts)-1]
+}
+
+// Install downloads repoID, resolves its snapshot, links it into the model
+// directory and validates the result. onProgress may be nil.
+func (i *Installer) Install(ctx context.Context, repoID string, onProgress func(hf.Progress)) (*Result, error) {
+	name := ModelName(repoID)
+	if name == "" || !strings.Contains(repoID, "/") {
+		return nil, &ValidationError{RepoID: repoID, Err: fmt.Errorf("invalid repo id, expected org/name")}
+	}
+
+	if err := os.MkdirAll(i.CacheDir(), 0755); err != nil {
+		return nil, &DownloadError{RepoID: repoID, Err: err}
+	}
+
+	if _, err := i.client.DownloadSnapshot(ctx, repoID, "main", i.CacheDir(), onProgress); err != nil {
+		return nil, &DownloadError{RepoID: repoID, Err: err}
+	}
+
+	snapshotPath, err := ResolveSnapshot(i.CacheDir(), repoID, "main")
+	if err != nil {
+		return nil, &SnapshotError{RepoID: repoID, Err: err}
+	}
+
+	linkPath := filepath.Join(i.modelDir, name)
+	if err := link(snapshotPath, linkPath); err != nil {
+		return nil, &LinkError{RepoID: repoID, Path: linkPath, Err: err}
+	}
+
+	result := &Result{
+		RepoID:       repoID,
+		Name:         name,
+		SnapshotPath: snapshotPath,
+		LinkPath:     linkPath,
+	}
+	if err := i.validate(result); err != nil {
+		return nil, &ValidationError{RepoID: repoID, Err: err}
+	}
+
+	return result, nil
+}
+
+// ResolveSnapshot returns the snapshot directory that revision points to.
+// It follows refs/<revision> and falls back to the most recently modified
+// snapshot when the ref is missing.
+func ResolveSnapshot(cacheDir, repoID, revision string) (string, error) {
+	repoDir := filepath.Join(cacheDir, hf.RepoFolderName(repoID))
+	snapshotsDir := filepath.Join(repoDir, "snapshots")
+
+	if data, err := os.ReadFile(filepath.Join(repoDir, "refs", revision)); err == nil {
+		path := filepath.Join(snapshotsDir, strings.TrimSpace(string(data)))
+		if st, err := os.Stat(path); err == nil && st.IsDir() {
+			return path, nil
+		}
+	}
+
+	entries, err := os.ReadDir(snapshotsDir)
+	if err != nil {
+		return "", fmt.Errorf("could not find downloaded model in cache: %w", err)
+	}
+
+	var dirs []os.DirEntry
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e)
+		}
+	}
+	if len(dirs) == 0 {
+		return "", fmt.Errorf("no snapshots found in %s", snapshotsDir)
+	}
+
+	sort.Slice(dirs, func(a, b int) bool {
+		ia, _ := dirs[a].Info()
+		ib, _ := dirs[b].Info()
+		if ia == nil || ib == nil {
+			return false
+		}
+		return ia.ModTime().After(ib.ModTime())
+	})
+	return filepath.Join(snapshotsDir, dirs[0].Name()), nil
+}
+
+// link points linkPath at the snapshot, replacing an older symlink but
+// never a real directory the user created
+func link(snapshotPath, linkPath string) error {
+	if info, err := os.Lstat(linkPath); err == nil {
+		if info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("%s exists and is not a symlink", linkPath)
+		}
+		if err := os.Remove(linkPath); err != nil {
+			return err
+		}
+	}
+	return os.Symlink(snapshotPath, linkPath)
+}
+
+// validate checks the installed model is listed by the store and looks
+// like a loadable model
+func (i *Installer) validate(r *Result) error {
+	store := model.NewStore(i.modelDir)
+	if !store.Exists(r.Name) {
+		return fmt.Errorf("%s is not listed in %s", r.Name, i.modelDir)
+	}
+
+	hasConfig := false
+	for _, name := range []string{"config.json", "model_index.json"} {
+		if _, err := os.Stat(filepath.Join(r.LinkPath, name)); err == nil {
+			hasConfig = true
+			break
+		}
+	}
+	if !hasConfig {
+		return fmt.Errorf("snapshot has no config.json or model_index.json")
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lmarques/efx-face-manager/internal/hf"
+)
+
+const testSHA = "0123456789abcdef"
+
+// newFakeHub serves the files of each repository at revision testSHA like
+// the Hub and returns a client for it
+func newFakeHub(t *testing.T, repos map[string]map[string]string) *hf.Client {
+	t.Helper()
+	t.Setenv("HF_TOKEN", "")
+	t.Setenv("HOME", t.TempDir())
+
+	mux := http.NewServeMux()
+	for repoID, files := range repos {
+		mux.HandleFunc("GET /api/models/"+repoID+"/revision/main", func(w http.ResponseWriter, r *http.Request) {
+			info := hf.RepoInfo{ID: repoID, SHA: testSHA}
+			for path, content := range files {
+				info.Files = append(info.Files, hf.RepoFile{Path: path, Size: int64(len(content)), BlobID: "blob-" + strings.ReplaceAll(path, "/", "-")})
+			}
+			json.NewEncoder(w).Encode(info)
+		})
+		mux.HandleFunc("GET /"+repoID+"/resolve/"+testSHA+"/{path...}", func(w http.ResponseWriter, r *http.Request) {
+			content, ok := files[r.PathValue("path")]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write([]byte(content))
+		})
+	}
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return hf.NewClientWithEndpoint(srv.URL)
+}
+
+func TestInstall(t *testing.T) {
+	client := newFakeHub(t, map[string]map[string]string{
+		"org/model": {"config.json": `{"model_type": "llama"}`, "model.safetensors": "weights"},
+	})
+	modelDir := t.TempDir()
+
+	result, err := New(client, modelDir).Install(context.Background(), "org/model", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snapshot := filepath.Join(modelDir, "cache", "models--org--model", "snapshots", testSHA)
+	if result.Name != "model" || result.SnapshotPath != snapshot || result.LinkPath != filepath.Join(modelDir, "model") {
+		t.Errorf("result %+v", result)
+	}
+	if target, err := os.Readlink(result.LinkPath); err != nil || target != snapshot {
+		t.Errorf("model links to %q (%v), want %s", target, err, snapshot)
+	}
+}
+
+func TestInstallErrors(t *testing.T) {
+	repos := map[string]map[string]string{
+		"org/model":    {"config.json": `{"model_type": "llama"}`},
+		"org/noconfig": {"README.md": "not a model"},
+	}
+
+	for _, tt := range []struct {
+		name   string
+		repoID string
+		setup  func(t *testing.T, modelDir string)
+		// clears the cache once every file is downloaded, like another
+		// process would
+		clearCache bool
+		check      func(t *testing.T, err error)
+	}{
+		{
+			name:   "download",
+			repoID: "org/missing",
+			check: func(t *testing.T, err error) {
+				var e *DownloadError
+				if !errors.As(err, &e) || e.RepoID != "org/missing" || !strings.Contains(err.Error(), "404") {
+					t.Errorf("error %v, want a DownloadError for the missing repository", err)
+				}
+			},
+		},
+		{
+			name:       "snapshot",
+			repoID:     "org/model",
+			clearCache: true,
+			check: func(t *testing.T, err error) {
+				var e *SnapshotError
+				if !errors.As(err, &e) || e.RepoID != "org/model" {
+					t.Errorf("error %v, want a SnapshotError", err)
+				}
+			},
+		},
+		{
+			name:   "link",
+			repoID: "org/model",
+			setup: func(t *testing.T, modelDir string) {
+				// A directory of the user's own where the link would go
+				if err := os.Mkdir(filepath.Join(modelDir, "model"), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+			check: func(t *testing.T, err error) {
+				var e *LinkError
+				if !errors.As(err, &e) || e.Path == "" || !strings.Contains(err.Error(), "not a symlink") {
+					t.Errorf("error %v, want a LinkError for the existing directory", err)
+				}
+			},
+		},
+		{
+			name:   "invalid repo id",
+			repoID: "model",
+			check: func(t *testing.T, err error) {
+				var e *ValidationError
+				if !errors.As(err, &e) || !strings.Contains(err.Error(), "expected org/name") {
+					t.Errorf("error %v, want a ValidationError for the repo id", err)
+				}
+			},
+		},
+		{
+			name:   "no config",
+			repoID: "org/noconfig",
+			check: func(t *testing.T, err error) {
+				var e *ValidationError
+				if !errors.As(err, &e) || !strings.Contains(err.Error(), "no config.json") {
+					t.Errorf("error %v, want a ValidationError for the missing config", err)
+				}
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newFakeHub(t, repos)
+			modelDir := t.TempDir()
+			if tt.setup != nil {
+				tt.setup(t, modelDir)
+			}
+			inst := New(client, modelDir)
+			var onProgress func(hf.Progress)
+			if tt.clearCache {
+				onProgress = func(p hf.Progress) {
+					if p.FilesDone == p.FilesTotal {
+						os.RemoveAll(filepath.Join(inst.CacheDir(), hf.RepoFolderName(tt.repoID), "snapshots"))
+					}
+				}
+			}
+
+			result, err := inst.Install(context.Background(), tt.repoID, onProgress)
+			if result != nil {
+				t.Errorf("result %+v with an error", result)
+			}
+			tt.check(t, err)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+// Package metrics exports efx-face's own state in the Prometheus text
+// exposition format. It has no dependencies: packages that own state
+// implement Collector and write their samples on every scrape.
+package metrics
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Collector writes the current value of its metrics to w
+type Collector interface {
+	CollectMetrics(w *Writer)
+}
+
+// Handler serves the metrics of collectors in the Prometheus text format
+func Handler(collectors ...Collector) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var mw Writer
+		for _, c := range collectors {
+			c.CollectMetrics(&mw)
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		mw.WriteTo(w)
+	})
+}
+
+// family is a metric name with its help text, type and samples
+type family struct {
+	name    string
+	help    string
+	kind    string
+	samples []string
+}
+
+// Writer collects samples during a scrape. Samples of the same metric are
+// grouped under one HELP and TYPE header, in the order metrics were first
+// written.
+type Writer struct {
+	families []*family
+	byName   map[string]*family
+}
+
+// Gauge writes a value that can go up and down. Labels are given as
+// name, value pairs.
+func (w *Writer) Gauge(name, help string, value float64, labels ...string) {
+	w.family(name, help, "gauge").sample(name, labels, "", "", value)
+}
+
+// Counter writes a value that only increases while the process runs
+func (w *Writer) Counter(name, help string, value float64, labels ...string) {
+	w.family(name, help, "counter").sample(name, labels, "", "", value)
+}
+
+// Histogram writes the buckets, sum and count of h
+func (w *Writer) Histogram(name, help string, h *Histogram, labels ...string) {
+	f := w.family(name, help, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var cumulative uint64
+	for i, bound := range h.buckets {
+		cumulative += h.counts[i]
+		f.sample(name+"_bucket", labels, "le", formatFloat(bound), float64(cumulative))
+	}
+	f.sample(name+"_bucket", labels, "le", "+Inf", float64(h.count))
+	f.sample(name+"_sum", labels, "", "", h.sum)
+	f.sample(name+"_count", labels, "", "", float64(h.count))
+}
+
+// WriteTo writes the collected metrics in the Prometheus text format
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	var b strings.Builder
+	for _, f := range w.families {
+		b.WriteString("# HELP " + f.name + " " + escapeHelp(f.help) + "\n")
+		b.WriteString("# TYPE " + f.name + " " + f.kind + "\n")
+		for _, s := range f.samples {
+			b.WriteString(s)
+		}
+	}
+	n, err := io.WriteString(out, b.String())
+	return int64(n), err
+}
+
+func (w *Writer) family(name, help, kind string) *family {
+	if f, ok := w.byName[name]; ok {
+		return f
+	}
+	if w.byName == nil {
+		w.byName = make(map[string]*family)
+	}
+	f := &family{name: name, help: help, kind: kind}
+	w.byName[name] = f
+	w.families = append(w.families, f)
+	return f
+}
+
+// sample adds a sample line. extraName and extraValue add one more label
+// after labels, such as the le bound of a histogram bucket.
+func (f *family) sample(name string, labels []string, extraName, extraValue string, value float64) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 1 || extraName != "" {
+		b.WriteByte('{')
+		sep := ""
+		for i := 0; i+1 < len(labels); i += 2 {
+			b.WriteString(sep + labels[i] + `="` + escapeLabel(labels[i+1]) + `"`)
+			sep = ","
+		}
+		if extraName != "" {
+			b.WriteString(sep + extraName + `="` + extraValue + `"`)
+		}
+		b.WriteByte('}')
+	}
+	b.WriteString(" " + formatFloat(value) + "\n")
+	f.samples = append(f.samples, b.String())
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// DefaultBuckets are latency bounds in seconds, from fast API calls to
+// long generations
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120}
+
+// Histogram counts observations in buckets. It is safe for concurrent use.
+type Histogram struct {
+	mu      sync.Mutex
+	buckets []float64 // upper bounds, ascending
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// NewHistogram creates a histogram with the given bucket upper bounds
+func NewHistogram(buckets []float64) *Histogram {
+	return &Histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+// Observe records one value
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.sum += v
+	h.count++
+}
+
+// RequestMetrics counts requests by a key, such as an API operation or a
+// model, and their response status, and records their latency by key. It
+// is safe for concurrent use.
+type RequestMetrics struct {
+	mu      sync.Mutex
+	counts  map[[2]string]uint64 // by key and status
+	latency map[string]*Histogram
+}
+
+// NewRequestMetrics creates an empty set of request metrics
+func NewRequestMetrics() *RequestMetrics {
+	return &RequestMetrics{
+		counts:  make(map[[2]string]uint64),
+		latency: make(map[string]*Histogram),
+	}
+}
+
+// Observe records a request for key that ended with status after d
+func (m *RequestMetrics) Observe(key, status string, d time.Duration) {
+	m.mu.Lock()
+	m.counts[[2]string{key, status}]++
+	h, ok := m.latency[key]
+	if !ok {
+		h = NewHistogram(DefaultBuckets)
+		m.latency[key] = h
+	}
+	m.mu.Unlock()
+	h.Observe(d.Seconds())
+}
+
+// Write writes <prefix>_requests_total by key and status and
+// <prefix>_request_duration_seconds by key, labeling keys with keyLabel
+func (m *RequestMetrics) Write(w *Writer, prefix, keyLabel, what string) {
+	m.mu.Lock()
+	counts := make(map[[2]string]uint64, len(m.counts))
+	for k, n := range m.counts {
+		counts[k] = n
+	}
+	latency := make(map[string]*Histogram, len(m.latency))
+	for k, h := range m.latency {
+		latency[k] = h
+	}
+	m.mu.Unlock()
+
+	countKeys := make([][2]string, 0, len(counts))
+	for k := range counts {
+		countKeys = append(countKeys, k)
+	}
+	sort.Slice(countKeys, func(i, j int) bool {
+		if countKeys[i][0] != countKeys[j][0] {
+			return countKeys[i][0] < countKeys[j][0]
+		}
+		return countKeys[i][1] < countKeys[j][1]
+	})
+	for _, k := range countKeys {
+		w.Counter(prefix+"_requests_total", what+" requests by "+keyLabel+" and response status",
+			float64(counts[k]), keyLabel, k[0], "code", k[1])
+	}
+
+	keys := make([]string, 0, len(latency))
+	for k := range latency {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		w.Histogram(prefix+"_request_duration_seconds", what+" request latency in seconds by "+keyLabel, latency[k], keyLabel, k)
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
@@ -26,6 +27,19 @@ const (
 	TypeWhisper         ModelType = "whisper"
 )
 
+// ModelTypes lists every model type mlx-openai-server can serve
+var ModelTypes = []ModelType{TypeLM, TypeMultimodal, TypeImageGeneration, TypeImageEdit, TypeEmbeddings, TypeWhisper}
+
+// Valid reports whether t is one of ModelTypes
+func (t ModelType) Valid() bool {
+	for _, known := range ModelTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
 // Store manages model storage
 type Store struct {
 	BaseDir string
@@ -157,3 +171,23 @@ func (s *Store) RemoveWithCache(name string) error {
 
 	return nil
 }
+
+// DiskSize returns the size of the files of the model at path. Snapshot
+// files are symlinks to cache blobs, so the blobs are measured.
+func DiskSize(path string) int64 {
+	root := path
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	var size int64
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := os.Stat(path); err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
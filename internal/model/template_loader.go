@@ -9,14 +9,20 @@ import (
 
 // TemplateConfig represents the structure of templates.yaml
 type TemplateConfig struct {
-	Templates []Template `yaml:"templates"`
+	Vars      map[string]string `yaml:"vars,omitempty"` // values for ${VAR} references
+	Templates []Template        `yaml:"templates"`
+}
+
+// TemplatesPath returns the path of templates.yaml
+func TemplatesPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".config", "efx-face-manager", "templates.yaml")
 }
 
 // LoadTemplates loads templates from ~/.config/efx-face-manager/templates.yaml
+// with their variables and extends resolved.
 // Falls back to default templates if file doesn't exist
 func LoadTemplates() ([]Template, error) {
-	configDir := filepath.Join(os.Getenv("HOME"), ".config", "efx-face-manager")
-	templateFile := filepath.Join(configDir, "templates.yaml")
+	templateFile := TemplatesPath()
 	
 	// Check if file exists
 	if _, err := os.Stat(templateFile); os.IsNotExist(err) {
@@ -30,10 +36,16 @@ func LoadTemplates() ([]Template, error) {
 		return nil, err
 	}
 	
-	// Parse YAML
+	// Parse YAML, resolving variables and extends
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, yamlError(templateFile, err)
+	}
 	var config TemplateConfig
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, err
+	if doc.Kind != 0 {
+		if config.Templates, err = resolveTemplates(&doc, templateFile); err != nil {
+			return nil, err
+		}
 	}
 	
 	// Merge with defaults - custom templates override defaults with same names
@@ -62,25 +74,3 @@ func LoadTemplates() ([]Template, error) {
 	
 	return result, nil
 }
-
-// SaveTemplates saves templates to ~/.config/efx-face-manager/templates.yaml
-func SaveTemplates(templates []Template) error {
-	configDir := filepath.Join(os.Getenv("HOME"), ".config", "efx-face-manager")
-	templateFile := filepath.Join(configDir, "templates.yaml")
-	
-	// Ensure directory exists
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return err
-	}
-	
-	config := TemplateConfig{
-		Templates: templates,
-	}
-	
-	data, err := yaml.Marshal(config)
-	if err != nil {
-		return err
-	}
-	
-	return os.WriteFile(templateFile, data, 0644)
-}
\ No newline at end of file
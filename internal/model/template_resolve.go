@@ -0,0 +1,288 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// TemplateError is a problem at a line of templates.yaml
+type TemplateError struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Template string `json:"template,omitempty"` // name of the template at fault, if known
+	Msg      string `json:"message"`
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+// templateResolver turns the entries of templates.yaml into templates:
+// ${VAR} references are replaced with the vars block or the environment,
+// and the fields of the template named by extends are inherited
+type templateResolver struct {
+	file    string
+	vars    map[string]string
+	entries []*yaml.Node
+	byName  map[string]int
+
+	resolved []*yaml.Node // merged entries, by index
+	visiting []int        // the extends chain being resolved
+}
+
+// resolveTemplates returns the templates of a templates.yaml document in
+// the order of the file
+func resolveTemplates(doc *yaml.Node, file string) ([]Template, error) {
+	r, err := newTemplateResolver(doc, file)
+	if err != nil {
+		return nil, err
+	}
+	return r.templates()
+}
+
+// newTemplateResolver returns a resolver for a templates.yaml document
+func newTemplateResolver(doc *yaml.Node, file string) (*templateResolver, error) {
+	list, err := templateList(doc)
+	if err != nil {
+		return nil, yamlError(file, err)
+	}
+	r := &templateResolver{
+		file:     file,
+		vars:     make(map[string]string),
+		entries:  list.Content,
+		byName:   make(map[string]int),
+		resolved: make([]*yaml.Node, len(list.Content)),
+	}
+	if vars := mappingValue(doc.Content[0], "vars"); vars != nil {
+		if err := vars.Decode(&r.vars); err != nil {
+			return nil, r.errorf(vars, "vars must map names to values")
+		}
+	}
+	for i, entry := range r.entries {
+		if entry.Kind != yaml.MappingNode {
+			return nil, r.errorf(entry, "templates must be mappings")
+		}
+		name := mappingValue(entry, "name")
+		if name == nil {
+			continue
+		}
+		if _, exists := r.byName[name.Value]; !exists {
+			r.byName[name.Value] = i
+		}
+	}
+	return r, nil
+}
+
+// templates resolves every entry
+func (r *templateResolver) templates() ([]Template, error) {
+	templates := make([]Template, len(r.entries))
+	for i := range r.entries {
+		node, err := r.resolve(i)
+		if err != nil {
+			return nil, err
+		}
+		if err := node.Decode(&templates[i]); err != nil {
+			return nil, r.attribute(yamlError(r.file, err), i)
+		}
+	}
+	return templates, nil
+}
+
+// resolve returns entry i with its variables replaced and the fields it
+// inherits merged in
+func (r *templateResolver) resolve(i int) (*yaml.Node, error) {
+	if r.resolved[i] != nil {
+		return r.resolved[i], nil
+	}
+	for n, j := range r.visiting {
+		if j == i {
+			chain := make([]string, 0, len(r.visiting)-n+1)
+			for _, k := range r.visiting[n:] {
+				chain = append(chain, r.name(k))
+			}
+			chain = append(chain, r.name(i))
+			extends := mappingValue(r.entries[r.visiting[len(r.visiting)-1]], "extends")
+			return nil, r.attribute(r.errorf(extends, "extends cycle: %s", strings.Join(chain, " -> ")), r.visiting[len(r.visiting)-1])
+		}
+	}
+	r.visiting = append(r.visiting, i)
+	defer func() { r.visiting = r.visiting[:len(r.visiting)-1] }()
+
+	entry, err := r.interpolate(r.entries[i])
+	if err != nil {
+		return nil, r.attribute(err, i)
+	}
+	merged := entry
+	if extends := mappingValue(entry, "extends"); extends != nil {
+		parent, ok := r.byName[extends.Value]
+		if !ok {
+			return nil, r.attribute(r.errorf(extends, "template %s extends unknown template %q", r.name(i), extends.Value), i)
+		}
+		base, err := r.resolve(parent)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeTemplateNodes(base, entry)
+	}
+	r.resolved[i] = merged
+	return merged, nil
+}
+
+// mergeTemplateNodes returns the fields of base overridden by those of
+// entry. The name and extends of base are not inherited.
+func mergeTemplateNodes(base, entry *yaml.Node) *yaml.Node {
+	merged := &yaml.Node{Kind: yaml.MappingNode, Line: entry.Line, Column: entry.Column}
+	for i := 0; i+1 < len(base.Content); i += 2 {
+		key := base.Content[i].Value
+		if key == "name" || key == "extends" || mappingValue(entry, key) != nil {
+			continue
+		}
+		merged.Content = append(merged.Content, base.Content[i], base.Content[i+1])
+	}
+	merged.Content = append(merged.Content, entry.Content...)
+	return merged
+}
+
+// interpolate returns a copy of node with the ${VAR} references in its
+// values replaced. $$ stands for a literal $.
+func (r *templateResolver) interpolate(node *yaml.Node) (*yaml.Node, error) {
+	out := *node
+	if node.Kind == yaml.ScalarNode {
+		if !strings.Contains(node.Value, "$") {
+			return &out, nil
+		}
+		value, err := r.expand(node)
+		if err != nil {
+			return nil, err
+		}
+		out.Value = value
+		if out.Style == 0 {
+			// Let the value decide its type, so port: ${PORT} is a number
+			out.Tag = ""
+		}
+		return &out, nil
+	}
+
+	out.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		if node.Kind == yaml.MappingNode && i%2 == 0 {
+			out.Content[i] = child // keys are not interpolated
+			continue
+		}
+		expanded, err := r.interpolate(child)
+		if err != nil {
+			return nil, err
+		}
+		out.Content[i] = expanded
+	}
+	return &out, nil
+}
+
+// expand replaces the ${VAR} references of a scalar, looking names up in
+// the vars block first and the environment second
+func (r *templateResolver) expand(node *yaml.Node) (string, error) {
+	var b strings.Builder
+	s := node.Value
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 || i == len(s)-1 {
+			b.WriteString(s)
+			return b.String(), nil
+		}
+		b.WriteString(s[:i])
+		switch s[i+1] {
+		case '$':
+			b.WriteByte('$')
+			s = s[i+2:]
+		case '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", r.errorf(node, "unterminated variable reference in %q", node.Value)
+			}
+			name := s[i+2 : i+end]
+			if name == "" {
+				return "", r.errorf(node, "empty variable reference in %q", node.Value)
+			}
+			value, ok := r.vars[name]
+			if !ok {
+				value, ok = os.LookupEnv(name)
+			}
+			if !ok {
+				return "", r.errorf(node, "undefined variable %s: set it in vars or the environment", name)
+			}
+			b.WriteString(value)
+			s = s[i+end+1:]
+		default:
+			b.WriteByte('$')
+			s = s[i+1:]
+		}
+	}
+}
+
+// yamlError reports the errors of the YAML parser and decoder in file in
+// the file:line format of TemplateError
+func yamlError(file string, err error) error {
+	var typeErr *yaml.TypeError
+	messages := []string{strings.TrimPrefix(err.Error(), "yaml: ")}
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+	var errs []error
+	for _, msg := range messages {
+		rest, ok := strings.CutPrefix(msg, "line ")
+		lineText, text, found := strings.Cut(rest, ": ")
+		line, convErr := strconv.Atoi(lineText)
+		if !ok || !found || convErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %s", file, msg))
+			continue
+		}
+		errs = append(errs, &TemplateError{File: file, Line: line, Msg: text})
+	}
+	return errors.Join(errs...)
+}
+
+// attribute sets the template of the TemplateErrors in err that have none
+// to entry i
+func (r *templateResolver) attribute(err error, i int) error {
+	for _, e := range templateErrors(err) {
+		if e.Template == "" {
+			e.Template = r.name(i)
+		}
+	}
+	return err
+}
+
+// templateErrors returns the TemplateErrors err holds, including those
+// joined with errors.Join
+func templateErrors(err error) []*TemplateError {
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		var all []*TemplateError
+		for _, e := range joined.Unwrap() {
+			all = append(all, templateErrors(e)...)
+		}
+		return all
+	}
+	var te *TemplateError
+	if errors.As(err, &te) {
+		return []*TemplateError{te}
+	}
+	return nil
+}
+
+// name returns the name of entry i for messages
+func (r *templateResolver) name(i int) string {
+	if name := mappingValue(r.entries[i], "name"); name != nil {
+		return name.Value
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// errorf returns a TemplateError at the line of node
+func (r *templateResolver) errorf(node *yaml.Node, format string, args ...any) error {
+	return &TemplateError{File: r.file, Line: node.Line, Msg: fmt.Sprintf(format, args...)}
+}
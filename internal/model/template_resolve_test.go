@@ -0,0 +1,147 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func resolveYAML(t *testing.T, data string) ([]Template, error) {
+	t.Helper()
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &doc); err != nil {
+		t.Fatal(err)
+	}
+	return resolveTemplates(&doc, "templates.yaml")
+}
+
+func TestResolveTemplates(t *testing.T) {
+	t.Setenv("EFX_TEST_PORT", "8123")
+
+	for _, tt := range []struct {
+		name string
+		yaml string
+		want Template // the last template of the file
+	}{
+		{
+			name: "multi-level extends",
+			yaml: `templates:
+  - name: base
+    model_name: qwen
+    model_type: lm
+    port: 8000
+    tool_call_parser: qwen3
+  - name: mid
+    extends: base
+    port: 8001
+    context_length: 8192
+  - name: leaf
+    extends: mid
+    debug: true
+`,
+			want: Template{Name: "leaf", Extends: "mid", ModelName: "qwen", ModelType: TypeLM, Port: 8001, ToolCallParser: "qwen3", ContextLength: 8192, Debug: true},
+		},
+		{
+			name: "null clears an inherited field",
+			yaml: `templates:
+  - name: base
+    model_name: qwen
+    model_type: lm
+    port: 8000
+    tool_call_parser: qwen3
+  - name: child
+    extends: base
+    port: null
+    tool_call_parser: null
+`,
+			want: Template{Name: "child", Extends: "base", ModelName: "qwen", ModelType: TypeLM},
+		},
+		{
+			name: "variables",
+			yaml: `vars:
+  MODEL: qwen
+templates:
+  - name: child
+    model_name: ${MODEL}
+    model_type: lm
+    port: ${EFX_TEST_PORT}
+    description: costs $$5 on ${MODEL}
+`,
+			want: Template{Name: "child", ModelName: "qwen", ModelType: TypeLM, Port: 8123, Description: "costs $5 on qwen"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			templates, err := resolveYAML(t, tt.yaml)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := templates[len(templates)-1]; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveTemplatesErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		yaml     string
+		line     int
+		template string
+		msg      string
+	}{
+		{
+			name: "undefined variable",
+			yaml: `templates:
+  - name: qwen
+    model_name: ${EFX_TEST_UNDEFINED}
+`,
+			line: 3, template: "qwen", msg: "undefined variable EFX_TEST_UNDEFINED",
+		},
+		{
+			name: "extends cycle",
+			yaml: `templates:
+  - name: a
+    extends: b
+  - name: b
+    extends: a
+`,
+			line: 5, template: "b", msg: "extends cycle: a -> b -> a",
+		},
+		{
+			name: "unknown parent",
+			yaml: `templates:
+  - name: child
+    extends: missing
+`,
+			line: 3, template: "child", msg: `extends unknown template "missing"`,
+		},
+		{
+			name: "wrong type",
+			yaml: `templates:
+  - name: qwen
+    model_name: qwen
+    port: eighty
+`,
+			line: 4, template: "qwen", msg: "cannot unmarshal",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := resolveYAML(t, tt.yaml)
+			var te *TemplateError
+			if !errors.As(err, &te) {
+				t.Fatalf("error %v, want a TemplateError", err)
+			}
+			if te.File != "templates.yaml" || te.Line != tt.line || te.Template != tt.template || !strings.Contains(te.Msg, tt.msg) {
+				t.Errorf("error %+v, want templates.yaml:%d in %s with %q", *te, tt.line, tt.template, tt.msg)
+			}
+			if want := fmt.Sprintf("templates.yaml:%d: ", tt.line); !strings.HasPrefix(err.Error(), want) {
+				t.Errorf("message %q, want it to start with %q", err, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateTemplates checks templates.yaml and returns its problems in the
+// order of the file, each at the line that causes it. Problems that keep
+// the file from loading are reported like the others. A template shares
+// the problems of the fields it inherits. store may be nil to skip checking
+// that the models of templates are installed.
+func ValidateTemplates(store *Store) ([]*TemplateError, error) {
+	file := TemplatesPath()
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return problemsOf(yamlError(file, err))
+	}
+	if doc.Kind == 0 {
+		return nil, nil
+	}
+	r, err := newTemplateResolver(&doc, file)
+	if err != nil {
+		return problemsOf(err)
+	}
+
+	var problems []*TemplateError
+	report := func(p *TemplateError) {
+		for _, seen := range problems {
+			if *seen == *p {
+				return
+			}
+		}
+		problems = append(problems, p)
+	}
+	firstLine := make(map[string]int)
+	known := templateKeys()
+	for i, entry := range r.entries {
+		node, err := r.resolve(i)
+		var t Template
+		if err == nil {
+			if decodeErr := node.Decode(&t); decodeErr != nil {
+				err = r.attribute(yamlError(file, decodeErr), i)
+			}
+		}
+		if err != nil {
+			found, err := problemsOf(err)
+			if err != nil {
+				return nil, err
+			}
+			for _, p := range found {
+				report(p)
+			}
+			continue
+		}
+
+		problem := func(key, format string, args ...any) {
+			line := entry.Line
+			if value := mappingValue(node, key); value != nil {
+				line = value.Line
+			}
+			report(&TemplateError{File: file, Line: line, Template: t.Name, Msg: fmt.Sprintf(format, args...)})
+		}
+
+		for k := 0; k+1 < len(entry.Content); k += 2 {
+			if key := entry.Content[k]; !known[key.Value] {
+				report(&TemplateError{File: file, Line: key.Line, Template: t.Name, Msg: fmt.Sprintf("unknown field %q", key.Value)})
+			}
+		}
+
+		if t.Name == "" {
+			problem("name", "template has no name")
+		} else if line, dup := firstLine[t.Name]; dup {
+			problem("name", "duplicate template name %q, first defined at line %d", t.Name, line)
+		} else {
+			firstLine[t.Name] = mappingValue(node, "name").Line
+		}
+
+		switch {
+		case t.ModelName == "":
+			problem("model_name", "model_name is missing")
+		case store != nil && !store.Exists(t.ModelName):
+			problem("model_name", "model %s is not installed", t.ModelName)
+		}
+
+		if !t.ModelType.Valid() {
+			types := make([]string, len(ModelTypes))
+			for i, mt := range ModelTypes {
+				types[i] = string(mt)
+			}
+			if t.ModelType == "" {
+				problem("model_type", "model_type is missing: use %s", strings.Join(types, ", "))
+			} else {
+				problem("model_type", "invalid model_type %q: use %s", t.ModelType, strings.Join(types, ", "))
+			}
+		}
+
+		for _, check := range []struct {
+			key, value string
+			known      []string
+		}{
+			{"tool_call_parser", t.ToolCallParser, ToolCallParsers},
+			{"reasoning_parser", t.ReasoningParser, ReasoningParsers},
+			{"message_converter", t.MessageConverter, MessageConverters},
+		} {
+			if check.value != "" && !slices.Contains(check.known, check.value) {
+				problem(check.key, "unknown %s %q: use %s", check.key, check.value, strings.Join(check.known, ", "))
+			}
+		}
+
+		if t.Port < 0 || t.Port > 65535 {
+			problem("port", "port %d is out of the range 1-65535", t.Port)
+		}
+	}
+	return problems, nil
+}
+
+// problemsOf returns the TemplateErrors of err, or err when it holds none
+func problemsOf(err error) ([]*TemplateError, error) {
+	if problems := templateErrors(err); len(problems) > 0 {
+		return problems, nil
+	}
+	return nil, err
+}
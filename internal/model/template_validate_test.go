@@ -0,0 +1,187 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTemplates makes data the templates.yaml of a new home
+func writeTemplates(t *testing.T, data string) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	file := TemplatesPath()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	type problem struct {
+		line     int
+		template string
+		msg      string
+	}
+	for _, tt := range []struct {
+		name string
+		yaml string
+		want []problem
+	}{
+		{
+			name: "valid",
+			yaml: `templates:
+  - name: qwen
+    model_name: qwen
+    model_type: lm
+    port: 8000
+    tool_call_parser: qwen3
+`,
+		},
+		{
+			name: "unknown field",
+			yaml: `templates:
+  - name: qwen
+    model_name: qwen
+    model_type: lm
+    tool_parser: qwen3
+`,
+			want: []problem{{5, "qwen", `unknown field "tool_parser"`}},
+		},
+		{
+			name: "bad port",
+			yaml: `templates:
+  - name: qwen
+    model_name: qwen
+    model_type: lm
+    port: 70000
+`,
+			want: []problem{{5, "qwen", "port 70000 is out of the range 1-65535"}},
+		},
+		{
+			name: "missing model",
+			yaml: `templates:
+  - name: qwen
+    model_type: lm
+`,
+			want: []problem{{2, "qwen", "model_name is missing"}},
+		},
+		{
+			name: "model not installed",
+			yaml: `templates:
+  - name: llama
+    model_name: llama
+    model_type: lm
+`,
+			want: []problem{{3, "llama", "model llama is not installed"}},
+		},
+		{
+			name: "invalid model type",
+			yaml: `templates:
+  - name: qwen
+    model_name: qwen
+    model_type: chat
+`,
+			want: []problem{{4, "qwen", `invalid model_type "chat"`}},
+		},
+		{
+			name: "missing model type",
+			yaml: `templates:
+  - name: qwen
+    model_name: qwen
+`,
+			want: []problem{{2, "qwen", "model_type is missing"}},
+		},
+		{
+			name: "unknown parser",
+			yaml: `templates:
+  - name: qwen
+    model_name: qwen
+    model_type: lm
+    reasoning_parser: qwen4
+`,
+			want: []problem{{5, "qwen", `unknown reasoning_parser "qwen4"`}},
+		},
+		{
+			name: "duplicate name",
+			yaml: `templates:
+  - name: qwen
+    model_name: qwen
+    model_type: lm
+  - name: qwen
+    model_name: qwen
+    model_type: lm
+`,
+			want: []problem{{5, "qwen", `duplicate template name "qwen", first defined at line 2`}},
+		},
+		{
+			name: "no name",
+			yaml: `templates:
+  - model_name: qwen
+    model_type: lm
+`,
+			want: []problem{{2, "", "template has no name"}},
+		},
+		{
+			name: "inherited problem",
+			yaml: `templates:
+  - name: base
+    model_name: qwen
+    model_type: lm
+    port: 0x1ffff
+  - name: child
+    extends: base
+`,
+			want: []problem{
+				{5, "base", "port 131071 is out of the range"},
+				{5, "child", "port 131071 is out of the range"},
+			},
+		},
+		{
+			name: "undefined variable",
+			yaml: `templates:
+  - name: qwen
+    model_name: ${EFX_TEST_UNDEFINED}
+    model_type: lm
+`,
+			want: []problem{{3, "qwen", "undefined variable EFX_TEST_UNDEFINED"}},
+		},
+		{
+			name: "invalid yaml",
+			yaml: `templates:
+  - name: qwen
+    model_name: "qwen
+    model_type: lm
+`,
+			want: []problem{{3, "", "found unexpected end of stream"}},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			writeTemplates(t, tt.yaml)
+			models := t.TempDir()
+			if err := os.Mkdir(filepath.Join(models, "qwen"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			problems, err := ValidateTemplates(NewStore(models))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(problems) != len(tt.want) {
+				for _, p := range problems {
+					t.Log(p)
+				}
+				t.Fatalf("%d problems, want %d", len(problems), len(tt.want))
+			}
+			for i, p := range problems {
+				want := tt.want[i]
+				if p.File != TemplatesPath() || p.Line != want.line || p.Template != want.template || !strings.Contains(p.Msg, want.msg) {
+					t.Errorf("problem %s (template %q), want line %d of %q with %q", p, p.Template, want.line, want.template, want.msg)
+				}
+			}
+		})
+	}
+}
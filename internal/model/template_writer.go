@@ -0,0 +1,310 @@
+package model
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SaveTemplates writes templates to ~/.config/efx-face-manager/templates.yaml.
+// The file is edited rather than rewritten: templates already in it keep
+// their position, comments and quoting, and only the fields that changed
+// are touched. New templates are appended and templates missing from the
+// list are removed. A template whose name is not in the file replaces the
+// removed one at the same position, so renaming keeps its place. Fields
+// that resolve to the value a template already has, through a ${VAR}
+// reference or extends, are left as written. Clearing a field the extended
+// template sets writes it as null.
+func SaveTemplates(templates []Template) error {
+	templateFile := TemplatesPath()
+
+	var doc yaml.Node
+	data, err := os.ReadFile(templateFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return yamlError(templateFile, err)
+		}
+	}
+	list, err := templateList(&doc)
+	if err != nil {
+		return yamlError(templateFile, err)
+	}
+	resolved, err := resolveTemplates(&doc, templateFile)
+	if err != nil {
+		return err
+	}
+
+	// Match templates to the entries of the file by name, then by position
+	existing := list.Content
+	byName := make(map[string]int)
+	for i, entry := range existing {
+		if name := mappingValue(entry, "name"); name != nil {
+			byName[name.Value] = i
+		}
+	}
+	used := make([]bool, len(existing))
+	nodes := make([]*yaml.Node, len(templates))
+	for i, t := range templates {
+		if j, ok := byName[t.Name]; ok && !used[j] {
+			used[j] = true
+			nodes[i] = existing[j]
+		}
+	}
+	for i := range templates {
+		if nodes[i] == nil && i < len(existing) && !used[i] {
+			if _, named := byName[templates[i].Name]; !named {
+				used[i] = true
+				nodes[i] = existing[i]
+			}
+		}
+	}
+
+	for i, t := range templates {
+		var have Template
+		for j, entry := range existing {
+			if entry == nodes[i] {
+				have = resolved[j]
+			}
+		}
+		var base *Template
+		for j := range templates {
+			if t.Extends != "" && templates[j].Name == t.Extends {
+				base = &templates[j]
+				break
+			}
+		}
+		if nodes[i] == nil {
+			nodes[i] = &yaml.Node{Kind: yaml.MappingNode}
+		}
+		if err := setTemplateNode(nodes[i], t, have, base); err != nil {
+			return err
+		}
+	}
+
+	// Entries stay where they were, new ones go last
+	var content []*yaml.Node
+	for j, entry := range existing {
+		if used[j] {
+			content = append(content, entry)
+		}
+	}
+	for _, node := range nodes {
+		if !containsNode(existing, node) {
+			content = append(content, node)
+		}
+	}
+	list.Content = content
+
+	// Refuse to write a file that no longer loads, such as one where a
+	// template extends a deleted one
+	if _, err := resolveTemplates(&doc, templateFile); err != nil {
+		return fmt.Errorf("cannot save templates: %w", err)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(templateFile), 0755); err != nil {
+		return err
+	}
+	tmp := templateFile + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, templateFile)
+}
+
+// templateList returns the templates sequence of a templates.yaml document,
+// adding it when the document has none
+func templateList(doc *yaml.Node) (*yaml.Node, error) {
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping with a templates key", root.Line)
+	}
+
+	list := mappingValue(root, "templates")
+	if list == nil {
+		list = &yaml.Node{Kind: yaml.SequenceNode}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: "templates"}, list)
+	}
+	switch {
+	case list.Kind == yaml.ScalarNode && list.Tag == "!!null":
+		// templates: with nothing under it
+		*list = yaml.Node{Kind: yaml.SequenceNode, HeadComment: list.HeadComment, LineComment: list.LineComment}
+	case list.Kind != yaml.SequenceNode:
+		return nil, fmt.Errorf("line %d: templates must be a list", list.Line)
+	}
+	list.Style = 0
+	return list, nil
+}
+
+// mappingValue returns the value of key in a mapping node, or nil
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setTemplateNode makes the mapping node of a template describe t, where
+// have is the template the node resolves to now and base the template it
+// extends, if any. Keys keep their order, comments and quoting; values that
+// did not change are left alone, fields t leaves empty are removed and keys
+// Template does not know are kept. Fields t inherits unchanged through
+// extends are not written, and fields t clears that base sets are written
+// as null so they are not inherited.
+func setTemplateNode(node *yaml.Node, t, have Template, base *Template) error {
+	want, order, err := templateFields(t)
+	if err != nil {
+		return err
+	}
+	current, _, err := templateFields(have)
+	if err != nil {
+		return err
+	}
+	inherited := make(map[string]*yaml.Node)
+	var inheritedOrder []string
+	if base != nil {
+		if inherited, inheritedOrder, err = templateFields(*base); err != nil {
+			return err
+		}
+	}
+	known := templateKeys()
+	cleared := func(key string) bool {
+		_, set := want[key]
+		return !set && inherited[key] != nil && key != "name" && key != "extends"
+	}
+
+	// A comment below the last field closes the entry, whichever field
+	// ends up last
+	var foot string
+	if n := len(node.Content); n >= 2 {
+		foot, node.Content[n-2].FootComment = node.Content[n-2].FootComment, ""
+	}
+
+	var content []*yaml.Node
+	seen := make(map[string]bool)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		newValue, ok := want[key.Value]
+		if cleared(key.Value) {
+			newValue, ok = nullNode(), true
+		}
+		if !ok {
+			if known[key.Value] {
+				continue
+			}
+			content = append(content, key, value)
+			continue
+		}
+		seen[key.Value] = true
+		if old := current[key.Value]; old != nil && sameValue(old, newValue) {
+			// Unchanged, possibly written as a ${VAR} reference
+		} else if !sameValue(value, newValue) {
+			if value.Kind == yaml.ScalarNode && newValue.Kind == yaml.ScalarNode && newValue.Tag == "!!str" {
+				newValue.Style = value.Style
+			}
+			newValue.LineComment = value.LineComment
+			value = newValue
+		}
+		content = append(content, key, value)
+	}
+	for _, key := range order {
+		if old := current[key]; old != nil && sameValue(old, want[key]) {
+			continue // inherited
+		}
+		if !seen[key] {
+			content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, want[key])
+		}
+	}
+	for _, key := range inheritedOrder {
+		if cleared(key) && !seen[key] {
+			content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, nullNode())
+		}
+	}
+	if foot != "" && len(content) >= 2 {
+		content[len(content)-2].FootComment = foot
+	}
+	node.Kind = yaml.MappingNode
+	node.Style = 0
+	node.Content = content
+	return nil
+}
+
+// templateFields returns the YAML values of the fields t sets by key, and
+// the keys in order
+func templateFields(t Template) (map[string]*yaml.Node, []string, error) {
+	var node yaml.Node
+	if err := node.Encode(t); err != nil {
+		return nil, nil, err
+	}
+	fields := make(map[string]*yaml.Node)
+	var order []string
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i].Value
+		fields[key] = node.Content[i+1]
+		order = append(order, key)
+	}
+	return fields, order, nil
+}
+
+// nullNode returns a YAML null value
+func nullNode() *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
+
+// sameValue reports whether two nodes decode to the same value
+func sameValue(a, b *yaml.Node) bool {
+	var va, vb any
+	if a.Decode(&va) != nil || b.Decode(&vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+// templateKeys returns the YAML keys of the fields of Template
+func templateKeys() map[string]bool {
+	keys := make(map[string]bool)
+	typ := reflect.TypeOf(Template{})
+	for i := 0; i < typ.NumField(); i++ {
+		name, _, _ := strings.Cut(typ.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}
+
+// containsNode reports whether nodes holds node itself
+func containsNode(nodes []*yaml.Node, node *yaml.Node) bool {
+	for _, n := range nodes {
+		if n == node {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveTemplatesClearsInheritedField(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	file := TemplatesPath()
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, []byte(`templates:
+  - name: base
+    model_name: qwen
+    model_type: lm
+    port: 8001
+    tool_call_parser: qwen3
+  - name: child
+    extends: base
+    context_length: 8192
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if child := templates[1]; child.ToolCallParser != "qwen3" || child.Port != 8001 {
+		t.Fatalf("child before the edit: %+v, want the fields of base", child)
+	}
+	templates[1].ToolCallParser = ""
+	templates[1].Port = 0
+	if err := SaveTemplates(templates); err != nil {
+		t.Fatal(err)
+	}
+
+	templates, err = LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base, child := templates[0], templates[1]
+	if child.ToolCallParser != "" || child.Port != 0 {
+		t.Errorf("child after clearing: tool_call_parser %q, port %d; want both empty", child.ToolCallParser, child.Port)
+	}
+	if child.ModelName != "qwen" || child.ContextLength != 8192 {
+		t.Errorf("child lost its other fields: %+v", child)
+	}
+	if base.ToolCallParser != "qwen3" || base.Port != 8001 {
+		t.Errorf("base changed: %+v", base)
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, childYAML, _ := strings.Cut(string(data), "name: child")
+	for _, want := range []string{"port: null", "tool_call_parser: null"} {
+		if !strings.Contains(childYAML, want) {
+			t.Errorf("child written without %q:\n%s", want, childYAML)
+		}
+	}
+	if strings.Contains(childYAML, "model_name") {
+		t.Errorf("child written with the model_name it inherits:\n%s", childYAML)
+	}
+}
+
+func TestSaveTemplatesKeepsLayout(t *testing.T) {
+	writeTemplates(t, `# Templates for the office machine
+# Keep the coder first
+
+vars:
+  PORT: "8000" # default port
+templates:
+  # Daily driver
+  - name: coder
+    port: ${PORT}
+    model_type: lm
+    model_name: qwen3-coder # the 30B one
+    tool_call_parser: qwen3_coder
+    # end of coder
+  - name: chat
+    model_name: "llama"
+    model_type: lm
+    description: 'Quoted, on purpose'
+    # end of chat
+# trailing notes
+`)
+	templates, err := LoadTemplates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates[0].ContextLength = 32768
+	templates[1].Port = 8002
+	templates[1].Description = ""
+	templates = append(templates, Template{Name: "embed", ModelName: "bge", ModelType: TypeEmbeddings})
+	if err := SaveTemplates(templates); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(TemplatesPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Templates for the office machine
+# Keep the coder first
+
+vars:
+  PORT: "8000" # default port
+templates:
+  # Daily driver
+  - name: coder
+    port: ${PORT}
+    model_type: lm
+    model_name: qwen3-coder # the 30B one
+    tool_call_parser: qwen3_coder
+    context_length: 32768
+    # end of coder
+  - name: chat
+    model_name: "llama"
+    model_type: lm
+    port: 8002
+    # end of chat
+  - name: embed
+    model_name: bge
+    model_type: embeddings
+# trailing notes
+`
+	if string(got) != want {
+		t.Errorf("saved\n%s\nwant\n%s", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package model
 // Template represents a predefined model configuration
 type Template struct {
 	Name             string   `yaml:"name"`
+	Extends          string   `yaml:"extends,omitempty"` // template whose fields this one inherits
 	ModelName        string   `yaml:"model_name"`
 	ModelType        ModelType `yaml:"model_type"`
 	ReasoningParser  string   `yaml:"reasoning_parser,omitempty"`
@@ -13,8 +14,38 @@ type Template struct {
 	Port             int      `yaml:"port,omitempty"`
 	Host             string   `yaml:"host,omitempty"`
 	Description      string   `yaml:"description,omitempty"`
+	Restart          string   `yaml:"restart,omitempty"`     // never, on-failure or always
+	MaxRetries       int      `yaml:"max_retries,omitempty"` // consecutive restarts before giving up
+
+	ContextLength     int    `yaml:"context_length,omitempty"`
+	DisableAutoResize bool   `yaml:"disable_auto_resize,omitempty"` // multimodal
+	ChatTemplateFile  string `yaml:"chat_template_file,omitempty"`
+	LogLevel          string `yaml:"log_level,omitempty"` // DEBUG, INFO, WARNING, ERROR or CRITICAL
+
+	// Image generation/edit specific
+	ConfigName string `yaml:"config_name,omitempty"`
+	Quantize   int    `yaml:"quantize,omitempty"`
+	LoraPaths  string `yaml:"lora_paths,omitempty"`
+	LoraScales string `yaml:"lora_scales,omitempty"`
+
+	// Whisper/embeddings specific, defaulting to 1, 300 and 100
+	MaxConcurrency int `yaml:"max_concurrency,omitempty"`
+	QueueTimeout   int `yaml:"queue_timeout,omitempty"`
+	QueueSize      int `yaml:"queue_size,omitempty"`
+
+	// ExtraArgs are passed to mlx-openai-server as they are, after the
+	// arguments built from the fields above
+	ExtraArgs []string `yaml:"extra_args,omitempty"`
 }
 
+// Parser and message converter names mlx-openai-server accepts, offered by
+// the configuration panel and checked by ValidateTemplates
+var (
+	ToolCallParsers   = []string{"qwen3", "glm4_moe", "qwen3_coder", "qwen3_moe", "qwen3_next", "qwen3_vl", "harmony", "minimax_m2"}
+	ReasoningParsers  = []string{"qwen3", "glm4_moe", "qwen3_coder", "qwen3_moe", "qwen3_next", "qwen3_vl", "harmony", "minimax_m2", "glm47_flash"}
+	MessageConverters = []string{"glm4_moe", "minimax_m2", "nemotron3_nano", "qwen3_coder"}
+)
+
 // DefaultTemplates returns an empty slice - all templates now come from YAML config
 func DefaultTemplates() []Template {
 	return []Template{}
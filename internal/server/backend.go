@@ -0,0 +1,114 @@
+package server
+
+import "time"
+
+// Backend is the set of server operations used by the TUI and CLI commands.
+// It is implemented by Manager for in-process servers and by daemon.Client
+// for servers hosted by a running efx-face daemon.
+type Backend interface {
+	Start(config Config) (*Instance, error)
+	Stop(port int) error
+	StopAll() error
+	Restart(port int) (*Instance, error)
+	Get(port int) *Instance
+	GetLogs(port int) string
+	GetLogEntries(port int) []LogEntry
+	ClearLogs(port int)
+	List() []*Instance
+	Count() int
+	IsPortInUse(port int) bool
+	CheckPort(host string, port int) error
+	NextAvailablePort(startPort int) int
+	UpdateChan() <-chan Update
+}
+
+// InstanceInfo is a serializable snapshot of an Instance
+type InstanceInfo struct {
+	Model     string    `json:"model"`
+	Type      string    `json:"type"`
+	Port      int       `json:"port"`
+	Host      string    `json:"host"`
+	Args      []string  `json:"args"`
+	PID       int       `json:"pid,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Running   bool      `json:"running"`
+
+	State       State     `json:"state"`
+	StateSince  time.Time `json:"state_since"`
+	ReadyAt     time.Time `json:"ready_at,omitempty"`
+	HealthError string    `json:"health_error,omitempty"`
+
+	Usage        ResourceUsage   `json:"usage"`
+	UsageHistory []ResourceUsage `json:"usage_history,omitempty"`
+
+	Restart   RestartPolicy `json:"restart,omitempty"`
+	Restarts  int           `json:"restarts,omitempty"`
+	CrashLoop bool          `json:"crash_loop,omitempty"`
+	LastExit  *ExitInfo     `json:"last_exit,omitempty"`
+
+	Adopted bool `json:"adopted,omitempty"`
+	RunID   int  `json:"run_id,omitempty"`
+}
+
+// Info returns a snapshot of the instance
+func (i *Instance) Info() InstanceInfo {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return InstanceInfo{
+		Model:     i.Model,
+		Type:      i.Type,
+		Port:      i.Port,
+		Host:      i.Host,
+		Args:      i.Args,
+		PID:       i.PID,
+		StartedAt: i.StartedAt,
+		Running:   i.Running,
+
+		State:       i.State,
+		StateSince:  i.StateSince,
+		ReadyAt:     i.ReadyAt,
+		HealthError: i.HealthError,
+
+		Usage:        i.Usage,
+		UsageHistory: i.UsageHistory,
+
+		Restart:   i.Restart,
+		Restarts:  i.Restarts,
+		CrashLoop: i.CrashLoop,
+		LastExit:  i.LastExit,
+
+		Adopted: i.Adopted,
+		RunID:   i.RunID,
+	}
+}
+
+// Instance rebuilds a detached Instance from a snapshot. The result has no
+// process, PTY or output buffer attached.
+func (info InstanceInfo) Instance() *Instance {
+	return &Instance{
+		Model:     info.Model,
+		Type:      info.Type,
+		Port:      info.Port,
+		Host:      info.Host,
+		Args:      info.Args,
+		PID:       info.PID,
+		StartedAt: info.StartedAt,
+		Running:   info.Running,
+
+		State:       info.State,
+		StateSince:  info.StateSince,
+		ReadyAt:     info.ReadyAt,
+		HealthError: info.HealthError,
+
+		Usage:        info.Usage,
+		UsageHistory: info.UsageHistory,
+
+		Restart:   info.Restart,
+		Restarts:  info.Restarts,
+		CrashLoop: info.CrashLoop,
+		LastExit:  info.LastExit,
+
+		Adopted: info.Adopted,
+		RunID:   info.RunID,
+	}
+}
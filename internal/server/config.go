@@ -8,31 +8,38 @@ import (
 
 // Config holds server configuration
 type Config struct {
-	Model            string
-	ModelPath        string
-	Type             model.ModelType
-	Port             int
-	Host             string
-	ContextLength    int
-	ToolCallParser   string
-	ReasoningParser  string
-	MessageConverter string
-	TrustRemoteCode  bool
-	Debug            bool
-	DisableAutoResize bool
-	ChatTemplateFile string
-	LogLevel         string
-	
+	Model             string          `json:"model"`
+	ModelPath         string          `json:"model_path"`
+	Type              model.ModelType `json:"type"`
+	Port              int             `json:"port"`
+	Host              string          `json:"host"`
+	ContextLength     int             `json:"context_length,omitempty"`
+	ToolCallParser    string          `json:"tool_call_parser,omitempty"`
+	ReasoningParser   string          `json:"reasoning_parser,omitempty"`
+	MessageConverter  string          `json:"message_converter,omitempty"`
+	TrustRemoteCode   bool            `json:"trust_remote_code,omitempty"`
+	Debug             bool            `json:"debug,omitempty"`
+	DisableAutoResize bool            `json:"disable_auto_resize,omitempty"`
+	ChatTemplateFile  string          `json:"chat_template_file,omitempty"`
+	LogLevel          string          `json:"log_level,omitempty"`
+
 	// Image generation/edit specific
-	ConfigName  string
-	Quantize    int
-	LoraPaths   string
-	LoraScales  string
-	
+	ConfigName string `json:"config_name,omitempty"`
+	Quantize   int    `json:"quantize,omitempty"`
+	LoraPaths  string `json:"lora_paths,omitempty"`
+	LoraScales string `json:"lora_scales,omitempty"`
+
 	// Whisper/embeddings specific
-	MaxConcurrency int
-	QueueTimeout   int
-	QueueSize      int
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	QueueTimeout   int `json:"queue_timeout,omitempty"`
+	QueueSize      int `json:"queue_size,omitempty"`
+
+	// Restart policy applied when the process exits
+	Restart    RestartPolicy `json:"restart,omitempty"`
+	MaxRetries int           `json:"max_retries,omitempty"`
+
+	// Arguments passed through to mlx-openai-server after the others
+	ExtraArgs []string `json:"extra_args,omitempty"`
 }
 
 // NewConfig creates a new server config with defaults
@@ -106,22 +113,101 @@ func (c *Config) BuildArgs() []string {
 	if c.LogLevel != "" && c.LogLevel != "INFO" {
 		args = append(args, "--log-level", c.LogLevel)
 	}
+
+	args = append(args, c.ExtraArgs...)
 	
 	return args
 }
 
-// FromTemplate creates a config from a model template
+// FromTemplate creates a config from a model template. Queue settings the
+// template leaves out get the defaults of NewConfig.
 func FromTemplate(t *model.Template, modelDir string) Config {
-	return Config{
-		Model:            t.ModelName,
-		ModelPath:        modelDir + "/" + t.ModelName,
-		Type:             t.ModelType,
-		Port:             t.Port,
-		Host:             t.Host,
-		ReasoningParser:  t.ReasoningParser,
-		ToolCallParser:   t.ToolCallParser,
-		MessageConverter: t.MessageConverter,
-		TrustRemoteCode:  t.TrustRemoteCode,
-		Debug:            t.Debug,
+	defaults := NewConfig()
+	cfg := Config{
+		Model:             t.ModelName,
+		ModelPath:         modelDir + "/" + t.ModelName,
+		Type:              t.ModelType,
+		Port:              t.Port,
+		Host:              t.Host,
+		ContextLength:     t.ContextLength,
+		ReasoningParser:   t.ReasoningParser,
+		ToolCallParser:    t.ToolCallParser,
+		MessageConverter:  t.MessageConverter,
+		TrustRemoteCode:   t.TrustRemoteCode,
+		Debug:             t.Debug,
+		DisableAutoResize: t.DisableAutoResize,
+		ChatTemplateFile:  t.ChatTemplateFile,
+		LogLevel:          t.LogLevel,
+		ConfigName:        t.ConfigName,
+		Quantize:          t.Quantize,
+		LoraPaths:         t.LoraPaths,
+		LoraScales:        t.LoraScales,
+		MaxConcurrency:    t.MaxConcurrency,
+		QueueTimeout:      t.QueueTimeout,
+		QueueSize:         t.QueueSize,
+		Restart:           RestartPolicy(t.Restart),
+		MaxRetries:        t.MaxRetries,
+		ExtraArgs:         t.ExtraArgs,
+	}
+	if cfg.MaxConcurrency == 0 {
+		cfg.MaxConcurrency = defaults.MaxConcurrency
+	}
+	if cfg.QueueTimeout == 0 {
+		cfg.QueueTimeout = defaults.QueueTimeout
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = defaults.QueueSize
+	}
+	return cfg
+}
+
+// ToTemplate creates a template that FromTemplate turns back into c. Fields
+// BuildArgs ignores for the model type are left out.
+func (c Config) ToTemplate(name, description string) model.Template {
+	t := model.Template{
+		Name:              name,
+		ModelName:         c.Model,
+		ModelType:         c.Type,
+		ReasoningParser:   c.ReasoningParser,
+		ToolCallParser:    c.ToolCallParser,
+		MessageConverter:  c.MessageConverter,
+		TrustRemoteCode:   c.TrustRemoteCode,
+		Debug:             c.Debug,
+		Port:              c.Port,
+		Host:              c.Host,
+		Description:       description,
+		Restart:           string(c.Restart),
+		MaxRetries:        c.MaxRetries,
+		ContextLength:     c.ContextLength,
+		DisableAutoResize: c.DisableAutoResize,
+		ChatTemplateFile:  c.ChatTemplateFile,
+		LogLevel:          c.LogLevel,
+		ConfigName:        c.ConfigName,
+		Quantize:          c.Quantize,
+		LoraPaths:         c.LoraPaths,
+		LoraScales:        c.LoraScales,
+		MaxConcurrency:    c.MaxConcurrency,
+		QueueTimeout:      c.QueueTimeout,
+		QueueSize:         c.QueueSize,
+		ExtraArgs:         c.ExtraArgs,
+	}
+	switch c.Type {
+	case model.TypeLM, model.TypeMultimodal:
+		t.ConfigName, t.Quantize, t.LoraPaths, t.LoraScales = "", 0, "", ""
+		t.MaxConcurrency, t.QueueTimeout, t.QueueSize = 0, 0, 0
+	case model.TypeImageGeneration, model.TypeImageEdit:
+		t.ContextLength, t.ChatTemplateFile = 0, ""
+		t.ReasoningParser, t.ToolCallParser, t.MessageConverter = "", "", ""
+		t.TrustRemoteCode, t.Debug = false, false
+		t.MaxConcurrency, t.QueueTimeout, t.QueueSize = 0, 0, 0
+	case model.TypeWhisper, model.TypeEmbeddings:
+		t.ContextLength, t.ChatTemplateFile = 0, ""
+		t.ReasoningParser, t.ToolCallParser, t.MessageConverter = "", "", ""
+		t.TrustRemoteCode, t.Debug = false, false
+		t.ConfigName, t.Quantize, t.LoraPaths, t.LoraScales = "", 0, "", ""
+	}
+	if c.Type != model.TypeMultimodal {
+		t.DisableAutoResize = false
 	}
+	return t
 }
@@ -76,7 +76,8 @@ func RequiredTools() map[string]DetectionResult {
 	}
 }
 
-// CheckRequirements returns any missing required tools
+// CheckRequirements returns any missing required tools. The Hugging Face
+// CLI is optional since models are downloaded natively.
 func CheckRequirements() []string {
 	missing := []string{}
 	
@@ -84,9 +85,6 @@ func CheckRequirements() []string {
 	if !tools["mlx-openai-server"].Installed {
 		missing = append(missing, "mlx-openai-server (pip install mlx-openai-server)")
 	}
-	if !tools["huggingface-cli"].Installed {
-		missing = append(missing, "huggingface-cli (pip install huggingface_hub)")
-	}
 	
 	return missing
 }
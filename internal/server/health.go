@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// State is the lifecycle state of a server instance
+type State string
+
+const (
+	StateStarting   State = "starting"   // process running, API not answering yet
+	StateReady      State = "ready"      // API answering health probes
+	StateUnhealthy  State = "unhealthy"  // API stopped answering after being ready
+	StateRestarting State = "restarting" // process exited, waiting to restart
+	StateStopped    State = "stopped"    // process exited
+)
+
+// HealthChecker probes instances and drives their state
+type HealthChecker struct {
+	StartingInterval time.Duration // probe interval while loading
+	Interval         time.Duration // probe interval once ready
+	Timeout          time.Duration // timeout of a single probe
+	FailureThreshold int           // consecutive failures before unhealthy
+	client           *http.Client
+}
+
+// NewHealthChecker creates a checker with the default intervals
+func NewHealthChecker() *HealthChecker {
+	return &HealthChecker{
+		StartingInterval: time.Second,
+		Interval:         5 * time.Second,
+		Timeout:          2 * time.Second,
+		FailureThreshold: 3,
+		client:           &http.Client{},
+	}
+}
+
+// Check probes a server: a TCP connect, then GET /health, falling back to
+// GET /v1/models for servers without a health endpoint
+func (h *HealthChecker) Check(ctx context.Context, host string, port int) error {
+	ctx, cancel := context.WithTimeout(ctx, h.Timeout)
+	defer cancel()
+
+	addr := net.JoinHostPort(probeHost(host), strconv.Itoa(port))
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+
+	status, err := h.get(ctx, "http://"+addr+"/health")
+	if err == nil && status == http.StatusNotFound {
+		status, err = h.get(ctx, "http://"+addr+"/v1/models")
+	}
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("health check returned %d", status)
+	}
+	return nil
+}
+
+func (h *HealthChecker) get(ctx context.Context, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// Watch probes inst until done is closed, moving it between Starting, Ready
+// and Unhealthy and publishing each transition on updates
+func (h *HealthChecker) Watch(inst *Instance, done <-chan struct{}, updates chan<- Update) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	failures := 0
+	for {
+		interval := h.Interval
+		if inst.CurrentState() == StateStarting {
+			interval = h.StartingInterval
+		}
+		select {
+		case <-done:
+			return
+		case <-time.After(interval):
+		}
+
+		err := h.Check(ctx, inst.Host, inst.Port)
+		if ctx.Err() != nil {
+			return
+		}
+
+		switch {
+		case err == nil:
+			failures = 0
+			inst.setState(StateReady, "", updates)
+		case inst.CurrentState() != StateStarting:
+			// A loading model does not answer yet; only a server that was
+			// ready can become unhealthy
+			failures++
+			if failures >= h.FailureThreshold {
+				inst.setState(StateUnhealthy, err.Error(), updates)
+			}
+		}
+	}
+}
+
+// CurrentState returns the instance state
+func (i *Instance) CurrentState() State {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.State
+}
+
+// setState records a state transition and publishes it when it changes
+func (i *Instance) setState(state State, reason string, updates chan<- Update) {
+	i.mu.Lock()
+	if i.State == state {
+		i.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	i.State = state
+	i.StateSince = now
+	i.HealthError = reason
+	if state == StateReady && i.ReadyAt.IsZero() {
+		i.ReadyAt = now
+	}
+	i.mu.Unlock()
+
+	if updates != nil {
+		updates <- Update{Port: i.Port, Type: UpdateState, Data: string(state)}
+	}
+}
+
+// probeHost returns the address to dial for a server listening on host
+func probeHost(host string) string {
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		return "127.0.0.1"
+	}
+	return host
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeModelServer serves /v1/models with the status in *status and has no
+// /health endpoint, like mlx-openai-server
+func fakeModelServer(t *testing.T, status *atomic.Int32) (host string, port int) {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/models", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	port, _ = strconv.Atoi(u.Port())
+	return u.Hostname(), port
+}
+
+func TestHealthCheck(t *testing.T) {
+	var status atomic.Int32
+	host, port := fakeModelServer(t, &status)
+	h := NewHealthChecker()
+
+	for _, tt := range []struct {
+		status int32
+		ok     bool
+	}{
+		{http.StatusOK, true},
+		{http.StatusServiceUnavailable, false},
+		{http.StatusInternalServerError, false},
+	} {
+		status.Store(tt.status)
+		if err := h.Check(context.Background(), host, port); (err == nil) != tt.ok {
+			t.Errorf("/v1/models %d: error %v, want ok %v", tt.status, err, tt.ok)
+		}
+	}
+}
+
+func TestHealthWatch(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusServiceUnavailable) // still loading
+	host, port := fakeModelServer(t, &status)
+
+	h := NewHealthChecker()
+	h.StartingInterval = 5 * time.Millisecond
+	h.Interval = 5 * time.Millisecond
+	inst := &Instance{Host: host, Port: port, State: StateStarting}
+	updates := make(chan Update, 10)
+	done := make(chan struct{})
+	defer close(done)
+	go h.Watch(inst, done, updates)
+
+	next := func(want State) {
+		t.Helper()
+		select {
+		case u := <-updates:
+			if u.Type != UpdateState || State(u.Data) != want || inst.CurrentState() != want {
+				t.Fatalf("update %+v, state %s; want %s", u, inst.CurrentState(), want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s, state %s", want, inst.CurrentState())
+		}
+	}
+
+	// Failed probes while loading keep the instance starting
+	time.Sleep(50 * time.Millisecond)
+	if state := inst.CurrentState(); state != StateStarting {
+		t.Fatalf("state %s while loading, want starting", state)
+	}
+
+	status.Store(http.StatusOK)
+	next(StateReady)
+	if inst.ReadyAt.IsZero() {
+		t.Error("ReadyAt not set")
+	}
+
+	status.Store(http.StatusInternalServerError)
+	next(StateUnhealthy)
+	if inst.HealthError == "" {
+		t.Error("unhealthy without a health error")
+	}
+
+	status.Store(http.StatusOK)
+	next(StateReady)
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxLineLength is the longest unfinished line kept before it is cut into
+// an entry of its own
+const maxLineLength = 64 << 10
+
+const levelNames = `TRACE|DEBUG|INFO|SUCCESS|WARNING|WARN|ERROR|CRITICAL|FATAL`
+
+// logLevelPattern finds the level in the prefixes Python servers write:
+// uvicorn and logging's default format ("INFO:     ..."), bracketed levels
+// ("[INFO] ..."), loguru ("2025-01-02 10:00:00.123 | INFO     | ...") and
+// timestamped logging formats ("2025-01-02 10:00:00,123 - app - INFO - ...")
+var logLevelPattern = regexp.MustCompile(`^(?:(` + levelNames + `):|\[(` + levelNames + `)\]|` +
+	`\d{4}-\d\d-\d\d[ T][\d:.,]+\s*(?:\|\s*|-\s*(?:[\w.]+\s*-\s*)?)(` + levelNames + `)\b)`)
+
+// ansiPattern matches terminal escape sequences such as colors
+var ansiPattern = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+
+// StripANSI removes terminal escape sequences from s
+func StripANSI(s string) string {
+	return ansiPattern.ReplaceAllString(s, "")
+}
+
+// parseLevel returns the level of a log line, or "" if it has no recognized
+// prefix
+func parseLevel(line string) string {
+	m := logLevelPattern.FindStringSubmatch(line)
+	if m == nil {
+		return ""
+	}
+	switch m[1] + m[2] + m[3] {
+	case "TRACE", "DEBUG":
+		return LevelDebug
+	case "INFO", "SUCCESS":
+		return LevelInfo
+	case "WARNING", "WARN":
+		return LevelWarning
+	}
+	return LevelError
+}
+
+// lineAssembler turns the chunks read from one output stream into lines.
+// Lines split across reads are joined, and a line redrawn with carriage
+// returns, like a progress bar, keeps only what a terminal would show last.
+type lineAssembler struct {
+	source      string
+	pending     string
+	level       string // level of the last line, inherited by continuation lines
+	inTraceback bool
+}
+
+// feed adds data read from the stream and returns the lines it completed
+func (a *lineAssembler) feed(data []byte) []string {
+	a.pending += string(data)
+	var lines []string
+	for {
+		i := strings.IndexByte(a.pending, '\n')
+		if i < 0 {
+			break
+		}
+		lines = append(lines, lastFrame(a.pending[:i]))
+		a.pending = a.pending[i+1:]
+	}
+	// Drop the overwritten frames of the unfinished line. A trailing \r is
+	// kept since it may be the first half of a \r\n split across reads.
+	if i := strings.LastIndexByte(strings.TrimSuffix(a.pending, "\r"), '\r'); i >= 0 {
+		a.pending = a.pending[i+1:]
+	}
+	if len(a.pending) > maxLineLength {
+		lines = append(lines, lastFrame(a.pending))
+		a.pending = ""
+	}
+	return lines
+}
+
+// partial returns the unfinished line as shown so far
+func (a *lineAssembler) partial() string {
+	return lastFrame(a.pending)
+}
+
+// flush returns the unfinished line once the stream has ended
+func (a *lineAssembler) flush() string {
+	line := lastFrame(a.pending)
+	a.pending = ""
+	return line
+}
+
+// entry builds the entry of a line received at t. Lines without a level
+// belong to the message above them and inherit its level. Python tracebacks,
+// from "Traceback (most recent call last):" to the exception line, are
+// errors. A partial line does not affect the lines after it.
+func (a *lineAssembler) entry(text string, t time.Time, partial bool) LogEntry {
+	state := a
+	if partial {
+		copied := *a
+		state = &copied
+	}
+	plain := StripANSI(text)
+	if level := parseLevel(plain); level != "" {
+		state.level = level
+		state.inTraceback = false
+	}
+	if strings.HasPrefix(plain, "Traceback (most recent call last):") {
+		state.inTraceback = true
+	}
+	if state.inTraceback {
+		state.level = LevelError
+		// The exception line is the first unindented line after the frames
+		if plain != "" && plain[0] != ' ' && !strings.HasPrefix(plain, "Traceback") &&
+			!strings.HasPrefix(plain, "During handling") && !strings.HasPrefix(plain, "The above exception") {
+			state.inTraceback = false
+		}
+	}
+	return LogEntry{Time: t, Source: a.source, Level: state.level, Text: text, Partial: partial}
+}
+
+// lastFrame returns what a terminal shows of a line redrawn with carriage
+// returns
+func lastFrame(line string) string {
+	line = strings.TrimRight(line, "\r")
+	if i := strings.LastIndexByte(line, '\r'); i >= 0 {
+		return line[i+1:]
+	}
+	return line
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLastFrame(t *testing.T) {
+	for _, tt := range []struct {
+		line, want string
+	}{
+		{"plain", "plain"},
+		{"10%\r50%\r100%", "100%"},
+		{"done\r", "done"},
+		{"done\r\r", "done"},
+		{"\r", ""},
+	} {
+		if got := lastFrame(tt.line); got != tt.want {
+			t.Errorf("lastFrame(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestLineAssembler(t *testing.T) {
+	long := strings.Repeat("x", maxLineLength+1)
+	for _, tt := range []struct {
+		name    string
+		reads   []string
+		lines   []string
+		partial string // unfinished line after the reads, returned by flush
+	}{
+		{
+			name:  "progress bar",
+			reads: []string{"Loading  10%\rLoading  50%\rLoading 100%\n"},
+			lines: []string{"Loading 100%"},
+		},
+		{
+			name:    "progress bar across reads",
+			reads:   []string{"Fetching  10%\r", "Fetching  50%\rFetch", "ing 100%\rdone\n", "Fetching  1%\rFetching  2%"},
+			lines:   []string{"done"},
+			partial: "Fetching  2%",
+		},
+		{
+			name:    "line split across reads",
+			reads:   []string{"INFO: Started ser", "ver process\nINFO: Wait", "ing"},
+			lines:   []string{"INFO: Started server process"},
+			partial: "INFO: Waiting",
+		},
+		{
+			name:  "CRLF split across reads",
+			reads: []string{"first\r", "\nsecond\r\n"},
+			lines: []string{"first", "second"},
+		},
+		{
+			name:    "flushed partial line",
+			reads:   []string{"Uvicorn running on http://127.0.0.1:8000 (Press CTRL+C to quit)"},
+			partial: "Uvicorn running on http://127.0.0.1:8000 (Press CTRL+C to quit)",
+		},
+		{
+			name:  "line too long to wait for",
+			reads: []string{long[:10], long[10:]},
+			lines: []string{long},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &lineAssembler{source: SourceStdout}
+			var lines []string
+			for _, read := range tt.reads {
+				lines = append(lines, a.feed([]byte(read))...)
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines %q, want %q", lines, tt.lines)
+			}
+			if got := a.partial(); got != tt.partial {
+				t.Errorf("partial %q, want %q", got, tt.partial)
+			}
+			if got := a.flush(); got != tt.partial {
+				t.Errorf("flush %q, want %q", got, tt.partial)
+			}
+			if got := a.flush(); got != "" {
+				t.Errorf("second flush %q, want nothing", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/config"
+)
+
+const (
+	defaultLogMaxSize  = 10 << 20 // rotate a run's log at 10 MB
+	defaultLogMaxFiles = 3        // rotated files kept per run
+	defaultLogMaxRuns  = 50       // runs kept before the oldest are deleted
+
+	logFileName  = "output.log"
+	runMetaName  = "run.json"
+	maxRunIDScan = 1000 // attempts to claim a run ID before giving up
+)
+
+// RunInfo describes one run of a server process and where its log is
+type RunInfo struct {
+	ID        int        `json:"id"`
+	Model     string     `json:"model"`
+	Type      string     `json:"type"`
+	Port      int        `json:"port"`
+	Args      []string   `json:"args"`
+	PID       int        `json:"pid"`
+	StartedAt time.Time  `json:"started_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	ExitCode  *int       `json:"exit_code,omitempty"`
+}
+
+// Running reports whether the run has not recorded an exit
+func (r RunInfo) Running() bool {
+	return r.EndedAt == nil
+}
+
+// LogStore keeps the output of every server run in its own directory under
+// the logs directory, with a run.json describing the run next to the log.
+// Logs rotate by size and the oldest runs are deleted past the retention
+// limit.
+type LogStore struct {
+	dir      string
+	maxSize  int64
+	maxFiles int
+	maxRuns  int
+	mu       sync.Mutex // serializes run ID allocation and pruning
+}
+
+// LogDir returns the directory holding server run logs
+func LogDir() string {
+	return filepath.Join(config.ConfigDir(), "logs")
+}
+
+// NewLogStore creates a store under dir using the log limits of cfg
+func NewLogStore(dir string, cfg *config.Config) *LogStore {
+	s := &LogStore{
+		dir:      dir,
+		maxSize:  defaultLogMaxSize,
+		maxFiles: defaultLogMaxFiles,
+		maxRuns:  defaultLogMaxRuns,
+	}
+	if cfg != nil {
+		if cfg.LogMaxSizeMB > 0 {
+			s.maxSize = int64(cfg.LogMaxSizeMB) << 20
+		}
+		if cfg.LogMaxFiles > 0 {
+			s.maxFiles = cfg.LogMaxFiles
+		}
+		if cfg.LogRetainRuns > 0 {
+			s.maxRuns = cfg.LogRetainRuns
+		}
+	}
+	return s
+}
+
+// Begin records a new run and opens its log file
+func (s *LogStore) Begin(info RunInfo) (*RunLog, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+	// Another efx-face process may share the directory, so a run ID is only
+	// ours once its directory was created
+	id := s.lastID()
+	var runDir string
+	for attempt := 0; ; attempt++ {
+		if attempt == maxRunIDScan {
+			return nil, fmt.Errorf("could not allocate a log run ID in %s", s.dir)
+		}
+		id++
+		runDir = filepath.Join(s.dir, strconv.Itoa(id))
+		err := os.Mkdir(runDir, 0755)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+	}
+
+	info.ID = id
+	file, err := os.OpenFile(filepath.Join(runDir, logFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, err
+	}
+	run := &RunLog{store: s, dir: runDir, info: info, file: file}
+	run.saveMeta()
+	s.prune()
+	return run, nil
+}
+
+// lastID returns the highest run ID on disk
+func (s *LogStore) lastID() int {
+	entries, _ := os.ReadDir(s.dir)
+	last := 0
+	for _, e := range entries {
+		if id, err := strconv.Atoi(e.Name()); err == nil && e.IsDir() && id > last {
+			last = id
+		}
+	}
+	return last
+}
+
+// prune deletes the oldest finished runs beyond the retention limit
+func (s *LogStore) prune() {
+	runs := s.Runs()
+	excess := len(runs) - s.maxRuns
+	for i := 0; i < len(runs) && excess > 0; i++ {
+		// A run without an exit record whose process is gone belonged to an
+		// efx-face process that died and can go
+		if runs[i].Running() && processAlive(runs[i].PID) {
+			continue
+		}
+		os.RemoveAll(filepath.Join(s.dir, strconv.Itoa(runs[i].ID)))
+		excess--
+	}
+}
+
+// Runs returns the recorded runs, oldest first
+func (s *LogStore) Runs() []RunInfo {
+	entries, _ := os.ReadDir(s.dir)
+	var runs []RunInfo
+	for _, e := range entries {
+		if _, err := strconv.Atoi(e.Name()); err != nil || !e.IsDir() {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, e.Name(), runMetaName))
+		if err != nil {
+			continue
+		}
+		var info RunInfo
+		if json.Unmarshal(data, &info) == nil {
+			runs = append(runs, info)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].ID < runs[j].ID })
+	return runs
+}
+
+// Run returns the run with id
+func (s *LogStore) Run(id int) (RunInfo, bool) {
+	for _, run := range s.Runs() {
+		if run.ID == id {
+			return run, true
+		}
+	}
+	return RunInfo{}, false
+}
+
+// FindRuns returns the runs of a server, oldest first. target is a port
+// number or a model name, matched against the full name or its last path
+// element.
+func (s *LogStore) FindRuns(target string) []RunInfo {
+	port, portErr := strconv.Atoi(target)
+	var runs []RunInfo
+	for _, run := range s.Runs() {
+		if (portErr == nil && run.Port == port) || run.Model == target ||
+			strings.EqualFold(filepath.Base(run.Model), filepath.Base(target)) {
+			runs = append(runs, run)
+		}
+	}
+	return runs
+}
+
+// LogPath returns the log file currently written by a run
+func (s *LogStore) LogPath(id int) string {
+	return filepath.Join(s.dir, strconv.Itoa(id), logFileName)
+}
+
+// Read returns the full log of a run, joining its rotated files in order
+func (s *LogStore) Read(id int) ([]LogEntry, error) {
+	runDir := filepath.Join(s.dir, strconv.Itoa(id))
+	if _, err := os.Stat(runDir); err != nil {
+		return nil, fmt.Errorf("no log for run %d", id)
+	}
+	var entries []LogEntry
+	for n := s.maxFiles; n >= 0; n-- {
+		data, err := os.ReadFile(rotatedName(filepath.Join(runDir, logFileName), n))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+			if line != "" {
+				entries = append(entries, ParseLogLine(line))
+			}
+		}
+	}
+	return entries, nil
+}
+
+// formatLogLine encodes an entry as a line of a log file: its receive
+// time, source, level ("-" when none) and text, separated by spaces
+func formatLogLine(e LogEntry) string {
+	level := e.Level
+	if level == "" {
+		level = "-"
+	}
+	return fmt.Sprintf("%s %s %s %s\n", e.Time.UTC().Format(time.RFC3339Nano), e.Source, level, e.Text)
+}
+
+// ParseLogLine decodes a line of a log file. Lines written before logs were
+// structured are returned as text without a time.
+func ParseLogLine(line string) LogEntry {
+	fields := strings.SplitN(line, " ", 4)
+	if len(fields) == 4 {
+		if t, err := time.Parse(time.RFC3339Nano, fields[0]); err == nil {
+			entry := LogEntry{Time: t.Local(), Source: fields[1], Level: fields[2], Text: fields[3]}
+			if entry.Level == "-" {
+				entry.Level = ""
+			}
+			return entry
+		}
+	}
+	return LogEntry{Text: line}
+}
+
+// rotatedName returns the name of the nth rotated log file, n 0 being the
+// file currently written
+func rotatedName(path string, n int) string {
+	if n == 0 {
+		return path
+	}
+	return fmt.Sprintf("%s.%d", path, n)
+}
+
+// RunLog is the log file of one run
+type RunLog struct {
+	store *LogStore
+	dir   string
+	mu    sync.Mutex
+	info  RunInfo
+	file  *os.File
+	size  int64
+}
+
+// ID returns the run ID
+func (r *RunLog) ID() int {
+	return r.info.ID
+}
+
+// Append writes a line of server output, rotating the file once it
+// exceeds the size limit
+func (r *RunLog) Append(entry LogEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+
+	line := formatLogLine(entry)
+	if r.size > 0 && r.size+int64(len(line)) > r.store.maxSize {
+		r.rotate()
+		if r.file == nil {
+			return nil
+		}
+	}
+	n, err := r.file.WriteString(line)
+	r.size += int64(n)
+	return err
+}
+
+// rotate shifts output.log to output.log.1 and so on, dropping the oldest.
+// The caller holds r.mu.
+func (r *RunLog) rotate() {
+	path := filepath.Join(r.dir, logFileName)
+	r.file.Close()
+	os.Remove(rotatedName(path, r.store.maxFiles))
+	for n := r.store.maxFiles - 1; n >= 0; n-- {
+		os.Rename(rotatedName(path, n), rotatedName(path, n+1))
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		r.file = nil
+		return
+	}
+	r.file = file
+	r.size = 0
+}
+
+// Finish records the exit of the run
+func (r *RunLog) Finish(exitCode int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	r.info.EndedAt = &now
+	r.info.ExitCode = &exitCode
+	r.saveMeta()
+}
+
+// Close closes the log file. Later writes are discarded.
+func (r *RunLog) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+// saveMeta writes run.json. The caller holds r.mu or owns r exclusively.
+func (r *RunLog) saveMeta() {
+	data, err := json.MarshalIndent(r.info, "", "  ")
+	if err != nil {
+		return
+	}
+	tmp := filepath.Join(r.dir, runMetaName+".tmp")
+	if os.WriteFile(tmp, data, 0644) == nil {
+		os.Rename(tmp, filepath.Join(r.dir, runMetaName))
+	}
+}
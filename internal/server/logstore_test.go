@@ -0,0 +1,103 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunLogRotation(t *testing.T) {
+	at := time.Date(2025, 1, 2, 10, 0, 0, 0, time.UTC)
+	entry := func(n int) LogEntry {
+		return LogEntry{Time: at, Source: SourceStdout, Level: LevelInfo, Text: fmt.Sprintf("line %d", n)}
+	}
+	// Two lines fit in a file, and two rotated files are kept
+	store := &LogStore{dir: t.TempDir(), maxSize: int64(2 * len(formatLogLine(entry(1)))), maxFiles: 2, maxRuns: 10}
+	run, err := store.Begin(RunInfo{Model: "qwen", PID: os.Getpid()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer run.Close()
+	for n := 1; n <= 8; n++ {
+		if err := run.Append(entry(n)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := store.LogPath(run.ID())
+	for n, want := range []string{"line 7", "line 5", "line 3"} {
+		data, err := os.ReadFile(rotatedName(path, n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		first, _, _ := strings.Cut(string(data), "\n")
+		if got := ParseLogLine(first).Text; got != want {
+			t.Errorf("%s starts with %q, want %q", filepath.Base(rotatedName(path, n)), got, want)
+		}
+	}
+	if _, err := os.Stat(rotatedName(path, 3)); !os.IsNotExist(err) {
+		t.Errorf("kept %s beyond the two rotated files", filepath.Base(rotatedName(path, 3)))
+	}
+
+	// Read joins the rotated files oldest first
+	entries, err := store.Read(run.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []LogEntry
+	for n := 3; n <= 8; n++ {
+		e := entry(n)
+		e.Time = e.Time.Local()
+		want = append(want, e)
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("read %+v\nwant %+v", entries, want)
+	}
+	if _, err := store.Read(run.ID() + 1); err == nil {
+		t.Error("read a run that does not exist")
+	}
+}
+
+func TestLogStorePrune(t *testing.T) {
+	// A process that has exited, like an efx-face that died mid-run
+	exited := exec.Command(os.Args[0], "-test.run=^$")
+	if err := exited.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	store := &LogStore{dir: t.TempDir(), maxSize: 1 << 20, maxFiles: 1, maxRuns: 2}
+	begin := func(pid int) *RunLog {
+		t.Helper()
+		run, err := store.Begin(RunInfo{Model: "qwen", PID: pid})
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(run.Close)
+		return run
+	}
+	orphaned := begin(exited.Process.Pid)
+	orphaned.Close()
+	live := begin(os.Getpid())
+	finished := begin(os.Getpid())
+	finished.Finish(0)
+	finished.Close()
+	current := begin(os.Getpid())
+
+	var ids []int
+	for _, run := range store.Runs() {
+		ids = append(ids, run.ID)
+	}
+	if want := []int{live.ID(), current.ID()}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("kept runs %v, want the live runs %v and not %d or %d", ids, want, orphaned.ID(), finished.ID())
+	}
+	for _, id := range []int{orphaned.ID(), finished.ID()} {
+		if _, err := os.Stat(filepath.Join(store.dir, fmt.Sprint(id))); !os.IsNotExist(err) {
+			t.Errorf("directory of pruned run %d left behind", id)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -22,13 +23,37 @@ const (
 	UpdateStopped
 	UpdateNewOutput
 	UpdateError
+	UpdateState     // Data holds the new State
+	UpdateResources // a new ResourceUsage sample was recorded
 )
 
+// String returns the name of the update type
+func (t UpdateType) String() string {
+	switch t {
+	case UpdateStarted:
+		return "started"
+	case UpdateStopped:
+		return "stopped"
+	case UpdateNewOutput:
+		return "output"
+	case UpdateError:
+		return "error"
+	case UpdateState:
+		return "state"
+	case UpdateResources:
+		return "resources"
+	}
+	return fmt.Sprintf("UpdateType(%d)", int(t))
+}
+
 // Update represents a server update message
 type Update struct {
-	Port int
-	Type UpdateType
-	Data string
+	Port int        `json:"port"`
+	Type UpdateType `json:"type"`
+	Data string     `json:"data,omitempty"`
+	// Entry is the output line, set for UpdateNewOutput. Partial entries
+	// show an unfinished line and are followed by its complete entry.
+	Entry *LogEntry `json:"entry,omitempty"`
 }
 
 // Instance represents a running server instance
@@ -38,12 +63,43 @@ type Instance struct {
 	Port      int
 	Host      string
 	Args      []string
+	PID       int
 	Cmd       *exec.Cmd
 	PTY       *os.File
 	Output    *RingBuffer
 	StartedAt time.Time
 	Running   bool
-	mu        sync.Mutex
+
+	// Health state, updated by the Manager's HealthChecker
+	State       State
+	StateSince  time.Time
+	ReadyAt     time.Time
+	HealthError string
+
+	// Resource use, updated by the Manager's ResourceMonitor
+	Usage        ResourceUsage
+	UsageHistory []ResourceUsage // oldest first
+
+	// Restart policy and crash history
+	Restart   RestartPolicy
+	Restarts  int
+	CrashLoop bool
+	LastExit  *ExitInfo
+
+	// Adopted instances were launched by an earlier session. Their output
+	// from before adoption was never captured.
+	Adopted bool
+
+	// RunID identifies the log of the current process in the Manager's
+	// LogStore, 0 when output is not persisted
+	RunID int
+
+	config           Config
+	retries          int             // consecutive restarts since the server last ran stably
+	stopping         bool            // Stop was called, do not restart
+	restartRequested bool            // Restart was called, relaunch once the process exits
+	exited           <-chan struct{} // closed when the current process exits
+	mu               sync.Mutex
 }
 
 // Manager handles multiple concurrent server instances
@@ -51,100 +107,342 @@ type Manager struct {
 	instances map[int]*Instance
 	mu        sync.RWMutex
 	Updates   chan Update
+	Health    *HealthChecker
+	Monitor   *ResourceMonitor
+
+	GracePeriod    time.Duration // wait after SIGTERM before SIGKILL
+	StopAllTimeout time.Duration // overall deadline of StopAll
+	RestartBackoff time.Duration // delay before a first automatic restart
+
+	stateFile string // where running servers are recorded, see TrackState
+
+	// Logs persists the output of every run when set
+	Logs *LogStore
 }
 
+const (
+	// DefaultGracePeriod is how long a server gets to shut down cleanly
+	DefaultGracePeriod = 10 * time.Second
+
+	// killTimeout is how long to wait for a process to die after SIGKILL
+	killTimeout = 5 * time.Second
+
+	// outputDrainTimeout is how long to wait for the rest of a process's
+	// output after it exits
+	outputDrainTimeout = 2 * time.Second
+)
+
 // NewManager creates a new server manager
 func NewManager() *Manager {
 	return &Manager{
 		instances: make(map[int]*Instance),
 		Updates:   make(chan Update, 100),
+		Health:    NewHealthChecker(),
+		Monitor:   NewResourceMonitor(),
+
+		GracePeriod:    DefaultGracePeriod,
+		StopAllTimeout: DefaultGracePeriod + 2*killTimeout,
+		RestartBackoff: DefaultRestartBackoff,
 	}
 }
 
+// SetGracePeriod sets how long servers get to shut down after SIGTERM and
+// extends the StopAll deadline to match
+func (m *Manager) SetGracePeriod(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	m.GracePeriod = d
+	m.StopAllTimeout = d + 2*killTimeout
+}
+
 // Start starts a new server instance
 func (m *Manager) Start(config Config) (*Instance, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	// Check port availability
-	if _, exists := m.instances[config.Port]; exists {
-		return nil, fmt.Errorf("port %d already in use", config.Port)
+	if existing, exists := m.instances[config.Port]; exists {
+		return nil, &PortConflict{Port: config.Port, Model: existing.Model}
+	}
+	if err := CheckPort(config.Host, config.Port); err != nil {
+		return nil, err
 	}
 
+	policy, err := ParseRestartPolicy(string(config.Restart))
+	if err != nil {
+		return nil, err
+	}
+	config.Restart = policy
+
 	// Build command args
 	args := config.BuildArgs()
 
 	instance := &Instance{
-		Model:     config.Model,
-		Type:      string(config.Type),
-		Port:      config.Port,
-		Host:      config.Host,
-		Args:      args,
-		Output:    NewRingBuffer(1000),
-		StartedAt: time.Now(),
+		Model:   config.Model,
+		Type:    string(config.Type),
+		Port:    config.Port,
+		Host:    config.Host,
+		Args:    args,
+		Output:  NewRingBuffer(1000),
+		Restart: config.Restart,
+		config:  config,
+	}
+
+	if err := m.launch(instance); err != nil {
+		return nil, err
 	}
+	m.instances[config.Port] = instance
+	m.saveState()
+
+	m.Updates <- Update{Port: config.Port, Type: UpdateStarted}
+	return instance, nil
+}
 
+// launch starts the server process of instance and the goroutines that read
+// its output, probe its health and wait for it to exit. The caller holds m.mu.
+func (m *Manager) launch(instance *Instance) error {
 	// Start the command with PTY
-	cmd := exec.Command("mlx-openai-server", args...)
+	cmd := exec.Command("mlx-openai-server", instance.Args...)
+	// stderr gets a pipe of its own so its lines can be told from stdout's
+	stderr, stderrWriter, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+	cmd.Stderr = stderrWriter
 	ptmx, err := pty.Start(cmd)
+	stderrWriter.Close()
 	if err != nil {
-		return nil, fmt.Errorf("failed to start server: %w", err)
+		stderr.Close()
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+
+	var runLog *RunLog
+	runID := 0
+	if m.Logs != nil {
+		runLog, err = m.Logs.Begin(RunInfo{
+			Model:     instance.Model,
+			Type:      instance.Type,
+			Port:      instance.Port,
+			Args:      instance.Args,
+			PID:       cmd.Process.Pid,
+			StartedAt: time.Now(),
+		})
+		if err != nil {
+			instance.Output.Write(fmt.Sprintf("Failed to open log file: %v", err))
+		} else {
+			runID = runLog.ID()
+		}
 	}
 
+	done := make(chan struct{})
+	instance.mu.Lock()
+	if instance.PTY != nil {
+		// Release the terminal of the previous process after a restart
+		instance.PTY.Close()
+	}
+	instance.exited = done
 	instance.Cmd = cmd
+	instance.PID = cmd.Process.Pid
 	instance.PTY = ptmx
 	instance.Running = true
-
-	m.instances[config.Port] = instance
+	instance.StartedAt = time.Now()
+	instance.State = StateStarting
+	instance.StateSince = instance.StartedAt
+	instance.ReadyAt = time.Time{}
+	instance.HealthError = ""
+	instance.RunID = runID
+	instance.mu.Unlock()
 
 	// Read output in goroutine
-	go instance.readOutput(m.Updates)
+	drained := make(chan struct{})
+	go instance.readOutput(ptmx, stderr, runLog, m.Updates, drained)
+
+	// Probe readiness and sample resource use until the process exits
+	go m.Health.Watch(instance, done, m.Updates)
+	go m.Monitor.Watch(instance, done, m.Updates)
 
 	// Wait for process in goroutine
 	go func() {
 		cmd.Wait()
+		// Let the last output, often a traceback, reach the buffer and the
+		// log before the exit is recorded. Processes the server left behind
+		// may hold its streams open, so this does not wait for long.
+		select {
+		case <-drained:
+		case <-time.After(outputDrainTimeout):
+		}
+		if runLog != nil {
+			runLog.Finish(cmd.ProcessState.ExitCode())
+		}
+		close(done)
 		m.mu.Lock()
-		if inst, exists := m.instances[config.Port]; exists {
-			inst.Running = false
+		// A restarted instance already runs a newer process, the port may
+		// belong to a newer instance, and a stopped instance is no longer
+		// in the map at all
+		instance.mu.Lock()
+		current := instance.exited == done
+		instance.mu.Unlock()
+		if !current {
+			m.mu.Unlock()
+			return
+		}
+		instance.setState(StateStopped, "", nil)
+		managed := m.instances[instance.Port] == instance
+		if managed {
+			instance.mu.Lock()
+			instance.Running = false
+			instance.mu.Unlock()
+			m.saveState()
 		}
 		m.mu.Unlock()
-		m.Updates <- Update{Port: config.Port, Type: UpdateStopped}
+		m.Updates <- Update{Port: instance.Port, Type: UpdateStopped}
+
+		instance.mu.Lock()
+		stopping, restart := instance.stopping, instance.restartRequested
+		instance.restartRequested = false
+		instance.mu.Unlock()
+		if !managed || stopping {
+			return
+		}
+		switch m.takeRequest(cmd.Process.Pid) {
+		case requestStop:
+			return
+		case requestRestart:
+			restart = true
+		}
+		if restart {
+			instance.setState(StateRestarting, "restart requested", m.Updates)
+			m.relaunch(instance)
+			return
+		}
+		m.handleExit(instance, cmd.ProcessState.ExitCode())
 	}()
 
-	m.Updates <- Update{Port: config.Port, Type: UpdateStarted}
-	return instance, nil
+	return nil
 }
 
-// Stop stops a server instance
+// Stop stops a server instance. It signals the server's whole process
+// group, escalates to SIGKILL after the grace period and returns once the
+// process has exited.
 func (m *Manager) Stop(port int) error {
 	m.mu.Lock()
-	defer m.mu.Unlock()
-
 	instance, exists := m.instances[port]
 	if !exists {
+		m.mu.Unlock()
 		return fmt.Errorf("no server on port %d", port)
 	}
+	instance.mu.Lock()
+	instance.stopping = true
+	pid, exited := instance.PID, instance.exited
+	instance.mu.Unlock()
+	m.mu.Unlock()
 
-	// Send SIGTERM for graceful shutdown
-	if instance.Cmd.Process != nil {
-		if err := instance.Cmd.Process.Signal(syscall.SIGTERM); err != nil {
-			// Force kill if SIGTERM fails
-			instance.Cmd.Process.Kill()
-		}
+	err := m.terminate(pid, exited)
+
+	m.mu.Lock()
+	if m.instances[port] == instance {
+		delete(m.instances, port)
+		m.saveState()
 	}
+	m.mu.Unlock()
 
 	// Close PTY
+	instance.mu.Lock()
 	if instance.PTY != nil {
 		instance.PTY.Close()
 	}
-
 	instance.Running = false
-	delete(m.instances, port)
+	instance.mu.Unlock()
 
-	return nil
+	return err
+}
+
+// Restart stops the process of a server and starts it again with the same
+// configuration on the same port. It returns once the new process started.
+func (m *Manager) Restart(port int) (*Instance, error) {
+	instance := m.Get(port)
+	if instance == nil {
+		return nil, fmt.Errorf("no server on port %d", port)
+	}
+	instance.mu.Lock()
+	if instance.Adopted {
+		instance.mu.Unlock()
+		return nil, fmt.Errorf("%s on port %d was adopted from an earlier session and cannot be restarted", instance.Model, port)
+	}
+	instance.restartRequested = true
+	pid, exited := instance.PID, instance.exited
+	instance.mu.Unlock()
+
+	select {
+	case <-exited:
+		// Waiting for a restart or stopped after a crash: launch it now,
+		// unless the exit handler has yet to see the request
+		m.mu.Lock()
+		instance.mu.Lock()
+		idle := !instance.Running
+		if idle {
+			instance.restartRequested = false
+			instance.retries = 0
+			instance.CrashLoop = false
+		}
+		instance.mu.Unlock()
+		m.mu.Unlock()
+		if idle {
+			m.relaunch(instance)
+		}
+	default:
+		if err := m.terminate(pid, exited); err != nil {
+			return nil, err
+		}
+	}
+
+	// The exit handler launches the new process
+	deadline := time.Now().Add(killTimeout)
+	for time.Now().Before(deadline) {
+		m.mu.RLock()
+		instance.mu.Lock()
+		relaunched := instance.exited != exited && instance.Running
+		instance.mu.Unlock()
+		m.mu.RUnlock()
+		if relaunched {
+			return instance, nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return nil, fmt.Errorf("%s on port %d did not start again", instance.Model, port)
 }
 
-// StopAll stops all server instances
+// terminate sends SIGTERM to a process group and waits for exited to close,
+// escalating to SIGKILL when the grace period runs out
+func (m *Manager) terminate(pid int, exited <-chan struct{}) error {
+	select {
+	case <-exited:
+		// Already gone, e.g. waiting to be restarted
+		return nil
+	default:
+	}
+
+	if err := terminateGroup(pid); err != nil {
+		killGroup(pid)
+	}
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(m.GracePeriod):
+	}
+
+	killGroup(pid)
+	select {
+	case <-exited:
+		return nil
+	case <-time.After(killTimeout):
+		return fmt.Errorf("server (pid %d) did not exit after SIGKILL", pid)
+	}
+}
+
+// StopAll stops all server instances concurrently and gives up waiting once
+// the StopAllTimeout deadline passes
 func (m *Manager) StopAll() error {
 	m.mu.Lock()
 	ports := make([]int, 0, len(m.instances))
@@ -153,10 +451,27 @@ func (m *Manager) StopAll() error {
 	}
 	m.mu.Unlock()
 
+	results := make(chan error, len(ports))
 	for _, port := range ports {
-		m.Stop(port)
+		go func(port int) {
+			results <- m.Stop(port)
+		}(port)
 	}
-	return nil
+
+	var errs []error
+	deadline := time.After(m.StopAllTimeout)
+	for range ports {
+		select {
+		case err := <-results:
+			if err != nil {
+				errs = append(errs, err)
+			}
+		case <-deadline:
+			errs = append(errs, fmt.Errorf("timed out after %s waiting for servers to stop", m.StopAllTimeout))
+			return errors.Join(errs...)
+		}
+	}
+	return errors.Join(errs...)
 }
 
 // Get returns a server instance by port
@@ -177,6 +492,32 @@ func (m *Manager) GetLogs(port int) string {
 	return ""
 }
 
+// GetLogEntries returns the captured output of a server with receive times
+func (m *Manager) GetLogEntries(port int) []LogEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if instance, exists := m.instances[port]; exists {
+		return instance.Output.Entries()
+	}
+	return nil
+}
+
+// ClearLogs clears the captured output of a server
+func (m *Manager) ClearLogs(port int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if instance, exists := m.instances[port]; exists {
+		instance.Output.Clear()
+	}
+}
+
+// UpdateChan returns the channel on which server updates are published
+func (m *Manager) UpdateChan() <-chan Update {
+	return m.Updates
+}
+
 // List returns all server instances sorted by port
 func (m *Manager) List() []*Instance {
 	m.mu.RLock()
@@ -201,45 +542,99 @@ func (m *Manager) Count() int {
 	return len(m.instances)
 }
 
-// IsPortInUse checks if a port is in use
+// IsPortInUse checks if a port is used by a managed server or cannot be
+// bound because another process holds it
 func (m *Manager) IsPortInUse(port int) bool {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
 	_, exists := m.instances[port]
-	return exists
+	m.mu.RUnlock()
+	return exists || !PortFree("", port)
 }
 
-// NextAvailablePort returns the next available port
+// CheckPort returns a *PortConflict if a server cannot listen on host:port
+func (m *Manager) CheckPort(host string, port int) error {
+	m.mu.RLock()
+	existing, exists := m.instances[port]
+	m.mu.RUnlock()
+	if exists {
+		return &PortConflict{Port: port, Model: existing.Model}
+	}
+	return CheckPort(host, port)
+}
+
+// NextAvailablePort returns the first port from startPort that is neither
+// managed nor busy at the OS level
 func (m *Manager) NextAvailablePort(startPort int) int {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
 	port := startPort
-	for m.instances[port] != nil {
+	for port < 65535 && (m.instances[port] != nil || !PortFree("", port)) {
 		port++
 	}
 	return port
 }
 
-// readOutput reads output from PTY and stores it
-func (i *Instance) readOutput(updates chan Update) {
-	buf := make([]byte, 1024)
-	for {
-		n, err := i.PTY.Read(buf)
-		if err != nil {
-			if err != io.EOF {
-				i.Output.Write(fmt.Sprintf("Error reading output: %v", err))
+// readOutput reads stdout from the PTY and stderr from its pipe, stores the
+// lines and tees them to the run log. drained is closed once both streams
+// have ended.
+func (i *Instance) readOutput(ptmx, stderr *os.File, runLog *RunLog, updates chan Update, drained chan struct{}) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		i.readStream(ptmx, SourceStdout, runLog, updates)
+	}()
+	go func() {
+		defer wg.Done()
+		i.readStream(stderr, SourceStderr, runLog, updates)
+		stderr.Close()
+	}()
+	wg.Wait()
+	if runLog != nil {
+		runLog.Close()
+	}
+	close(drained)
+}
+
+// readStream reads one output stream until it ends, reassembling its lines
+func (i *Instance) readStream(r io.Reader, source string, runLog *RunLog, updates chan Update) {
+	lines := &lineAssembler{source: source}
+	emit := func(entry LogEntry) {
+		if entry.Partial {
+			i.Output.SetPartial(entry)
+		} else {
+			entry = i.Output.Append(entry)
+			if runLog != nil {
+				runLog.Append(entry)
 			}
-			return
 		}
+		updates <- Update{Port: i.Port, Type: UpdateNewOutput, Data: entry.Text, Entry: &entry}
+	}
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := r.Read(buf)
 		if n > 0 {
-			lines := strings.Split(string(buf[:n]), "\n")
-			for _, line := range lines {
+			now := time.Now()
+			for _, line := range lines.feed(buf[:n]) {
 				if line != "" {
-					i.Output.Write(line)
-					updates <- Update{Port: i.Port, Type: UpdateNewOutput, Data: line}
+					emit(lines.entry(line, now, false))
 				}
 			}
+			if partial := lines.partial(); partial != "" {
+				emit(lines.entry(partial, now, true))
+			}
+		}
+		if err != nil {
+			if line := lines.flush(); line != "" {
+				emit(lines.entry(line, time.Now(), false))
+			}
+			// A PTY reports EIO once the process closed its end
+			if err != io.EOF && !errors.Is(err, syscall.EIO) {
+				i.Output.Write(fmt.Sprintf("Error reading output: %v", err))
+			}
+			return
 		}
 	}
 }
@@ -0,0 +1,131 @@
+//go:build !windows
+
+package server
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// fakeServerScript stands in for mlx-openai-server. It records its start in
+// $FAKE_SERVER_DIR and runs until a file named after its PID appears there,
+// then exits with the code written in it.
+const fakeServerScript = `#!/bin/sh
+touch "$FAKE_SERVER_DIR/started-$$"
+f="$FAKE_SERVER_DIR/exit-$$"
+while [ ! -e "$f" ]; do sleep 0.02; done
+exit "$(cat "$f")"
+`
+
+// installFakeServer puts a fake mlx-openai-server first in PATH and returns
+// the directory its processes wait in
+func installFakeServer(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "mlx-openai-server"), []byte(fakeServerScript), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("FAKE_SERVER_DIR", dir)
+	t.Setenv("HOME", t.TempDir())
+	return dir
+}
+
+// exitServer makes the fake server process pid exit with code
+func exitServer(t *testing.T, dir string, pid, code int) {
+	t.Helper()
+	path := filepath.Join(dir, "exit-"+strconv.Itoa(pid))
+	if err := os.WriteFile(path+".tmp", []byte(strconv.Itoa(code)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// startedServers returns how many fake server processes have started
+func startedServers(t *testing.T, dir string) int {
+	t.Helper()
+	started, err := filepath.Glob(filepath.Join(dir, "started-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return len(started)
+}
+
+// newTestManager returns a manager whose updates are discarded
+func newTestManager(t *testing.T) *Manager {
+	t.Helper()
+	m := NewManager()
+	go func() {
+		for range m.Updates {
+		}
+	}()
+	t.Cleanup(func() { m.StopAll() })
+	return m
+}
+
+// freePort returns a loopback port nothing listens on
+func freePort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestManagerExitWhileReading(t *testing.T) {
+	dir := installFakeServer(t)
+	m := NewManager()
+	m.Updates = make(chan Update) // every send waits for the receiver
+	t.Cleanup(func() { m.StopAll() })
+	discard := func(stop chan struct{}) {
+		for {
+			select {
+			case <-m.Updates:
+			case <-stop:
+				return
+			}
+		}
+	}
+	hold, held := make(chan struct{}), make(chan struct{})
+	go func() {
+		discard(hold)
+		close(held)
+	}()
+
+	inst, err := m.Start(Config{Model: "fake", Port: freePort(t), Host: "127.0.0.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// With nobody receiving updates, the exit blocks on announcing the
+	// stop, right after it records it, while the instance is read the way
+	// the daemon and API read it
+	close(hold)
+	<-held
+	exitServer(t, dir, inst.Info().PID, 0)
+	waitUntil(t, "the server to exit", func() bool { return !inst.Info().Running })
+	go discard(nil)
+
+	if state := inst.CurrentState(); state != StateStopped {
+		t.Errorf("state %s after the exit, want stopped", state)
+	}
+}
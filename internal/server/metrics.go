@@ -0,0 +1,42 @@
+package server
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/metrics"
+)
+
+// CollectMetrics writes the state and resource use of every instance
+func (m *Manager) CollectMetrics(w *metrics.Writer) {
+	now := time.Now()
+	for _, inst := range m.List() {
+		info := inst.Info()
+		labels := []string{"port", strconv.Itoa(info.Port), "model", info.Model}
+
+		w.Gauge("efx_server_up", "Whether the server process is running", boolValue(info.Running), labels...)
+		w.Gauge("efx_server_ready", "Whether the server answers health checks", boolValue(info.State == StateReady), labels...)
+		w.Counter("efx_server_restarts_total", "Times the server was restarted after exiting", float64(info.Restarts), labels...)
+
+		uptime := 0.0
+		if info.Running {
+			uptime = now.Sub(info.StartedAt).Seconds()
+		}
+		w.Gauge("efx_server_uptime_seconds", "Time since the server process started", uptime, labels...)
+
+		// Usage is only sampled while the process runs
+		if !info.Running || info.Usage.At.IsZero() {
+			continue
+		}
+		w.Gauge("efx_server_cpu_percent", "CPU use of the server process tree in percent of one core", info.Usage.CPU, labels...)
+		w.Gauge("efx_server_resident_memory_bytes", "Resident memory of the server process tree", float64(info.Usage.RSS), labels...)
+		w.Gauge("efx_server_threads", "Threads of the server process tree", float64(info.Usage.Threads), labels...)
+	}
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,321 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/model"
+)
+
+// TrackedServer records a launched server so other efx-face processes can
+// find it, and a later session can recognize it if this one dies without
+// stopping it
+type TrackedServer struct {
+	PID       int       `json:"pid"`
+	Port      int       `json:"port"`
+	Host      string    `json:"host"`
+	Model     string    `json:"model"`
+	Type      string    `json:"type"`
+	Args      []string  `json:"args"`
+	StartedAt time.Time `json:"started_at"`
+
+	Owner int `json:"-"` // PID of the efx-face process running the server
+}
+
+// stateFile is the state of one efx-face process and the servers it runs
+type stateFile struct {
+	Owner   int             `json:"owner"`
+	Servers []TrackedServer `json:"servers"`
+}
+
+// StateDir returns the directory holding one state file per running
+// efx-face process
+func StateDir() string {
+	return filepath.Join(config.ConfigDir(), "servers")
+}
+
+// TrackState makes the manager record its servers in a state file under dir
+// and keep it current as servers start and stop
+func (m *Manager) TrackState(dir string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stateFile = filepath.Join(dir, strconv.Itoa(os.Getpid())+".json")
+	m.saveState()
+}
+
+// saveState writes the state file, or removes it when no server runs. The
+// caller holds m.mu.
+func (m *Manager) saveState() {
+	if m.stateFile == "" {
+		return
+	}
+	state := stateFile{Owner: os.Getpid()}
+	for _, inst := range m.instances {
+		inst.mu.Lock()
+		if inst.Running {
+			state.Servers = append(state.Servers, TrackedServer{
+				PID:       inst.PID,
+				Port:      inst.Port,
+				Host:      inst.Host,
+				Model:     inst.Model,
+				Type:      inst.Type,
+				Args:      inst.Args,
+				StartedAt: inst.StartedAt,
+			})
+		}
+		inst.mu.Unlock()
+	}
+	if len(state.Servers) == 0 {
+		os.Remove(m.stateFile)
+		return
+	}
+
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(m.stateFile), 0755); err != nil {
+		return
+	}
+	tmp := m.stateFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, m.stateFile)
+}
+
+// TrackedServers returns the servers recorded by running efx-face processes
+func TrackedServers() []TrackedServer {
+	var servers []TrackedServer
+	files, _ := filepath.Glob(filepath.Join(StateDir(), "*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var state stateFile
+		if json.Unmarshal(data, &state) != nil || !processAlive(state.Owner) {
+			continue
+		}
+		for _, srv := range state.Servers {
+			if processAlive(srv.PID) {
+				srv.Owner = state.Owner
+				servers = append(servers, srv)
+			}
+		}
+	}
+	return servers
+}
+
+// Orphan is a live mlx-openai-server process that no running efx-face
+// session manages, typically left behind by one that crashed
+type Orphan struct {
+	PID       int
+	Port      int
+	Host      string
+	Model     string
+	Type      string
+	Args      []string
+	StartedAt time.Time
+}
+
+// serverProcess is a running mlx-openai-server found by listServerProcesses
+type serverProcess struct {
+	PID       int
+	PPID      int
+	Args      []string // arguments after the mlx-openai-server executable
+	StartedAt time.Time
+}
+
+// FindOrphans scans for mlx-openai-server processes that are neither managed
+// here nor recorded by another live efx-face process. State files left by
+// dead sessions supply the details of the servers they launched and are
+// removed once none of their servers is alive.
+func (m *Manager) FindOrphans() []Orphan {
+	procs := listServerProcesses()
+	if len(procs) == 0 {
+		return nil
+	}
+
+	owned := make(map[int]bool)
+	m.mu.RLock()
+	for _, inst := range m.instances {
+		owned[inst.PID] = true
+	}
+	m.mu.RUnlock()
+
+	alive := make(map[int]bool, len(procs))
+	for _, p := range procs {
+		alive[p.PID] = true
+	}
+
+	known := make(map[int]TrackedServer)
+	files, _ := filepath.Glob(filepath.Join(StateDir(), "*.json"))
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var state stateFile
+		if json.Unmarshal(data, &state) != nil {
+			continue
+		}
+		if state.Owner == os.Getpid() {
+			continue
+		}
+		if processAlive(state.Owner) {
+			for _, entry := range state.Servers {
+				owned[entry.PID] = true
+			}
+			continue
+		}
+		stale := true
+		for _, entry := range state.Servers {
+			known[entry.PID] = entry
+			if alive[entry.PID] {
+				stale = false
+			}
+		}
+		if stale {
+			os.Remove(file)
+		}
+	}
+	removeStaleRequests()
+
+	var orphans []Orphan
+	for _, p := range procs {
+		// A wrapper script and the interpreter it runs both match; keep the
+		// outermost process, which is the one launched
+		if owned[p.PID] || alive[p.PPID] {
+			continue
+		}
+		if entry, ok := known[p.PID]; ok {
+			orphans = append(orphans, Orphan{PID: p.PID, Port: entry.Port, Host: entry.Host,
+				Model: entry.Model, Type: entry.Type, Args: entry.Args, StartedAt: entry.StartedAt})
+			continue
+		}
+		orphans = append(orphans, orphanFromArgs(p))
+	}
+	return orphans
+}
+
+// orphanFromArgs describes a server process from its command line
+func orphanFromArgs(p serverProcess) Orphan {
+	o := Orphan{PID: p.PID, Port: 8000, Host: "0.0.0.0", Type: string(model.TypeLM), Args: p.Args, StartedAt: p.StartedAt}
+	for i := 0; i+1 < len(p.Args); i++ {
+		value := p.Args[i+1]
+		switch p.Args[i] {
+		case "--port":
+			if port, err := strconv.Atoi(value); err == nil {
+				o.Port = port
+			}
+		case "--host":
+			o.Host = value
+		case "--model-path":
+			o.Model = filepath.Base(strings.TrimRight(value, "/"))
+		case "--model-type":
+			o.Type = value
+		}
+	}
+	if o.Model == "" {
+		o.Model = fmt.Sprintf("pid %d", p.PID)
+	}
+	return o
+}
+
+// isServerCommand reports whether argv runs mlx-openai-server and returns
+// the arguments passed to it. The executable may come first or follow an
+// interpreter, and is always given a subcommand such as launch.
+func isServerCommand(argv []string) ([]string, bool) {
+	for i := 0; i < len(argv) && i < 3; i++ {
+		if filepath.Base(argv[i]) == "mlx-openai-server" && i+1 < len(argv) && argv[i+1] == "launch" {
+			return argv[i+1:], true
+		}
+	}
+	return nil, false
+}
+
+// Adopt takes over an orphaned server. Its status is tracked and it can be
+// stopped, but the output it wrote before adoption is lost and it is not
+// restarted when it exits.
+func (m *Manager) Adopt(o Orphan) (*Instance, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if existing, exists := m.instances[o.Port]; exists {
+		return nil, &PortConflict{Port: o.Port, Model: existing.Model}
+	}
+	if !processAlive(o.PID) {
+		return nil, fmt.Errorf("process %d is no longer running", o.PID)
+	}
+
+	exited := watchExit(o.PID)
+	now := time.Now()
+	instance := &Instance{
+		Model:      o.Model,
+		Type:       o.Type,
+		Port:       o.Port,
+		Host:       o.Host,
+		Args:       o.Args,
+		PID:        o.PID,
+		Output:     NewRingBuffer(1000),
+		StartedAt:  o.StartedAt,
+		Running:    true,
+		State:      StateStarting,
+		StateSince: now,
+		Restart:    RestartNever,
+		Adopted:    true,
+		exited:     exited,
+	}
+	if instance.StartedAt.IsZero() {
+		instance.StartedAt = now
+	}
+
+	go m.Health.Watch(instance, exited, m.Updates)
+	go m.Monitor.Watch(instance, exited, m.Updates)
+	go func() {
+		<-exited
+		// Adopted servers are not restarted, whatever was requested
+		m.takeRequest(o.PID)
+		instance.setState(StateStopped, "", nil)
+		m.mu.Lock()
+		if m.instances[instance.Port] == instance {
+			instance.mu.Lock()
+			instance.Running = false
+			instance.mu.Unlock()
+			m.saveState()
+		}
+		m.mu.Unlock()
+		m.Updates <- Update{Port: instance.Port, Type: UpdateStopped}
+	}()
+
+	m.instances[o.Port] = instance
+	m.saveState()
+	m.Updates <- Update{Port: o.Port, Type: UpdateStarted}
+	return instance, nil
+}
+
+// KillOrphan stops an orphaned server the same way Stop does: SIGTERM to its
+// process group, then SIGKILL after the grace period
+func (m *Manager) KillOrphan(o Orphan) error {
+	return m.terminate(o.PID, watchExit(o.PID))
+}
+
+// watchExit returns a channel closed once pid exits. It polls because the
+// process is not a child of this one and cannot be waited for.
+func watchExit(pid int) <-chan struct{} {
+	exited := make(chan struct{})
+	go func() {
+		for processAlive(pid) {
+			time.Sleep(500 * time.Millisecond)
+		}
+		close(exited)
+	}()
+	return exited
+}
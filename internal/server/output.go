@@ -1,52 +1,113 @@
 package server
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
-// RingBuffer keeps last N lines of output
+// Output sources
+const (
+	SourceStdout = "stdout"
+	SourceStderr = "stderr"
+	SourceSystem = "efx" // messages from efx-face itself
+)
+
+// Log levels parsed from server output
+const (
+	LevelDebug   = "DEBUG"
+	LevelInfo    = "INFO"
+	LevelWarning = "WARNING"
+	LevelError   = "ERROR"
+)
+
+// LogEntry is a line of server output, when it was received, the stream it
+// came from and the level parsed from it
+type LogEntry struct {
+	Time    time.Time `json:"time"`
+	Source  string    `json:"source,omitempty"`
+	Level   string    `json:"level,omitempty"`
+	Text    string    `json:"text"`
+	Seq     uint64    `json:"seq,omitempty"`     // position in the buffer's output, 0 for partial lines
+	Partial bool      `json:"partial,omitempty"` // unfinished line, replaced as more arrives
+}
+
+// Format renders the entry raw, as the server printed it, or structured,
+// prefixed with its receive time, source and level
+func (e LogEntry) Format(structured bool) string {
+	if !structured || e.Time.IsZero() {
+		return e.Text
+	}
+	level := e.Level
+	if level == "" {
+		level = "-"
+	}
+	return fmt.Sprintf("%s %-6s %-7s %s", e.Time.Format("2006-01-02 15:04:05.000"), e.Source, level, e.Text)
+}
+
+// RingBuffer keeps last N lines of output, plus the unfinished line of each
+// stream
 type RingBuffer struct {
-	lines    []string
+	entries  []LogEntry
+	partials map[string]LogEntry
 	capacity int
 	head     int
 	size     int
+	seq      uint64
 	mu       sync.Mutex
 }
 
 // NewRingBuffer creates a new ring buffer
 func NewRingBuffer(capacity int) *RingBuffer {
 	return &RingBuffer{
-		lines:    make([]string, capacity),
+		entries:  make([]LogEntry, capacity),
+		partials: make(map[string]LogEntry),
 		capacity: capacity,
 	}
 }
 
-// Write adds a line to the buffer
+// Write adds a message from efx-face to the buffer, stamped with the
+// current time
 func (b *RingBuffer) Write(line string) {
+	b.Append(LogEntry{Time: time.Now(), Source: SourceSystem, Text: line})
+}
+
+// Append adds a complete line to the buffer, replacing the partial line of
+// its stream, and returns it with its sequence number
+func (b *RingBuffer) Append(entry LogEntry) LogEntry {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	b.lines[b.head] = line
+	b.seq++
+	entry.Seq = b.seq
+	entry.Partial = false
+	delete(b.partials, entry.Source)
+	b.entries[b.head] = entry
 	b.head = (b.head + 1) % b.capacity
 	if b.size < b.capacity {
 		b.size++
 	}
+	return entry
 }
 
-// String returns all lines as a single string
-func (b *RingBuffer) String() string {
+// SetPartial records the unfinished line of a stream, shown after the
+// complete lines until Append replaces it
+func (b *RingBuffer) SetPartial(entry LogEntry) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
+	entry.Seq = 0
+	entry.Partial = true
+	b.partials[entry.Source] = entry
+}
+
+// String returns all lines as a single string
+func (b *RingBuffer) String() string {
 	var result strings.Builder
-	start := 0
-	if b.size == b.capacity {
-		start = b.head
-	}
-	for i := 0; i < b.size; i++ {
-		idx := (start + i) % b.capacity
-		result.WriteString(b.lines[idx])
+	for _, entry := range b.Entries() {
+		result.WriteString(entry.Text)
 		result.WriteString("\n")
 	}
 	return result.String()
@@ -54,19 +115,33 @@ func (b *RingBuffer) String() string {
 
 // Lines returns all lines as a slice
 func (b *RingBuffer) Lines() []string {
+	entries := b.Entries()
+	result := make([]string, len(entries))
+	for i, entry := range entries {
+		result[i] = entry.Text
+	}
+	return result
+}
+
+// Entries returns all lines, oldest first, followed by the partial lines
+func (b *RingBuffer) Entries() []LogEntry {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
-	result := make([]string, b.size)
+	result := make([]LogEntry, b.size, b.size+len(b.partials))
 	start := 0
 	if b.size == b.capacity {
 		start = b.head
 	}
 	for i := 0; i < b.size; i++ {
-		idx := (start + i) % b.capacity
-		result[i] = b.lines[idx]
+		result[i] = b.entries[(start+i)%b.capacity]
 	}
-	return result
+	partials := make([]LogEntry, 0, len(b.partials))
+	for _, entry := range b.partials {
+		partials = append(partials, entry)
+	}
+	sort.Slice(partials, func(i, j int) bool { return partials[i].Time.Before(partials[j].Time) })
+	return append(result, partials...)
 }
 
 // Clear clears the buffer
@@ -76,6 +151,7 @@ func (b *RingBuffer) Clear() {
 
 	b.head = 0
 	b.size = 0
+	b.partials = make(map[string]LogEntry)
 }
 
 // Size returns the current number of lines
@@ -0,0 +1,36 @@
+//go:build !linux
+
+package server
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// portOwner asks lsof for the process listening on port. Where lsof is not
+// available the owner is reported as unknown.
+func portOwner(port int) (int, string) {
+	out, err := exec.Command("lsof", "-nP", fmt.Sprintf("-iTCP:%d", port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil {
+		return 0, ""
+	}
+
+	pid, name := 0, ""
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		switch line[0] {
+		case 'p':
+			if pid != 0 {
+				return pid, name
+			}
+			pid, _ = strconv.Atoi(line[1:])
+		case 'c':
+			name = line[1:]
+		}
+	}
+	return pid, name
+}
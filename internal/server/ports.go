@@ -0,0 +1,74 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// PortConflict reports a port that a server cannot bind
+type PortConflict struct {
+	Port    int
+	Model   string // model of the efx-face server holding the port, if any
+	PID     int    // process holding the port, 0 if unknown
+	Process string // name of that process
+	Err     error  // bind error
+}
+
+func (e *PortConflict) Error() string {
+	switch {
+	case e.Model != "":
+		return fmt.Sprintf("port %d is used by server %s", e.Port, e.Model)
+	case e.PID != 0:
+		return fmt.Sprintf("port %d is in use by %s (pid %d)", e.Port, e.Process, e.PID)
+	case e.Err != nil:
+		return fmt.Sprintf("port %d is not available: %v", e.Port, e.Err)
+	default:
+		return fmt.Sprintf("port %d is in use", e.Port)
+	}
+}
+
+func (e *PortConflict) Unwrap() error {
+	return e.Err
+}
+
+// CheckPort tries to bind host:port and returns a *PortConflict naming the
+// process that holds the port when it is not available
+func CheckPort(host string, port int) error {
+	err := bindPort(host, port)
+	if err == nil {
+		return nil
+	}
+	conflict := &PortConflict{Port: port, Err: err}
+	conflict.PID, conflict.Process = portOwner(port)
+	return conflict
+}
+
+// PortFree reports whether host:port can be bound
+func PortFree(host string, port int) bool {
+	return bindPort(host, port) == nil
+}
+
+// bindPort opens and closes a listener on host:port. An empty host binds all
+// interfaces, the mlx-openai-server default. Loopback and the wildcard
+// address are tried as well: some systems let a wildcard listener share a
+// port held on loopback, where clients of the server connect.
+func bindPort(host string, port int) error {
+	if host == "" {
+		host = "0.0.0.0"
+	}
+	hosts := []string{host}
+	for _, h := range []string{"127.0.0.1", "0.0.0.0"} {
+		if h != host {
+			hosts = append(hosts, h)
+		}
+	}
+	for _, h := range hosts {
+		ln, err := net.Listen("tcp", net.JoinHostPort(h, strconv.Itoa(port)))
+		if err != nil {
+			return err
+		}
+		ln.Close()
+	}
+	return nil
+}
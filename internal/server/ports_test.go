@@ -0,0 +1,31 @@
+package server
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestCheckPortLoopbackListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	for _, host := range []string{"", "0.0.0.0", "127.0.0.1"} {
+		var conflict *PortConflict
+		if err := CheckPort(host, port); !errors.As(err, &conflict) || conflict.Port != port {
+			t.Errorf("CheckPort(%q) = %v with the port held on loopback, want a PortConflict", host, err)
+		}
+		if PortFree(host, port) {
+			t.Errorf("PortFree(%q) = true with the port held on loopback", host)
+		}
+	}
+
+	ln.Close()
+	if err := CheckPort("", port); err != nil {
+		t.Errorf("CheckPort after the listener closed: %v", err)
+	}
+}
@@ -0,0 +1,35 @@
+//go:build !windows
+
+package server
+
+import "syscall"
+
+// terminateGroup asks every process in the server's process group to exit.
+// Servers are started in their own session, so the group ID is the PID.
+func terminateGroup(pid int) error {
+	return signalGroup(pid, syscall.SIGTERM)
+}
+
+// killGroup forcibly kills every process in the server's process group
+func killGroup(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// signalGroup signals the process group led by pid, or pid alone when it
+// does not lead a group, as with adopted servers started outside efx-face
+func signalGroup(pid int, sig syscall.Signal) error {
+	if err := syscall.Kill(-pid, sig); err == nil {
+		return nil
+	}
+	return syscall.Kill(pid, sig)
+}
+
+// processAlive reports whether a process with pid exists and has not
+// exited. A zombie waiting to be reaped counts as exited.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return (err == nil || err == syscall.EPERM) && !isZombie(pid)
+}
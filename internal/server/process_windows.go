@@ -0,0 +1,32 @@
+//go:build windows
+
+package server
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// terminateGroup asks the server and its child processes to exit
+func terminateGroup(pid int) error {
+	return exec.Command("taskkill", "/T", "/PID", strconv.Itoa(pid)).Run()
+}
+
+// killGroup forcibly kills the server and its child processes
+func killGroup(pid int) error {
+	return exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(pid)).Run()
+}
+
+// processAlive reports whether a process with pid exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
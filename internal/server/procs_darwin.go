@@ -0,0 +1,126 @@
+//go:build darwin
+
+package server
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// listServerProcesses finds running mlx-openai-server processes with ps.
+// ps joins the arguments with spaces, so paths containing spaces are split.
+func listServerProcesses() []serverProcess {
+	out, err := exec.Command("ps", "-axo", "pid=,ppid=,etime=,command=").Output()
+	if err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	var procs []serverProcess
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		args, ok := isServerCommand(fields[3:])
+		if !ok {
+			continue
+		}
+		pid, _ := strconv.Atoi(fields[0])
+		ppid, _ := strconv.Atoi(fields[1])
+		procs = append(procs, serverProcess{
+			PID:       pid,
+			PPID:      ppid,
+			Args:      args,
+			StartedAt: now.Add(-parseElapsed(fields[2])),
+		})
+	}
+	return procs
+}
+
+// sampleProcessTree adds up the CPU time, resident memory and threads of
+// pid and its descendants with ps
+func sampleProcessTree(pid int) (treeSample, error) {
+	out, err := exec.Command("ps", "-axo", "pid=,ppid=,rss=,time=").Output()
+	if err != nil {
+		return treeSample{}, err
+	}
+	type procStat struct {
+		cpu time.Duration
+		rss uint64 // KiB
+	}
+	stats := make(map[int]procStat)
+	children := make(map[int][]int)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		p, _ := strconv.Atoi(fields[0])
+		ppid, _ := strconv.Atoi(fields[1])
+		rss, _ := strconv.ParseUint(fields[2], 10, 64)
+		stats[p] = procStat{cpu: parseCPUTime(fields[3]), rss: rss}
+		children[ppid] = append(children[ppid], p)
+	}
+	if _, ok := stats[pid]; !ok {
+		return treeSample{}, fmt.Errorf("process %d not found", pid)
+	}
+
+	var sample treeSample
+	var pids []string
+	queue := []int{pid}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		sample.cpu += stats[p].cpu
+		sample.rss += stats[p].rss << 10
+		sample.processes++
+		pids = append(pids, strconv.Itoa(p))
+		queue = append(queue, children[p]...)
+	}
+
+	// ps -M prints a header, then one line per thread
+	if out, err := exec.Command("ps", "-M", "-p", strings.Join(pids, ",")).Output(); err == nil {
+		sample.threads = max(strings.Count(strings.TrimSpace(string(out)), "\n"), 0)
+	}
+	return sample, nil
+}
+
+// parseCPUTime parses the ps time format [[dd-]hh:]mm:ss[.cc]
+func parseCPUTime(value string) time.Duration {
+	var days int
+	if d, rest, ok := strings.Cut(value, "-"); ok {
+		days, _ = strconv.Atoi(d)
+		value = rest
+	}
+	var secs float64
+	for _, part := range strings.Split(value, ":") {
+		n, _ := strconv.ParseFloat(part, 64)
+		secs = secs*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(secs*float64(time.Second))
+}
+
+// isZombie reports whether pid has exited but not been reaped yet
+func isZombie(pid int) bool {
+	out, err := exec.Command("ps", "-o", "stat=", "-p", strconv.Itoa(pid)).Output()
+	return err == nil && strings.HasPrefix(strings.TrimSpace(string(out)), "Z")
+}
+
+// parseElapsed parses the ps etime format [[dd-]hh:]mm:ss
+func parseElapsed(etime string) time.Duration {
+	var days int
+	if d, rest, ok := strings.Cut(etime, "-"); ok {
+		days, _ = strconv.Atoi(d)
+		etime = rest
+	}
+	var secs int
+	for _, part := range strings.Split(etime, ":") {
+		n, _ := strconv.Atoi(part)
+		secs = secs*60 + n
+	}
+	return time.Duration(days)*24*time.Hour + time.Duration(secs)*time.Second
+}
@@ -0,0 +1,149 @@
+//go:build linux
+
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the unit of process start times in /proc, USER_HZ, which is
+// 100 on every mainstream Linux architecture
+const clockTicks = 100
+
+// procRoot is where the proc filesystem is mounted
+const procRoot = "/proc"
+
+// listServerProcesses finds running mlx-openai-server processes in /proc
+func listServerProcesses() []serverProcess {
+	boot := bootTime()
+	dirs, _ := filepath.Glob(filepath.Join(procRoot, "[0-9]*"))
+
+	var procs []serverProcess
+	for _, dir := range dirs {
+		raw, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+		if err != nil || len(raw) == 0 {
+			continue
+		}
+		args, ok := isServerCommand(strings.Split(strings.TrimRight(string(raw), "\x00"), "\x00"))
+		if !ok {
+			continue
+		}
+		pid, _ := strconv.Atoi(filepath.Base(dir))
+		p := serverProcess{PID: pid, Args: args}
+
+		// Fields after the parenthesized command name start at field 3
+		if stat, err := os.ReadFile(filepath.Join(dir, "stat")); err == nil {
+			if i := strings.LastIndexByte(string(stat), ')'); i >= 0 {
+				fields := strings.Fields(string(stat[i+1:]))
+				if len(fields) > 19 {
+					p.PPID, _ = strconv.Atoi(fields[1])
+					if ticks, err := strconv.ParseInt(fields[19], 10, 64); err == nil && !boot.IsZero() {
+						p.StartedAt = boot.Add(time.Duration(ticks) * time.Second / clockTicks)
+					}
+				}
+			}
+		}
+		procs = append(procs, p)
+	}
+	return procs
+}
+
+// sampleProcessTree adds up the CPU time, resident memory and threads of
+// pid and its descendants
+func sampleProcessTree(pid int) (treeSample, error) {
+	return sampleProcessTreeIn(procRoot, pid)
+}
+
+// sampleProcessTreeIn is sampleProcessTree reading the proc filesystem
+// mounted at root
+func sampleProcessTreeIn(root string, pid int) (treeSample, error) {
+	type procStat struct {
+		ppid    int
+		cpu     int64 // utime + stime in clock ticks
+		threads int
+		rss     uint64 // pages
+	}
+	stats := make(map[int]procStat)
+	children := make(map[int][]int)
+	dirs, _ := filepath.Glob(filepath.Join(root, "[0-9]*"))
+	for _, dir := range dirs {
+		raw, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue
+		}
+		// Fields after the parenthesized command name start at field 3
+		i := strings.LastIndexByte(string(raw), ')')
+		if i < 0 {
+			continue
+		}
+		fields := strings.Fields(string(raw[i+1:]))
+		if len(fields) < 22 {
+			continue
+		}
+		p, _ := strconv.Atoi(filepath.Base(dir))
+		var st procStat
+		st.ppid, _ = strconv.Atoi(fields[1])
+		utime, _ := strconv.ParseInt(fields[11], 10, 64)
+		stime, _ := strconv.ParseInt(fields[12], 10, 64)
+		st.cpu = utime + stime
+		st.threads, _ = strconv.Atoi(fields[17])
+		st.rss, _ = strconv.ParseUint(fields[21], 10, 64)
+		stats[p] = st
+		children[st.ppid] = append(children[st.ppid], p)
+	}
+
+	if _, ok := stats[pid]; !ok {
+		return treeSample{}, fmt.Errorf("process %d not found", pid)
+	}
+	var sample treeSample
+	var ticks int64
+	pageSize := uint64(os.Getpagesize())
+	queue := []int{pid}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		st := stats[p]
+		ticks += st.cpu
+		sample.rss += st.rss * pageSize
+		sample.threads += st.threads
+		sample.processes++
+		queue = append(queue, children[p]...)
+	}
+	sample.cpu = time.Duration(ticks) * time.Second / clockTicks
+	return sample, nil
+}
+
+// isZombie reports whether pid has exited but not been reaped yet
+func isZombie(pid int) bool {
+	stat, err := os.ReadFile(filepath.Join(procRoot, strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	i := strings.LastIndexByte(string(stat), ')')
+	return i >= 0 && strings.HasPrefix(string(stat[i+1:]), " Z")
+}
+
+// bootTime reads the system boot time from /proc/stat
+func bootTime() time.Time {
+	f, err := os.Open(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return time.Time{}
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "btime "); ok {
+			if secs, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+				return time.Unix(secs, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,65 @@
+//go:build linux
+
+package server
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// statLine returns a /proc/<pid>/stat line with the fields that
+// sampleProcessTree reads set
+func statLine(pid int, comm string, ppid int, utime, stime int64, threads int, rss uint64) string {
+	return fmt.Sprintf("%d (%s) S %d %d %d 0 -1 4194560 2954 0 0 0 %d %d 0 0 20 0 %d 0 81234 3276800 %d 18446744073709551615 1 1 0 0 0 0 0 16781312 16386 0 0 0 17 3 0 0 0 0 0\n",
+		pid, comm, ppid, pid, pid, utime, stime, threads, rss)
+}
+
+func TestSampleProcessTree(t *testing.T) {
+	root := t.TempDir()
+	for pid, stat := range map[int]string{
+		100: statLine(100, "mlx-openai-serv", 1, 500, 100, 12, 1000),
+		101: statLine(101, "python3 (worker) 2", 100, 200, 50, 4, 300),
+		102: statLine(102, ") S 1 (", 101, 30, 20, 1, 10),
+		103: statLine(103, "my proc", 100, 0, 0, 1, 5),
+		200: statLine(200, "bash", 1, 70, 7, 1, 80), // not part of the tree
+		300: "300 (truncated) S 100 300",
+		301: "no command name",
+	} {
+		dir := filepath.Join(root, fmt.Sprint(pid))
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	page := uint64(os.Getpagesize())
+
+	tests := []struct {
+		name    string
+		pid     int
+		want    treeSample
+		wantErr bool
+	}{
+		{"tree", 100, treeSample{cpu: 9 * time.Second, rss: 1315 * page, threads: 18, processes: 4}, false},
+		{"comm with spaces and parentheses", 101, treeSample{cpu: 3 * time.Second, rss: 310 * page, threads: 5, processes: 2}, false},
+		{"leaf", 102, treeSample{cpu: 500 * time.Millisecond, rss: 10 * page, threads: 1, processes: 1}, false},
+		{"unrelated process", 200, treeSample{cpu: 770 * time.Millisecond, rss: 80 * page, threads: 1, processes: 1}, false},
+		{"unreadable stat", 300, treeSample{}, true},
+		{"missing process", 999, treeSample{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := sampleProcessTreeIn(root, tt.pid)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
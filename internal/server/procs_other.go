@@ -0,0 +1,22 @@
+//go:build !linux && !darwin
+
+package server
+
+import "errors"
+
+// listServerProcesses is not implemented on this platform, so orphaned
+// servers are not detected
+func listServerProcesses() []serverProcess {
+	return nil
+}
+
+// isZombie is not implemented on this platform
+func isZombie(pid int) bool {
+	return false
+}
+
+// sampleProcessTree is not implemented on this platform, so resource use
+// is not monitored
+func sampleProcessTree(pid int) (treeSample, error) {
+	return treeSample{}, errors.ErrUnsupported
+}
@@ -0,0 +1,96 @@
+package server
+
+import "time"
+
+// ResourceUsage is the combined resource use of a server process and the
+// processes it started
+type ResourceUsage struct {
+	At        time.Time `json:"at"`
+	CPU       float64   `json:"cpu"` // percent of one core since the previous sample
+	RSS       uint64    `json:"rss"` // resident memory in bytes
+	Threads   int       `json:"threads"`
+	Processes int       `json:"processes"`
+}
+
+// treeSample is the usage of a process tree at one moment, as read from
+// the operating system
+type treeSample struct {
+	cpu       time.Duration // CPU time used so far
+	rss       uint64
+	threads   int
+	processes int
+}
+
+// ResourceMonitor samples the resource use of instances
+type ResourceMonitor struct {
+	Interval time.Duration // time between samples
+	History  int           // samples kept per instance
+}
+
+// NewResourceMonitor creates a monitor with the default interval and
+// history length
+func NewResourceMonitor() *ResourceMonitor {
+	return &ResourceMonitor{
+		Interval: 2 * time.Second,
+		History:  60,
+	}
+}
+
+// Watch samples the process tree of inst until done is closed, recording
+// each sample on inst and publishing it on updates. Nothing is recorded on
+// platforms where process trees cannot be read.
+func (r *ResourceMonitor) Watch(inst *Instance, done <-chan struct{}, updates chan<- Update) {
+	inst.mu.Lock()
+	pid := inst.PID
+	inst.mu.Unlock()
+
+	// The first sample is only a baseline for measuring CPU use
+	last, err := sampleProcessTree(pid)
+	if err != nil {
+		return
+	}
+	lastAt := time.Now()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-time.After(r.Interval):
+		}
+
+		sample, err := sampleProcessTree(pid)
+		if err != nil {
+			continue
+		}
+		now := time.Now()
+		usage := ResourceUsage{
+			At:        now,
+			RSS:       sample.rss,
+			Threads:   sample.threads,
+			Processes: sample.processes,
+		}
+		// CPU time falls when a child exits, leaving no usable difference
+		if sample.cpu >= last.cpu {
+			usage.CPU = float64(sample.cpu-last.cpu) / float64(now.Sub(lastAt)) * 100
+		}
+		last, lastAt = sample, now
+
+		inst.recordUsage(usage, r.History)
+		updates <- Update{Port: inst.Port, Type: UpdateResources}
+	}
+}
+
+// recordUsage stores a sample, keeping the last limit samples as history
+func (i *Instance) recordUsage(usage ResourceUsage, limit int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// Readers may hold the old history, so it is copied rather than
+	// appended to in place
+	history := i.UsageHistory
+	if len(history) >= limit {
+		history = history[len(history)-limit+1:]
+	}
+	i.UsageHistory = append(append(make([]ResourceUsage, 0, len(history)+1), history...), usage)
+	i.Usage = usage
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"fmt"
+	"time"
+)
+
+// RestartPolicy decides whether a server is restarted after its process exits
+type RestartPolicy string
+
+const (
+	RestartNever     RestartPolicy = "never"
+	RestartOnFailure RestartPolicy = "on-failure"
+	RestartAlways    RestartPolicy = "always"
+)
+
+const (
+	// DefaultMaxRetries bounds consecutive restarts when a config sets none
+	DefaultMaxRetries = 5
+
+	// crashLogLines is how much output is kept with an exit record
+	crashLogLines = 20
+
+	// stableAfter is how long a server must run before its consecutive
+	// restart count resets
+	stableAfter = 5 * time.Minute
+
+	// DefaultRestartBackoff is the delay before a first restart, doubled
+	// for each consecutive one up to restartBackoffMax
+	DefaultRestartBackoff = time.Second
+	restartBackoffMax     = time.Minute
+)
+
+// ExitInfo records how a server process last exited
+type ExitInfo struct {
+	Code int       `json:"code"`
+	At   time.Time `json:"at"`
+	Logs []string  `json:"logs,omitempty"`
+}
+
+// ParseRestartPolicy validates a policy name, treating empty as never
+func ParseRestartPolicy(name string) (RestartPolicy, error) {
+	switch RestartPolicy(name) {
+	case "", RestartNever:
+		return RestartNever, nil
+	case RestartOnFailure, RestartAlways:
+		return RestartPolicy(name), nil
+	}
+	return "", fmt.Errorf("unknown restart policy %q (never, on-failure, always)", name)
+}
+
+// shouldRestart reports whether the policy restarts a process that exited
+// with code
+func (p RestartPolicy) shouldRestart(code int) bool {
+	switch p {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return code != 0
+	}
+	return false
+}
+
+// restartBackoff returns the delay before the nth consecutive restart,
+// starting from base
+func restartBackoff(base time.Duration, n int) time.Duration {
+	delay := base
+	for i := 1; i < n && delay < restartBackoffMax; i++ {
+		delay *= 2
+	}
+	if delay > restartBackoffMax {
+		delay = restartBackoffMax
+	}
+	return delay
+}
+
+// handleExit records an unexpected exit and schedules a restart when the
+// instance's policy allows one. After too many consecutive restarts the
+// instance is left stopped and flagged as crash looping.
+func (m *Manager) handleExit(instance *Instance, code int) {
+	logs := instance.Output.Lines()
+	if len(logs) > crashLogLines {
+		logs = logs[len(logs)-crashLogLines:]
+	}
+
+	instance.mu.Lock()
+	instance.LastExit = &ExitInfo{Code: code, At: time.Now(), Logs: logs}
+	if time.Since(instance.StartedAt) >= stableAfter {
+		instance.retries = 0
+	}
+	restart := instance.Restart.shouldRestart(code)
+	maxRetries := instance.config.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = DefaultMaxRetries
+	}
+	if restart && instance.retries >= maxRetries {
+		restart = false
+		instance.CrashLoop = true
+	}
+	if restart {
+		instance.retries++
+		instance.Restarts++
+	}
+	retries := instance.retries
+	crashLoop := instance.CrashLoop
+	instance.mu.Unlock()
+
+	if crashLoop && !restart {
+		m.Updates <- Update{Port: instance.Port, Type: UpdateError,
+			Data: fmt.Sprintf("crash loop: gave up after %d restarts (exit code %d)", maxRetries, code)}
+		return
+	}
+	if !restart {
+		return
+	}
+
+	delay := restartBackoff(m.RestartBackoff, retries)
+	instance.setState(StateRestarting, fmt.Sprintf("exit code %d, restart %d/%d in %s", code, retries, maxRetries, delay), m.Updates)
+	time.AfterFunc(delay, func() { m.relaunch(instance) })
+}
+
+// relaunch restarts the process of an instance unless it was stopped,
+// replaced or already restarted while waiting
+func (m *Manager) relaunch(instance *Instance) {
+	m.mu.Lock()
+	instance.mu.Lock()
+	stopping, running := instance.stopping, instance.Running
+	instance.mu.Unlock()
+	if m.instances[instance.Port] != instance || stopping || running {
+		m.mu.Unlock()
+		return
+	}
+	err := m.launch(instance)
+	if err == nil {
+		m.saveState()
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		m.Updates <- Update{Port: instance.Port, Type: UpdateError, Data: err.Error()}
+		m.handleExit(instance, -1)
+		return
+	}
+	m.Updates <- Update{Port: instance.Port, Type: UpdateStarted}
+}
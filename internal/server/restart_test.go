@@ -0,0 +1,66 @@
+package server
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRestartPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		want RestartPolicy
+		ok   bool
+	}{
+		{"", RestartNever, true},
+		{"never", RestartNever, true},
+		{"on-failure", RestartOnFailure, true},
+		{"always", RestartAlways, true},
+		{"sometimes", "", false},
+	} {
+		got, err := ParseRestartPolicy(tt.name)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("ParseRestartPolicy(%q) = %q, %v; want %q, ok %v", tt.name, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestShouldRestart(t *testing.T) {
+	for _, tt := range []struct {
+		policy RestartPolicy
+		code   int
+		want   bool
+	}{
+		{RestartNever, 0, false},
+		{RestartNever, 1, false},
+		{RestartOnFailure, 0, false},
+		{RestartOnFailure, 1, true},
+		{RestartOnFailure, -1, true}, // killed by a signal
+		{RestartAlways, 0, true},
+		{RestartAlways, 1, true},
+	} {
+		if got := tt.policy.shouldRestart(tt.code); got != tt.want {
+			t.Errorf("%s with exit code %d: restart %v, want %v", tt.policy, tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestRestartBackoff(t *testing.T) {
+	for _, tt := range []struct {
+		base time.Duration
+		n    int
+		want time.Duration
+	}{
+		{time.Second, 1, time.Second},
+		{time.Second, 2, 2 * time.Second},
+		{time.Second, 3, 4 * time.Second},
+		{time.Second, 6, 32 * time.Second},
+		{time.Second, 7, time.Minute},
+		{time.Second, 100, time.Minute},
+		{10 * time.Millisecond, 4, 80 * time.Millisecond},
+		{45 * time.Second, 2, time.Minute},
+	} {
+		if got := restartBackoff(tt.base, tt.n); got != tt.want {
+			t.Errorf("restartBackoff(%s, %d) = %s, want %s", tt.base, tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+//go:build !windows
+
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// startRestarting starts a fake server with policy on a manager that
+// restarts it after a short backoff
+func startRestarting(t *testing.T, policy RestartPolicy, maxRetries int) (*Manager, *Instance, string) {
+	t.Helper()
+	dir := installFakeServer(t)
+	m := newTestManager(t)
+	m.RestartBackoff = 10 * time.Millisecond
+	inst, err := m.Start(Config{Model: "fake", Port: freePort(t), Host: "127.0.0.1", Restart: policy, MaxRetries: maxRetries})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m, inst, dir
+}
+
+// waitRestart waits until the process pid of inst has been replaced
+func waitRestart(t *testing.T, inst *Instance, pid int) {
+	t.Helper()
+	waitUntil(t, "the restart", func() bool {
+		info := inst.Info()
+		return info.Running && info.PID != pid
+	})
+}
+
+func TestRestartPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		policy  RestartPolicy
+		code    int
+		restart bool
+	}{
+		{RestartNever, 1, false},
+		{RestartOnFailure, 0, false},
+		{RestartOnFailure, 3, true},
+		{RestartAlways, 0, true},
+	} {
+		t.Run(fmt.Sprintf("%s exit %d", tt.policy, tt.code), func(t *testing.T) {
+			_, inst, dir := startRestarting(t, tt.policy, 0)
+			pid := inst.Info().PID
+			exitServer(t, dir, pid, tt.code)
+
+			if tt.restart {
+				waitRestart(t, inst, pid)
+				waitUntil(t, "the new process to start", func() bool { return startedServers(t, dir) == 2 })
+			} else {
+				waitUntil(t, "the exit to be recorded", func() bool { return inst.Info().LastExit != nil })
+				time.Sleep(100 * time.Millisecond) // well past the backoff
+			}
+			info := inst.Info()
+			if info.LastExit == nil || info.LastExit.Code != tt.code {
+				t.Errorf("last exit %+v, want code %d", info.LastExit, tt.code)
+			}
+			want := 1
+			if tt.restart {
+				want = 2
+			}
+			if started := startedServers(t, dir); started != want || info.Restarts != want-1 {
+				t.Errorf("%d processes started and %d restarts, want %d and %d", started, info.Restarts, want, want-1)
+			}
+		})
+	}
+}
+
+func TestRestartCrashLoop(t *testing.T) {
+	_, inst, dir := startRestarting(t, RestartOnFailure, 2)
+	for range 2 {
+		pid := inst.Info().PID
+		exitServer(t, dir, pid, 1)
+		waitRestart(t, inst, pid)
+	}
+	exitServer(t, dir, inst.Info().PID, 1)
+	waitUntil(t, "the crash loop", func() bool { return inst.Info().CrashLoop })
+
+	time.Sleep(100 * time.Millisecond) // well past the backoff
+	info := inst.Info()
+	if info.Running || info.State != StateStopped || info.Restarts != 2 {
+		t.Errorf("running %v, state %s, %d restarts; want stopped after 2 restarts", info.Running, info.State, info.Restarts)
+	}
+	if started := startedServers(t, dir); started != 3 {
+		t.Errorf("%d processes started, want 3", started)
+	}
+}
+
+func TestRestartAfterStop(t *testing.T) {
+	m, inst, dir := startRestarting(t, RestartAlways, 0)
+	waitUntil(t, "the process to start", func() bool { return startedServers(t, dir) == 1 })
+	if err := m.Stop(inst.Port); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(100 * time.Millisecond) // well past the backoff
+	if started := startedServers(t, dir); started != 1 {
+		t.Errorf("%d processes started, want no restart after a stop", started)
+	}
+	if info := inst.Info(); info.State != StateStopped || info.Restarts != 0 || info.LastExit != nil {
+		t.Errorf("state %s, %d restarts, last exit %+v; want a stop rather than a crash", info.State, info.Restarts, info.LastExit)
+	}
+	if m.Get(inst.Port) != nil {
+		t.Error("stopped server still listed")
+	}
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Requests another efx-face process leaves next to the state files for the
+// owner of a server, read when the server's process exits
+const (
+	requestStop    = "stop"
+	requestRestart = "restart"
+)
+
+// requestPath returns the file requesting action for the server with pid
+func requestPath(dir string, pid int, action string) string {
+	return filepath.Join(dir, strconv.Itoa(pid)+"."+action)
+}
+
+// takeRequest returns and removes the request left for the server with pid,
+// or "" when there is none
+func (m *Manager) takeRequest(pid int) string {
+	m.mu.RLock()
+	stateFile := m.stateFile
+	m.mu.RUnlock()
+	if stateFile == "" {
+		return ""
+	}
+	for _, action := range []string{requestStop, requestRestart} {
+		if os.Remove(requestPath(filepath.Dir(stateFile), pid, action)) == nil {
+			return action
+		}
+	}
+	return ""
+}
+
+// removeStaleRequests removes the requests left for servers that exited
+// without an owner to read them
+func removeStaleRequests() {
+	for _, action := range []string{requestStop, requestRestart} {
+		files, _ := filepath.Glob(filepath.Join(StateDir(), "*."+action))
+		for _, file := range files {
+			pid, err := strconv.Atoi(strings.TrimSuffix(filepath.Base(file), "."+action))
+			if err == nil && !processAlive(pid) {
+				os.Remove(file)
+			}
+		}
+	}
+}
+
+// StopTracked stops a server run by another efx-face process the way Stop
+// does, and asks that process not to restart it
+func (m *Manager) StopTracked(srv TrackedServer) error {
+	return m.signalTracked(srv, requestStop)
+}
+
+// RestartTracked stops a server run by another efx-face process and has
+// that process start it again. It returns the record of the new process.
+func (m *Manager) RestartTracked(srv TrackedServer) (TrackedServer, error) {
+	if err := m.signalTracked(srv, requestRestart); err != nil {
+		return srv, err
+	}
+	deadline := time.Now().Add(outputDrainTimeout + killTimeout)
+	for time.Now().Before(deadline) {
+		for _, s := range TrackedServers() {
+			if s.Port == srv.Port && s.PID != srv.PID {
+				return s, nil
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return srv, fmt.Errorf("%s on port %d did not start again", srv.Model, srv.Port)
+}
+
+// signalTracked leaves a request for the owner of srv and terminates it
+func (m *Manager) signalTracked(srv TrackedServer, action string) error {
+	path := requestPath(StateDir(), srv.PID, action)
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return err
+	}
+	if err := m.terminate(srv.PID, watchExit(srv.PID)); err != nil {
+		os.Remove(path)
+		return err
+	}
+	return nil
+}
+
+// Info describes a tracked server like Instance.Info. Its state comes from
+// a single health probe and its resource use from a short sample, since no
+// checker or monitor watches it in this process.
+func (srv TrackedServer) Info(h *HealthChecker) InstanceInfo {
+	info := InstanceInfo{
+		Model:      srv.Model,
+		Type:       srv.Type,
+		Port:       srv.Port,
+		Host:       srv.Host,
+		Args:       srv.Args,
+		PID:        srv.PID,
+		StartedAt:  srv.StartedAt,
+		Running:    true,
+		State:      StateStarting,
+		StateSince: srv.StartedAt,
+	}
+	if err := h.Check(context.Background(), srv.Host, srv.Port); err != nil {
+		info.HealthError = err.Error()
+	} else {
+		info.State = StateReady
+	}
+
+	first, err := sampleProcessTree(srv.PID)
+	if err != nil {
+		return info
+	}
+	firstAt := time.Now()
+	time.Sleep(250 * time.Millisecond)
+	sample, err := sampleProcessTree(srv.PID)
+	if err != nil {
+		return info
+	}
+	at := time.Now()
+	info.Usage = ResourceUsage{
+		At:        at,
+		RSS:       sample.rss,
+		Threads:   sample.threads,
+		Processes: sample.processes,
+	}
+	if sample.cpu >= first.cpu {
+		info.Usage.CPU = float64(sample.cpu-first.cpu) / float64(at.Sub(firstAt)) * 100
+	}
+	return info
+}
@@ -1,11 +1,22 @@
 package tui
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lmarques/efx-face-manager/internal/api"
 	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/daemon"
+	"github.com/lmarques/efx-face-manager/internal/downloads"
+	"github.com/lmarques/efx-face-manager/internal/gateway"
 	"github.com/lmarques/efx-face-manager/internal/hf"
+	"github.com/lmarques/efx-face-manager/internal/installer"
+	"github.com/lmarques/efx-face-manager/internal/metrics"
 	"github.com/lmarques/efx-face-manager/internal/model"
 	"github.com/lmarques/efx-face-manager/internal/server"
 )
@@ -27,6 +38,9 @@ const (
 	viewNewServer
 	viewStorageConfig
 	viewUninstall
+	viewDownloads
+	viewOrphans
+	viewLogHistory
 )
 
 // Main application model
@@ -41,7 +55,9 @@ type appModel struct {
 	// Core services
 	cfg      *config.Config
 	store    *model.Store
-	servers  *server.Manager
+	servers   server.Backend
+	downloads downloads.Backend
+	attached  bool // servers are owned by a daemon and outlive the TUI
 
 	// Sub-models
 	menuModel          menuModel
@@ -55,21 +71,66 @@ type appModel struct {
 	uninstallModel     uninstallModel
 	detailsModel       detailsModel
 	serverNewModel     serverNewModel
+	downloadsModel     downloadsModel
+	orphansModel       orphansModel
+	logHistoryModel    logHistoryModel
 }
 
 // Initialize the main model
 func initialModel() appModel {
 	cfg, _ := config.Load()
 	store := model.NewStore(cfg.ModelDir)
-	servers := server.NewManager()
+	servers, queue, attached := newBackend(cfg)
 
-	return appModel{
+	menu := newMenuModel(cfg, store)
+	menu.attached = attached
+	menu.serverCount = servers.Count()
+
+	m := appModel{
 		state:     viewMenu,
 		history:   []viewState{},
 		cfg:       cfg,
 		store:     store,
 		servers:   servers,
-		menuModel: newMenuModel(cfg, store),
+		downloads: queue,
+		attached:  attached,
+		menuModel: menu,
+	}
+
+	// Offer to take over servers a crashed session left behind. A daemon
+	// adopts them itself when it starts.
+	if mgr, ok := servers.(*server.Manager); ok {
+		if orphans := mgr.FindOrphans(); len(orphans) > 0 {
+			m.state = viewOrphans
+			m.orphansModel = newOrphansModel(mgr, orphans)
+		}
+	}
+	return m
+}
+
+// newBackend attaches to a running daemon when one is listening, otherwise
+// servers and downloads are managed in-process and stop when the TUI exits
+func newBackend(cfg *config.Config) (server.Backend, downloads.Backend, bool) {
+	if client, err := daemon.Dial(daemon.SocketPath()); err == nil {
+		return client, client.Downloads(), true
+	}
+	inst := installer.New(hf.NewClient(), cfg.ModelDir)
+	queue := downloads.NewManager(inst, downloads.QueuePath(), cfg.DownloadParallelism)
+	mgr := server.NewManager()
+	mgr.SetGracePeriod(cfg.StopGracePeriod())
+	mgr.TrackState(server.StateDir())
+	mgr.Logs = server.NewLogStore(server.LogDir(), cfg)
+	return mgr, queue, false
+}
+
+// shutdown stops in-process servers and downloads before quitting. Work
+// owned by a daemon keeps running.
+func (m appModel) shutdown() {
+	if !m.attached {
+		m.servers.StopAll()
+	}
+	if queue, ok := m.downloads.(*downloads.Manager); ok {
+		queue.Close()
 	}
 }
 
@@ -100,7 +161,10 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		// Handle ESC globally for ALL views EXCEPT viewSearch (which handles its own ESC for search/filter)
-		if msg.String() == "esc" && m.state != viewMenu && m.state != viewSearch {
+		if msg.String() == "esc" && m.state != viewMenu && m.state != viewSearch &&
+			!(m.state == viewServerManager && m.serverManagerModel.capturesEsc()) &&
+			!(m.state == viewConfig && m.configPanelModel.capturesEsc()) &&
+			!(m.state == viewTemplates && m.templatesModel.confirmDelete) {
 			prevState, newHistory := popHistory(m.history)
 			m.history = newHistory
 			m.state = prevState
@@ -112,6 +176,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.menuModel.width = m.width
 				m.menuModel.height = m.height
 				m.menuModel.serverCount = m.servers.Count()
+				m.menuModel.attached = m.attached
 			case viewTemplates:
 				m.templatesModel = newTemplatesModel(m.cfg, m.store)
 				m.templatesModel.width = m.width
@@ -129,9 +194,14 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.modelTypeModel.width = m.width
 				m.modelTypeModel.height = m.height
 			case viewSearch:
-				m.searchModel = newSearchModel(m.cfg, m.store)
+				m.searchModel = newSearchModel(m.cfg, m.store, m.downloads)
 				m.searchModel.width = m.width
 				m.searchModel.height = m.height
+			case viewDownloads:
+				m.downloadsModel = newDownloadsModel(m.downloads)
+				m.downloadsModel.width = m.width
+				m.downloadsModel.height = m.height
+				return m, m.downloadsModel.Init()
 			case viewUninstall:
 				m.uninstallModel = newUninstallModel(m.cfg, m.store)
 				m.uninstallModel.width = m.width
@@ -148,16 +218,17 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		// Handle ctrl+c globally
 		if msg.String() == "ctrl+c" {
-			m.servers.StopAll()
+			m.shutdown()
 			return m, tea.Quit
 		}
 
 		// Handle 'q' key - skip for views with text input
-		if m.state != viewSearch && m.state != viewConfig && m.state != viewStorageConfig {
+		if m.state != viewSearch && m.state != viewConfig && m.state != viewStorageConfig &&
+			!(m.state == viewServerManager && m.serverManagerModel.searching) {
 			if msg.String() == "q" {
 				if m.state == viewMenu {
 					// On home page - quit application
-					m.servers.StopAll()
+					m.shutdown()
 					return m, tea.Quit
 				}
 				// On any other page - return to home page
@@ -167,6 +238,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.menuModel.width = m.width
 				m.menuModel.height = m.height
 				m.menuModel.serverCount = m.servers.Count()
+				m.menuModel.attached = m.attached
 				return m, nil
 			}
 		}
@@ -177,6 +249,8 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Update sub-models with new size
 		m.menuModel.width = msg.Width
 		m.menuModel.height = msg.Height
+		m.orphansModel.width = msg.Width
+		m.orphansModel.height = msg.Height
 
 	case serverUpdateMsg:
 		// Handle server updates
@@ -186,6 +260,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		// Update menu to show server count
 		m.menuModel.serverCount = m.servers.Count()
+		m.menuModel.attached = m.attached
 		return m, tea.Batch(cmds...)
 
 	case openTemplatesMsg:
@@ -216,10 +291,18 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.history = pushHistory(m.history, m.state)
 		m.state = viewConfig
 		m.configPanelModel = newConfigPanelModel(msg.config, m.cfg, m.servers)
+		m.configPanelModel.template = msg.template
+		m.configPanelModel.editing = msg.edit
 		m.configPanelModel.width = m.width
 		m.configPanelModel.height = m.height
 		return m, nil
 
+	case openLogHistoryMsg:
+		m.history = pushHistory(m.history, m.state)
+		m.state = viewLogHistory
+		m.logHistoryModel = newLogHistoryModel(m.cfg, msg.model, m.width, m.height)
+		return m, nil
+
 	case openServerManagerMsg:
 		m.history = pushHistory(m.history, m.state)
 		m.state = viewServerManager
@@ -251,6 +334,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.menuModel.width = m.width
 		m.menuModel.height = m.height
 		m.menuModel.serverCount = m.servers.Count()
+		m.menuModel.attached = m.attached
 		return m, nil
 
 	case goBackMsg:
@@ -261,16 +345,25 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.menuModel.width = m.width
 		m.menuModel.height = m.height
 		m.menuModel.serverCount = m.servers.Count()
+		m.menuModel.attached = m.attached
 		return m, nil
 
 	case openInstallMsg:
 		m.history = pushHistory(m.history, m.state)
 		m.state = viewSearch
-		m.searchModel = newSearchModel(m.cfg, m.store)
+		m.searchModel = newSearchModel(m.cfg, m.store, m.downloads)
 		m.searchModel.width = m.width
 		m.searchModel.height = m.height
 		return m, m.searchModel.Init()
 
+	case openDownloadsMsg:
+		m.history = pushHistory(m.history, m.state)
+		m.state = viewDownloads
+		m.downloadsModel = newDownloadsModel(m.downloads)
+		m.downloadsModel.width = m.width
+		m.downloadsModel.height = m.height
+		return m, m.downloadsModel.Init()
+
 	case openUninstallMsg:
 		m.history = pushHistory(m.history, m.state)
 		m.state = viewUninstall
@@ -282,7 +375,7 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case openDetailsMsg:
 		m.history = pushHistory(m.history, m.state)
 		m.state = viewDetails
-		m.detailsModel = newDetailsModel(m.cfg, m.store, msg.model)
+		m.detailsModel = newDetailsModel(m.cfg, m.store, m.downloads, msg.model)
 		m.detailsModel.width = m.width
 		m.detailsModel.height = m.height
 		return m, nil
@@ -335,6 +428,12 @@ func (m appModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.detailsModel, cmd = m.detailsModel.Update(msg)
 	case viewNewServer:
 		m.serverNewModel, cmd = m.serverNewModel.Update(msg)
+	case viewDownloads:
+		m.downloadsModel, cmd = m.downloadsModel.Update(msg)
+	case viewOrphans:
+		m.orphansModel, cmd = m.orphansModel.Update(msg)
+	case viewLogHistory:
+		m.logHistoryModel, cmd = m.logHistoryModel.Update(msg)
 	}
 	cmds = append(cmds, cmd)
 
@@ -365,6 +464,12 @@ func (m appModel) View() string {
 		return m.detailsModel.View()
 	case viewNewServer:
 		return m.serverNewModel.View()
+	case viewDownloads:
+		return m.downloadsModel.View()
+	case viewOrphans:
+		return m.orphansModel.View()
+	case viewLogHistory:
+		return m.logHistoryModel.View()
 	default:
 		return m.menuModel.View()
 	}
@@ -374,21 +479,26 @@ func (m appModel) View() string {
 type openTemplatesMsg struct{}
 type openModelsMsg struct{}
 type openModelTypeMsg struct{ model string }
-type openConfigPanelMsg struct{ config server.Config }
+type openConfigPanelMsg struct {
+	config   server.Config
+	template *model.Template // template the config comes from, if any
+	edit     bool            // edit the template rather than run it
+}
 type openServerManagerMsg struct{}
 type openStorageConfigMsg struct{}
 type openInstallMsg struct{}
 type openUninstallMsg struct{}
 type openNewServerMsg struct{}
+type openDownloadsMsg struct{}
 type serverStartedMsg struct{ port int }
 type configSavedMsg struct{ config *config.Config }
 type serverUpdateMsg server.Update
 type goBackMsg struct{} // Navigation back to menu
 
 // Command to listen for server updates
-func listenForServerUpdates(mgr *server.Manager) tea.Cmd {
+func listenForServerUpdates(mgr server.Backend) tea.Cmd {
 	return func() tea.Msg {
-		update := <-mgr.Updates
+		update := <-mgr.UpdateChan()
 		return serverUpdateMsg(update)
 	}
 }
@@ -402,17 +512,17 @@ func Run() error {
 	return err
 }
 
-// RunModel runs a specific model
-func RunModel(modelName string) error {
-	// TODO: Implement direct model run
-	return Run()
-}
-
 // RunList lists installed models
-func RunList() error {
+func RunList(format OutputFormat) error {
 	cfg, _ := config.Load()
 	store := model.NewStore(cfg.ModelDir)
-	models, _ := store.List()
+	models, err := store.List()
+	if err != nil {
+		return fmt.Errorf("failed to list models in %s: %w", config.DisplayPath(cfg.ModelDir), err)
+	}
+	if format.structured() {
+		return printOutput(format, api.NewModels(models))
+	}
 
 	println("Installed Models")
 	println("================")
@@ -429,12 +539,15 @@ func RunList() error {
 }
 
 // RunSearch searches HuggingFace models (CLI mode)
-func RunSearch(query string) error {
+func RunSearch(query string, format OutputFormat) error {
+	if query == "" && format.structured() {
+		return fmt.Errorf("a search query is required with --output %s", format)
+	}
 	if query == "" {
 		// No query = open TUI search
 		m := initialModel()
 		m.state = viewSearch
-		m.searchModel = newSearchModel(m.cfg, m.store)
+		m.searchModel = newSearchModel(m.cfg, m.store, m.downloads)
 		p := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
 		_, err := p.Run()
 		return err
@@ -446,6 +559,9 @@ func RunSearch(query string) error {
 	if err != nil {
 		return err
 	}
+	if format.structured() {
+		return printOutput(format, api.NewSearchResults(results))
+	}
 
 	println()
 	println("HuggingFace MLX Models")
@@ -463,8 +579,13 @@ func RunSearch(query string) error {
 	return nil
 }
 
-// RunServerManager opens server manager
-func RunServerManager() error {
+// RunServerManager opens server manager, or prints the running servers
+// with a structured output format
+func RunServerManager(format OutputFormat) error {
+	if format.structured() {
+		return printOutput(format, cliInstances())
+	}
+
 	m := initialModel()
 	m.state = viewServerManager
 	m.serverManagerModel = newServerManagerModel(m.servers, 80, 24)
@@ -474,8 +595,17 @@ func RunServerManager() error {
 	return err
 }
 
-// RunConfig opens config view
-func RunConfig() error {
+// RunConfig opens config view, or prints the configuration with a
+// structured output format
+func RunConfig(format OutputFormat) error {
+	if format.structured() {
+		cfg, err := config.Load()
+		if err != nil {
+			return err
+		}
+		return printOutput(format, cfg)
+	}
+
 	m := initialModel()
 	m.state = viewStorageConfig
 	m.storageModel = newStorageModel(m.cfg)
@@ -486,36 +616,267 @@ func RunConfig() error {
 }
 
 // RunInstall installs a model from HuggingFace (CLI mode)
-func RunInstall(repoID string) error {
+func RunInstall(repoID string, format OutputFormat) error {
 	cfg, _ := config.Load()
+	inst := installer.New(hf.NewClient(), cfg.ModelDir)
+	if format.structured() {
+		result, err := inst.Install(context.Background(), repoID, nil)
+		if err != nil {
+			return err
+		}
+		return printOutput(format, api.InstallResult{
+			RepoID:   result.RepoID,
+			Name:     result.Name,
+			Path:     result.LinkPath,
+			Snapshot: result.SnapshotPath,
+		})
+	}
 	
 	fmt.Println()
 	fmt.Println("Installing model:", repoID)
 	fmt.Println("Target:", config.DisplayPath(cfg.ModelDir))
 	fmt.Println()
 
-	// Use huggingface-cli to download
-	client := hf.NewClient()
-	
 	fmt.Println("Downloading from HuggingFace...")
-	err := client.Download(repoID, cfg.ModelDir)
+	result, err := inst.Install(context.Background(), repoID, printProgress)
+	fmt.Println()
 	if err != nil {
-		return fmt.Errorf("download failed: %w", err)
+		return err
 	}
 
-	fmt.Println("Successfully installed:", repoID)
+	fmt.Println("Successfully installed:", result.Name)
+	fmt.Println("Path:", config.DisplayPath(result.LinkPath))
 	return nil
 }
 
+// RunQueueInstall adds a model to the download queue (CLI mode)
+func RunQueueInstall(repoID string, format OutputFormat) error {
+	queue, attached := cliQueue()
+	job, err := queue.Add(repoID)
+	if err != nil {
+		return err
+	}
+	if format.structured() {
+		return printOutput(format, job)
+	}
+
+	fmt.Printf("Queued %s as job #%d\n", repoID, job.ID)
+	switch {
+	case attached:
+	case downloads.QueueOwned(downloads.QueuePath()):
+		fmt.Println("The running efx-face session picks up the download")
+	default:
+		fmt.Println("No daemon running: the download starts with the next `efx-face` or `efx-face daemon start`")
+	}
+	return nil
+}
+
+// RunDownloads prints the download queue (CLI mode)
+func RunDownloads(format OutputFormat) error {
+	queue, _ := cliQueue()
+	jobs := queue.List()
+	if format.structured() {
+		return printOutput(format, jobs)
+	}
+	if len(jobs) == 0 {
+		fmt.Println("No downloads")
+		return nil
+	}
+
+	for _, job := range jobs {
+		fmt.Printf("  #%-4d %-45s %-9s %s\n", job.ID, job.RepoID, job.Status, renderJobProgress(job))
+		if job.Error != "" {
+			fmt.Printf("        %s\n", job.Error)
+		}
+	}
+	return nil
+}
+
+// RunDownloadAction cancels, pauses or resumes a queued download (CLI mode)
+func RunDownloadAction(action string, id int) error {
+	queue, _ := cliQueue()
+	switch action {
+	case "cancel":
+		return queue.Cancel(id)
+	case "pause":
+		return queue.Pause(id)
+	case "resume":
+		return queue.Resume(id)
+	case "clear":
+		return queue.Clear()
+	}
+	return fmt.Errorf("unknown action: %s", action)
+}
+
+// GatewayOptions configures RunGateway
+type GatewayOptions struct {
+	Host        string
+	Port        int
+	OnDemand    bool
+	IdleTimeout time.Duration
+	MaxModels   int
+	MaxMemoryGB float64
+	Metrics     bool // serve /metrics on the gateway port
+}
+
+// RunGateway serves the OpenAI gateway in the foreground (CLI mode). It
+// routes to the servers hosted by the daemon; with on-demand loading and no
+// daemon, it hosts the servers it starts itself.
+func RunGateway(opts GatewayOptions) error {
+	addr := net.JoinHostPort(opts.Host, strconv.Itoa(opts.Port))
+
+	cfg, _ := config.Load()
+	var servers server.Backend
+	var collectors []metrics.Collector
+	if client, err := daemon.Dial(daemon.SocketPath()); err == nil {
+		servers = client
+	} else if opts.OnDemand {
+		mgr := server.NewManager()
+		mgr.SetGracePeriod(cfg.StopGracePeriod())
+		mgr.TrackState(server.StateDir())
+		mgr.Logs = server.NewLogStore(server.LogDir(), cfg)
+		go drainUpdates(mgr.Updates)
+		defer mgr.StopAll()
+		servers = mgr
+		// Nobody else exports the servers this process owns
+		collectors = append(collectors, mgr)
+	} else {
+		return fmt.Errorf("the gateway routes to daemon servers, start it with `efx-face daemon start` or use --on-demand")
+	}
+
+	var loader *gateway.Loader
+	if opts.OnDemand {
+		templates, err := model.LoadTemplates()
+		if err != nil {
+			return fmt.Errorf("failed to load templates: %w", err)
+		}
+		loader = gateway.NewLoader(servers, gateway.LoaderOptions{
+			ModelDir:    cfg.ModelDir,
+			Templates:   templates,
+			IdleTimeout: opts.IdleTimeout,
+			MaxModels:   opts.MaxModels,
+			MaxMemory:   int64(opts.MaxMemoryGB * (1 << 30)),
+			BasePort:    cfg.DefaultPort,
+		})
+	}
+
+	gw := gateway.New(servers, loader)
+	if opts.Metrics {
+		gw.EnableMetrics(collectors...)
+	}
+	return gateway.Run(addr, gw)
+}
+
+// APIOptions configures RunAPI
+type APIOptions struct {
+	Listen string // TCP address, used when Socket is empty
+	Socket string // Unix socket path
+	Token  string // bearer token clients must send, if set
+}
+
+// RunAPI serves the control API in the foreground. It manages the daemon's
+// servers and downloads when the daemon is running, otherwise its own,
+// which stop when it exits.
+func RunAPI(opts APIOptions) error {
+	cfg, _ := config.Load()
+
+	var listener net.Listener
+	var err error
+	if opts.Socket != "" {
+		if conn, dialErr := net.Dial("unix", opts.Socket); dialErr == nil {
+			conn.Close()
+			return fmt.Errorf("%s is already in use", opts.Socket)
+		}
+		// Remove a stale socket left behind by a process that did not exit cleanly
+		os.Remove(opts.Socket)
+		if listener, err = net.Listen("unix", opts.Socket); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", opts.Socket, err)
+		}
+		defer os.Remove(opts.Socket)
+		os.Chmod(opts.Socket, 0600)
+	} else {
+		host, _, err := net.SplitHostPort(opts.Listen)
+		if err != nil {
+			return fmt.Errorf("invalid listen address %q: %w", opts.Listen, err)
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) && opts.Token == "" {
+			return fmt.Errorf("refusing to serve the API on %s without a token, set --token or EFX_API_TOKEN", opts.Listen)
+		}
+		if listener, err = net.Listen("tcp", opts.Listen); err != nil {
+			return fmt.Errorf("failed to listen on %s: %w", opts.Listen, err)
+		}
+	}
+
+	servers, queue, attached := newBackend(cfg)
+	if !attached {
+		defer servers.StopAll()
+		if manager, ok := queue.(*downloads.Manager); ok {
+			defer manager.Close()
+		}
+	}
+
+	apiOpts := api.Options{
+		ModelDir:    cfg.ModelDir,
+		DefaultHost: cfg.DefaultHost,
+		DefaultPort: cfg.DefaultPort,
+		Token:       opts.Token,
+	}
+	if opts.Socket == "" {
+		apiOpts.Addr = listener.Addr().String()
+	}
+	srv := api.NewServer(servers, queue, apiOpts)
+	if opts.Socket != "" {
+		fmt.Printf("efx-face API listening on %s\n", opts.Socket)
+	} else {
+		fmt.Printf("efx-face API listening on http://%s/api\n", listener.Addr())
+	}
+	return api.Run(listener, srv)
+}
+
+// drainUpdates discards server updates nobody is displaying so the
+// manager never blocks on a full channel
+func drainUpdates(updates <-chan server.Update) {
+	for range updates {
+	}
+}
+
+// cliQueue returns the daemon's download queue when one is running, otherwise
+// the persisted queue file, which a running TUI picks changes up from
+func cliQueue() (downloads.Backend, bool) {
+	if client, err := daemon.Dial(daemon.SocketPath()); err == nil {
+		return client.Downloads(), true
+	}
+	return downloads.NewFileQueue(downloads.QueuePath()), false
+}
+
+// printProgress renders download progress on a single terminal line
+func printProgress(p hf.Progress) {
+	percent := 0.0
+	if p.TotalBytes > 0 {
+		percent = float64(p.Bytes) / float64(p.TotalBytes) * 100
+	}
+	fmt.Printf("\r\033[K  [%d/%d] %s  %s / %s (%.0f%%)",
+		min(p.FilesDone+1, p.FilesTotal), p.FilesTotal, truncateStr(p.File, 40),
+		hf.FormatBytes(p.Bytes), hf.FormatBytes(p.TotalBytes), percent)
+}
+
 // RunUninstall removes a model (CLI mode)
-func RunUninstall(modelName string) error {
+func RunUninstall(modelName string, format OutputFormat) error {
 	cfg, _ := config.Load()
 	store := model.NewStore(cfg.ModelDir)
 
 	// Check if model exists
-	if !store.Exists(modelName) {
+	m, err := store.Get(modelName)
+	if err != nil {
 		return fmt.Errorf("model not found: %s", modelName)
 	}
+	if format.structured() {
+		removed := api.NewModel(*m)
+		if err := store.RemoveWithCache(modelName); err != nil {
+			return fmt.Errorf("uninstall failed: %w", err)
+		}
+		return printOutput(format, removed)
+	}
 
 	fmt.Println()
 	fmt.Println("Uninstalling model:", modelName)
@@ -523,7 +884,7 @@ func RunUninstall(modelName string) error {
 	fmt.Println()
 
 	// Remove with cache
-	err := store.RemoveWithCache(modelName)
+	err = store.RemoveWithCache(modelName)
 	if err != nil {
 		return fmt.Errorf("uninstall failed: %w", err)
 	}
@@ -2,6 +2,7 @@ package tui
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -24,14 +25,22 @@ const (
 	actionRun = iota
 	actionPort
 	actionTrustToggle
+	actionSave
 	actionCancel
 )
 
+// Steps of the save as template prompt
+const (
+	promptNone = iota
+	promptName
+	promptDescription
+)
+
 // configPanelModel handles the configuration view
 type configPanelModel struct {
 	config       server.Config
 	cfg          *config.Config
-	servers      *server.Manager
+	servers      server.Backend
 	width        int
 	height       int
 	focusedPanel int
@@ -49,6 +58,19 @@ type configPanelModel struct {
 	showSetup       bool
 	editingValue    bool
 	editBuffer      string
+
+	portWarning string // why the configured port cannot be used
+	startErr    string
+
+	// Save as template
+	template    *model.Template // template the panel was opened from, if any
+	editing     bool            // saving replaces template, even under a new name
+	savePrompt  int
+	saveName    string
+	saveBuffer  string
+	saveConfirm bool // the name belongs to another template, Enter again replaces it
+	saveStatus  string
+	saveErr     string
 }
 
 type configOption struct {
@@ -59,7 +81,7 @@ type configOption struct {
 	isToggle bool
 }
 
-func newConfigPanelModel(cfg server.Config, appCfg *config.Config, servers *server.Manager) configPanelModel {
+func newConfigPanelModel(cfg server.Config, appCfg *config.Config, servers server.Backend) configPanelModel {
 	m := configPanelModel{
 		config:       cfg,
 		cfg:          appCfg,
@@ -67,9 +89,40 @@ func newConfigPanelModel(cfg server.Config, appCfg *config.Config, servers *serv
 		focusedPanel: panelActionBar,
 	}
 	m.buildOptions()
+	m.checkPort()
 	return m
 }
 
+// checkPort records a warning when the configured port is taken, naming the
+// process holding it and the port the server will use instead
+func (m *configPanelModel) checkPort() {
+	m.portWarning = ""
+	err := m.servers.CheckPort(m.config.Host, m.config.Port)
+	if err == nil {
+		return
+	}
+	next := m.servers.NextAvailablePort(m.config.Port)
+	m.portWarning = fmt.Sprintf("⚠ %v - will run on port %d", err, next)
+}
+
+// start launches the server, moving to the next free port if the configured
+// one is taken
+func (m *configPanelModel) start() (configPanelModel, tea.Cmd) {
+	if m.servers.IsPortInUse(m.config.Port) {
+		m.config.Port = m.servers.NextAvailablePort(m.config.Port)
+	}
+	_, err := m.servers.Start(m.config)
+	if err != nil {
+		m.startErr = err.Error()
+		m.checkPort()
+		return *m, nil
+	}
+	port := m.config.Port
+	return *m, func() tea.Msg {
+		return serverStartedMsg{port: port}
+	}
+}
+
 func (m *configPanelModel) buildOptions() {
 	m.options = []configOption{}
 	
@@ -79,11 +132,11 @@ func (m *configPanelModel) buildOptions() {
 			configOption{key: "context_length", label: "Context length", value: formatInt(m.config.ContextLength)},
 			configOption{key: "auto_tool_choice", label: "Auto tool choice", value: "enabled", isToggle: true},
 			configOption{key: "tool_call_parser", label: "Tool call parser", value: formatStr(m.config.ToolCallParser), 
-				choices: []string{"qwen3", "glm4_moe", "qwen3_coder", "qwen3_moe", "qwen3_next", "qwen3_vl", "harmony", "minimax_m2", "(clear)"}},
+				choices: withClear(model.ToolCallParsers)},
 			configOption{key: "reasoning_parser", label: "Reasoning parser", value: formatStr(m.config.ReasoningParser),
-				choices: []string{"qwen3", "glm4_moe", "qwen3_coder", "qwen3_moe", "qwen3_next", "qwen3_vl", "harmony", "minimax_m2", "glm47_flash", "(clear)"}},
+				choices: withClear(model.ReasoningParsers)},
 			configOption{key: "message_converter", label: "Message converter", value: formatStr(m.config.MessageConverter),
-				choices: []string{"glm4_moe", "minimax_m2", "nemotron3_nano", "qwen3_coder", "(clear)"}},
+				choices: withClear(model.MessageConverters)},
 			configOption{key: "chat_template_file", label: "Chat template file", value: formatStr(m.config.ChatTemplateFile)},
 			configOption{key: "debug", label: "Debug mode", value: formatBool(m.config.Debug), isToggle: true},
 			configOption{key: "trust_remote_code", label: "Trust remote code", value: formatBool(m.config.TrustRemoteCode), isToggle: true},
@@ -135,6 +188,8 @@ func (m *configPanelModel) buildOptions() {
 		configOption{key: "host", label: "Host", value: m.config.Host},
 		configOption{key: "log_level", label: "Log level", value: formatStr(m.config.LogLevel),
 			choices: []string{"DEBUG", "INFO", "WARNING", "ERROR", "CRITICAL", "(clear)"}},
+		configOption{key: "restart", label: "Restart policy", value: formatStr(string(m.config.Restart)),
+			choices: []string{string(server.RestartNever), string(server.RestartOnFailure), string(server.RestartAlways)}},
 	)
 	
 	// Done and Cancel removed - actions are in action bar only
@@ -147,6 +202,9 @@ func (m configPanelModel) Init() tea.Cmd {
 func (m configPanelModel) Update(msg tea.Msg) (configPanelModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.savePrompt != promptNone {
+			return m.updateSavePrompt(msg), nil
+		}
 		switch msg.String() {
 		case "left", "h":
 			if m.editingValue {
@@ -248,7 +306,7 @@ func (m configPanelModel) Update(msg tea.Msg) (configPanelModel, tea.Cmd) {
 			}
 		case "backspace":
 			if m.editingValue && len(m.editBuffer) > 0 {
-				m.editBuffer = m.editBuffer[:len(m.editBuffer)-1]
+				m.editBuffer = dropLastRune(m.editBuffer)
 			}
 		default:
 			if m.editingValue && len(msg.String()) == 1 {
@@ -265,17 +323,7 @@ func (m *configPanelModel) handleEnter() (configPanelModel, tea.Cmd) {
 		switch m.controlSelected {
 		case actionRun:
 			// Run button just runs the server with current port
-			// Auto-increment port if in use
-			if m.servers.IsPortInUse(m.config.Port) {
-				m.config.Port = m.servers.NextAvailablePort(m.config.Port)
-			}
-			_, err := m.servers.Start(m.config)
-			if err != nil {
-				return *m, nil
-			}
-			return *m, func() tea.Msg {
-				return serverStartedMsg{port: m.config.Port}
-			}
+			return m.start()
 		case actionPort:
 			// Port field - toggle editing mode
 			if !m.editingValue {
@@ -288,6 +336,7 @@ func (m *configPanelModel) handleEnter() (configPanelModel, tea.Cmd) {
 					m.config.Port = port
 					m.editingValue = false
 					m.editBuffer = ""
+					m.checkPort()
 				} else {
 					// Invalid port, keep editing
 					return *m, nil
@@ -296,6 +345,14 @@ func (m *configPanelModel) handleEnter() (configPanelModel, tea.Cmd) {
 		case actionTrustToggle:
 			m.config.TrustRemoteCode = !m.config.TrustRemoteCode
 			m.buildOptions()
+		case actionSave:
+			m.savePrompt = promptName
+			m.saveBuffer = m.config.Model
+			if m.template != nil {
+				m.saveBuffer = m.template.Name
+			}
+			m.saveConfirm = false
+			m.saveStatus, m.saveErr = "", ""
 		case actionCancel:
 			return *m, func() tea.Msg { return goBackMsg{} }
 		}
@@ -304,13 +361,7 @@ func (m *configPanelModel) handleEnter() (configPanelModel, tea.Cmd) {
 	case panelOptions:
 		opt := m.options[m.optionSelected]
 		if opt.key == "done" {
-			_, err := m.servers.Start(m.config)
-			if err != nil {
-				return *m, nil
-			}
-			return *m, func() tea.Msg {
-				return serverStartedMsg{port: m.config.Port}
-			}
+			return m.start()
 		} else if opt.key == "cancel" {
 			return *m, func() tea.Msg { return goBackMsg{} }
 		} else if opt.isToggle {
@@ -348,6 +399,127 @@ func (m *configPanelModel) handleEnter() (configPanelModel, tea.Cmd) {
 	return *m, nil
 }
 
+// capturesEsc reports whether esc cancels a text input rather than leaving
+// the view
+func (m configPanelModel) capturesEsc() bool {
+	return m.editingValue || m.savePrompt != promptNone
+}
+
+// updateSavePrompt handles keys while asking for the name and description
+// of the template to save
+func (m configPanelModel) updateSavePrompt(msg tea.KeyMsg) configPanelModel {
+	switch msg.String() {
+	case "esc":
+		m.savePrompt = promptNone
+		m.saveBuffer = ""
+	case "enter":
+		if m.savePrompt == promptName {
+			name := strings.TrimSpace(m.saveBuffer)
+			if name == "" {
+				return m
+			}
+			if !m.saveConfirm && m.nameTaken(name) {
+				m.saveConfirm = true
+				return m
+			}
+			m.saveName = name
+			m.savePrompt = promptDescription
+			m.saveBuffer = ""
+			if m.template != nil {
+				m.saveBuffer = m.template.Description
+			}
+			return m
+		}
+		m.savePrompt = promptNone
+		if err := m.saveTemplate(m.saveName, strings.TrimSpace(m.saveBuffer)); err != nil {
+			m.saveErr = err.Error()
+		} else {
+			m.saveStatus = fmt.Sprintf("✓ Saved template %s to %s", m.saveName, model.TemplatesPath())
+		}
+		m.saveBuffer = ""
+	case "backspace":
+		if len(m.saveBuffer) > 0 {
+			m.saveBuffer = dropLastRune(m.saveBuffer)
+		}
+		m.saveConfirm = false
+	default:
+		if len(msg.Runes) > 0 {
+			m.saveBuffer += string(msg.Runes)
+			m.saveConfirm = false
+		}
+	}
+	return m
+}
+
+// nameTaken reports whether saving under name would replace a template
+// other than the one the panel was opened from
+func (m configPanelModel) nameTaken(name string) bool {
+	if m.template != nil && m.template.Name == name {
+		return false
+	}
+	templates, _ := model.LoadTemplates()
+	for _, t := range templates {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// saveTemplate writes the current configuration to templates.yaml. It
+// replaces the template of the same name, or the edited template.
+func (m *configPanelModel) saveTemplate(name, description string) error {
+	templates, err := model.LoadTemplates()
+	if err != nil {
+		return err
+	}
+	t := m.config.ToTemplate(name, description)
+
+	replace := name
+	if m.editing && m.template != nil {
+		replace = m.template.Name
+		t.Extends = m.template.Extends
+	}
+	saved := false
+	result := make([]model.Template, 0, len(templates)+1)
+	for _, existing := range templates {
+		switch {
+		case existing.Name == replace && !saved:
+			result = append(result, t)
+			saved = true
+		case existing.Name == name:
+			// Replaced after confirmation
+		default:
+			result = append(result, existing)
+		}
+	}
+	if !saved {
+		result = append(result, t)
+	}
+	if err := model.SaveTemplates(result); err != nil {
+		return err
+	}
+
+	m.template = &t
+	return nil
+}
+
+// renderSavePrompt renders the name or description input of the save as
+// template prompt
+func (m configPanelModel) renderSavePrompt() string {
+	label := "Template name: "
+	hint := "  Enter to continue, Esc to cancel"
+	if m.savePrompt == promptDescription {
+		label = "Description: "
+		hint = "  Enter to save, Esc to cancel"
+	}
+	line := optionSelectedStyle.Render(label+m.saveBuffer+"█") + infoLineStyle.Render(hint)
+	if m.saveConfirm {
+		line += "\n" + warningStyle.Render(fmt.Sprintf("⚠ A template named %s exists - press Enter again to replace it", strings.TrimSpace(m.saveBuffer)))
+	}
+	return line
+}
+
 func (m *configPanelModel) updateSetupPanel() {
 	if m.optionSelected < len(m.options) {
 		opt := m.options[m.optionSelected]
@@ -385,6 +557,8 @@ func (m *configPanelModel) applyChoice(key, choice string) {
 		fmt.Sscanf(choice, "%d", &m.config.Quantize)
 	case "log_level":
 		m.config.LogLevel = choice
+	case "restart":
+		m.config.Restart = server.RestartPolicy(choice)
 	}
 }
 
@@ -394,8 +568,10 @@ func (m *configPanelModel) applyTextValue(key, value string) {
 		fmt.Sscanf(value, "%d", &m.config.ContextLength)
 	case "port":
 		fmt.Sscanf(value, "%d", &m.config.Port)
+		m.checkPort()
 	case "host":
 		m.config.Host = value
+		m.checkPort()
 	case "chat_template_file":
 		m.config.ChatTemplateFile = value
 	case "lora_paths":
@@ -445,7 +621,19 @@ func (m configPanelModel) View() string {
 		actionBoxStyle = panelFocusedStyle.Width(actionBarWidth)
 	}
 	b.WriteString(actionBoxStyle.Render(actionBar))
-	b.WriteString("\n\n")
+	b.WriteString("\n")
+	if m.savePrompt != promptNone {
+		b.WriteString(m.renderSavePrompt())
+	} else if m.saveErr != "" {
+		b.WriteString(errorStyle.Render("Failed to save template: " + m.saveErr))
+	} else if m.saveStatus != "" {
+		b.WriteString(successStyle.Render(m.saveStatus))
+	} else if m.startErr != "" {
+		b.WriteString(errorStyle.Render("Failed to start: " + m.startErr))
+	} else if m.portWarning != "" {
+		b.WriteString(warningStyle.Render(m.portWarning))
+	}
+	b.WriteString("\n")
 
 	optionsContent := m.renderOptionsPanel(optionsWidth)
 	setupContent := m.renderSetupPanel()
@@ -535,7 +723,12 @@ func (m configPanelModel) renderActionBar() string {
 		trustLabel += "off"
 	}
 	
-	controls := []string{runLabel, portLabel, trustLabel, "✖ Cancel"}
+	saveLabel := "💾 Save as template"
+	if m.editing {
+		saveLabel = "💾 Save template"
+	}
+
+	controls := []string{runLabel, portLabel, trustLabel, saveLabel, "✖ Cancel"}
 	
 	var parts []string
 	for i, ctrl := range controls {
@@ -622,6 +815,11 @@ func formatInt(v int) string {
 	return fmt.Sprintf("%d", v)
 }
 
+// withClear returns choices followed by the option that clears a value
+func withClear(choices []string) []string {
+	return append(slices.Clone(choices), "(clear)")
+}
+
 func formatStr(v string) string {
 	if v == "" {
 		return "(not set)"
@@ -635,3 +833,9 @@ func formatBool(v bool) string {
 	}
 	return "disabled"
 }
+
+// dropLastRune removes the last character of s, which may be several bytes
+func dropLastRune(s string) string {
+	r := []rune(s)
+	return string(r[:len(r)-1])
+}
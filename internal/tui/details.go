@@ -8,7 +8,9 @@ import (
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/downloads"
 	"github.com/lmarques/efx-face-manager/internal/hf"
+	"github.com/lmarques/efx-face-manager/internal/installer"
 	"github.com/lmarques/efx-face-manager/internal/model"
 )
 
@@ -17,26 +19,27 @@ type detailsModel struct {
 	cfg        *config.Config
 	store      *model.Store
 	hfClient   *hf.Client
+	queue      downloads.Backend
 	model      hf.Model
 	width      int
 	height     int
 	selected   int // 0=Cancel, 1=Open Browser, 2=Install
 	installing bool
 	installed  bool
+	queued     bool
 	err        error
 	message    string
 }
 
-func newDetailsModel(cfg *config.Config, store *model.Store, hfModel hf.Model) detailsModel {
+func newDetailsModel(cfg *config.Config, store *model.Store, queue downloads.Backend, hfModel hf.Model) detailsModel {
 	// Check if already installed
-	parts := strings.Split(hfModel.ID, "/")
-	modelName := parts[len(parts)-1]
-	installed := store.Exists(modelName)
+	installed := store.Exists(installer.ModelName(hfModel.ID))
 
 	return detailsModel{
 		cfg:       cfg,
 		store:     store,
 		hfClient:  hf.NewClient(),
+		queue:     queue,
 		model:     hfModel,
 		selected:  2, // Default to Install
 		installed: installed,
@@ -49,16 +52,14 @@ func (m detailsModel) Init() tea.Cmd {
 
 func (m detailsModel) Update(msg tea.Msg) (detailsModel, tea.Cmd) {
 	switch msg := msg.(type) {
-	case installCompleteMsg:
+	case installQueuedMsg:
 		m.installing = false
 		if msg.err != nil {
 			m.err = msg.err
 			m.message = fmt.Sprintf("Install failed: %v", msg.err)
 		} else {
-			m.installed = true
-			m.message = "Successfully installed!"
-			// Refresh store
-			m.store = model.NewStore(m.cfg.ModelDir)
+			m.queued = true
+			m.message = fmt.Sprintf("Queued for download (job #%d) - see Downloads", msg.jobID)
 		}
 
 	case tea.KeyMsg:
@@ -87,16 +88,16 @@ func (m detailsModel) Update(msg tea.Msg) (detailsModel, tea.Cmd) {
 				url := fmt.Sprintf("https://huggingface.co/%s", m.model.ID)
 				exec.Command("open", url).Start()
 			case 2: // Install
-				if !m.installed {
+				if !m.installed && !m.queued {
 					m.installing = true
-					m.message = "Installing..."
+					m.message = "Queueing..."
 					return m, m.performInstall()
 				}
 			}
 		case "i":
-			if !m.installed && !m.installing {
+			if !m.installed && !m.installing && !m.queued {
 				m.installing = true
-				m.message = "Installing..."
+				m.message = "Queueing..."
 				return m, m.performInstall()
 			}
 		case "o":
@@ -110,50 +111,7 @@ func (m detailsModel) Update(msg tea.Msg) (detailsModel, tea.Cmd) {
 }
 
 func (m detailsModel) performInstall() tea.Cmd {
-	return func() tea.Msg {
-		modelID := m.model.ID
-		parts := strings.Split(modelID, "/")
-		modelName := parts[len(parts)-1]
-
-		// Download using huggingface-cli
-		cmd := exec.Command("huggingface-cli", "download", modelID)
-		
-		// Use cache directory
-		cacheDir := m.cfg.ModelDir + "/cache"
-		cmd.Env = append(cmd.Environ(), fmt.Sprintf("HF_HOME=%s", cacheDir))
-
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			return installCompleteMsg{modelID: modelID, err: fmt.Errorf("%v: %s", err, string(output))}
-		}
-
-		// Find snapshot and create symlink
-		orgName := strings.ReplaceAll(modelID, "/", "--")
-		modelCacheDir := cacheDir + "/models--" + orgName + "/snapshots"
-
-		entries, err := exec.Command("ls", modelCacheDir).Output()
-		if err != nil {
-			return installCompleteMsg{modelID: modelID, err: fmt.Errorf("could not find downloaded model")}
-		}
-
-		snapshots := strings.Fields(string(entries))
-		if len(snapshots) == 0 {
-			return installCompleteMsg{modelID: modelID, err: fmt.Errorf("no snapshots found")}
-		}
-
-		snapshotPath := modelCacheDir + "/" + snapshots[0]
-		symlinkPath := m.cfg.ModelDir + "/" + modelName
-
-		// Remove existing symlink
-		exec.Command("rm", "-f", symlinkPath).Run()
-
-		// Create symlink
-		if err := exec.Command("ln", "-s", snapshotPath, symlinkPath).Run(); err != nil {
-			return installCompleteMsg{modelID: modelID, err: fmt.Errorf("failed to create symlink: %v", err)}
-		}
-
-		return installCompleteMsg{modelID: modelID, err: nil}
-	}
+	return queueInstall(m.queue, m.model.ID)
 }
 
 func (m detailsModel) View() string {
@@ -248,7 +206,9 @@ func (m detailsModel) renderButtons() string {
 	if m.installed {
 		install = buttonDisabledStyle.Render("[ Installed ]")
 	} else if m.installing {
-		install = buttonDisabledStyle.Render("[ Installing... ]")
+		install = buttonDisabledStyle.Render("[ Queueing... ]")
+	} else if m.queued {
+		install = buttonDisabledStyle.Render("[ Queued ]")
 	} else {
 		install = installStyle.Render("[ Install ]")
 	}
@@ -0,0 +1,189 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lmarques/efx-face-manager/internal/downloads"
+	"github.com/lmarques/efx-face-manager/internal/hf"
+)
+
+// downloadsModel shows the download queue with live progress
+type downloadsModel struct {
+	queue    downloads.Backend
+	jobs     []downloads.Job
+	selected int
+	width    int
+	height   int
+	err      error
+}
+
+// downloadsTickMsg refreshes the job list while the view is open
+type downloadsTickMsg struct{}
+
+func newDownloadsModel(queue downloads.Backend) downloadsModel {
+	return downloadsModel{
+		queue: queue,
+		jobs:  queue.List(),
+	}
+}
+
+func (m downloadsModel) Init() tea.Cmd {
+	return downloadsTick()
+}
+
+func downloadsTick() tea.Cmd {
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return downloadsTickMsg{} })
+}
+
+func (m downloadsModel) Update(msg tea.Msg) (downloadsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case downloadsTickMsg:
+		m.refresh()
+		return m, downloadsTick()
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "down", "j":
+			if m.selected < len(m.jobs)-1 {
+				m.selected++
+			}
+		case "p":
+			if job, ok := m.selectedJob(); ok {
+				m.err = m.queue.Pause(job.ID)
+			}
+		case "r":
+			if job, ok := m.selectedJob(); ok {
+				m.err = m.queue.Resume(job.ID)
+			}
+		case "x":
+			if job, ok := m.selectedJob(); ok {
+				m.err = m.queue.Cancel(job.ID)
+			}
+		case "c":
+			m.err = m.queue.Clear()
+		case "i":
+			return m, func() tea.Msg { return openInstallMsg{} }
+		}
+		m.refresh()
+	}
+	return m, nil
+}
+
+func (m *downloadsModel) refresh() {
+	m.jobs = m.queue.List()
+	if m.selected >= len(m.jobs) {
+		m.selected = len(m.jobs) - 1
+	}
+	if m.selected < 0 {
+		m.selected = 0
+	}
+}
+
+func (m downloadsModel) selectedJob() (downloads.Job, bool) {
+	if m.selected < len(m.jobs) {
+		return m.jobs[m.selected], true
+	}
+	return downloads.Job{}, false
+}
+
+func (m downloadsModel) View() string {
+	contentWidth := getContentWidth(m.width)
+	var b strings.Builder
+
+	// Header (80% width)
+	b.WriteString(renderHeader(version, m.width))
+	b.WriteString("\n\n")
+
+	// Section title
+	active := 0
+	for _, job := range m.jobs {
+		if !job.Finished() {
+			active++
+		}
+	}
+	b.WriteString(subtitleStyle.Render(fmt.Sprintf("Downloads (%d active)", active)))
+	b.WriteString("\n")
+	b.WriteString(sectionTitleStyle.Render(strings.Repeat("─", contentWidth-4)))
+	b.WriteString("\n")
+
+	if len(m.jobs) == 0 {
+		b.WriteString(statusMutedStyle.Render("  No downloads. Press [i] to browse models."))
+		b.WriteString("\n")
+	}
+
+	nameWidth := contentWidth - 48
+	if nameWidth < 20 {
+		nameWidth = 20
+	}
+	for i, job := range m.jobs {
+		line := fmt.Sprintf("%-*s %-9s %s", nameWidth, truncateStr(job.RepoID, nameWidth), job.Status, renderJobProgress(job))
+		if i == m.selected {
+			b.WriteString(menuItemSelectedStyle.Width(contentWidth - 4).Render("> " + line))
+		} else {
+			style := menuItemStyle
+			switch job.Status {
+			case downloads.StatusFailed:
+				style = style.Foreground(danger)
+			case downloads.StatusCompleted:
+				style = style.Foreground(secondary)
+			}
+			b.WriteString(style.Render("  " + line))
+		}
+		b.WriteString("\n")
+		if job.Status == downloads.StatusRunning && job.File != "" {
+			b.WriteString(infoLineStyle.Render(fmt.Sprintf("    [%d/%d] %s", min(job.FilesDone+1, job.FilesTotal), job.FilesTotal, job.File)))
+			b.WriteString("\n")
+		}
+		if job.Error != "" && i == m.selected {
+			b.WriteString(errorStyle.Render("    " + truncateStr(job.Error, contentWidth-8)))
+			b.WriteString("\n")
+		}
+	}
+
+	if m.err != nil {
+		b.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
+
+	// Calculate padding to push footer to bottom
+	content := b.String()
+	contentLines := strings.Count(content, "\n") + 1
+	padding := calculatePadding(contentLines, 1, m.height)
+	b.WriteString(strings.Repeat("\n", padding))
+
+	// Footer
+	helpText := "[↑/↓] select  [p] pause  [r] resume  [x] cancel  [c] clear finished  [i] install  [esc] back"
+	b.WriteString("\n" + helpStyle.Render(helpText))
+
+	return appStyle.Render(b.String())
+}
+
+// renderJobProgress formats bytes, percentage, speed and ETA of a job
+func renderJobProgress(job downloads.Job) string {
+	if job.TotalBytes == 0 {
+		return ""
+	}
+	progress := fmt.Sprintf("%3.0f%% %s/%s", job.Percent(), hf.FormatBytes(job.Bytes), hf.FormatBytes(job.TotalBytes))
+	if job.Status == downloads.StatusRunning && job.Speed > 0 {
+		progress += fmt.Sprintf("  %s/s  ETA %s", hf.FormatBytes(int64(job.Speed)), formatDuration(job.ETA()))
+	}
+	return progress
+}
+
+// formatDuration renders a duration as a compact h/m/s string
+func formatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	if d >= time.Hour {
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+	if d >= time.Minute {
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%ds", int(d.Seconds()))
+}
@@ -0,0 +1,203 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// logHistoryRows is how many runs the list shows at once
+const logHistoryRows = 8
+
+// logHistoryModel browses the persisted logs of current and past runs
+type logHistoryModel struct {
+	logs        *server.LogStore
+	model       string // only show runs of this model, empty for all
+	runs        []server.RunInfo
+	selected    int
+	viewport    viewport.Model
+	focusOnLogs bool
+	structured  bool // show receive time, source and level of each line
+	err         error
+	width       int
+	height      int
+}
+
+// openLogHistoryMsg opens the run logs, filtered to a model when set
+type openLogHistoryMsg struct{ model string }
+
+func newLogHistoryModel(cfg *config.Config, modelName string, width, height int) logHistoryModel {
+	m := logHistoryModel{
+		logs:     server.NewLogStore(server.LogDir(), cfg),
+		model:    modelName,
+		viewport: viewport.New(0, 0),
+	}
+	m.resize(width, height)
+	m.reload()
+	return m
+}
+
+// reload reads the run index, newest first, and shows the selected log
+func (m *logHistoryModel) reload() {
+	runs := m.logs.Runs()
+	m.runs = m.runs[:0]
+	for i := len(runs) - 1; i >= 0; i-- {
+		if m.model == "" || runs[i].Model == m.model {
+			m.runs = append(m.runs, runs[i])
+		}
+	}
+	if m.selected >= len(m.runs) {
+		m.selected = max(len(m.runs)-1, 0)
+	}
+	m.loadSelected()
+}
+
+func (m *logHistoryModel) loadSelected() {
+	m.err = nil
+	if len(m.runs) == 0 {
+		m.viewport.SetContent("")
+		return
+	}
+	entries, err := m.logs.Read(m.runs[m.selected].ID)
+	if err != nil {
+		m.err = err
+	}
+	lines := classifyLogLines(entries, m.structured)
+	rendered := make([]string, len(lines))
+	for i, line := range lines {
+		rendered[i] = line.render(nil, false)
+	}
+	m.viewport.SetContent(strings.Join(rendered, "\n"))
+	m.viewport.GotoBottom()
+}
+
+func (m *logHistoryModel) resize(width, height int) {
+	m.width = width
+	m.height = height
+	m.viewport.Width = max(width-8, 10)
+	m.viewport.Height = max(height-logHistoryRows-14, 5)
+}
+
+func (m logHistoryModel) Update(msg tea.Msg) (logHistoryModel, tea.Cmd) {
+	var cmd tea.Cmd
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.resize(msg.Width, msg.Height)
+
+	case tea.KeyMsg:
+		if m.focusOnLogs && msg.String() != "tab" {
+			m.viewport, cmd = m.viewport.Update(msg)
+			switch msg.String() {
+			case "g":
+				m.viewport.GotoTop()
+			case "G":
+				m.viewport.GotoBottom()
+			}
+			return m, cmd
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+				m.loadSelected()
+			}
+		case "down", "j":
+			if m.selected < len(m.runs)-1 {
+				m.selected++
+				m.loadSelected()
+			}
+		case "a":
+			// Toggle between the server's runs and all runs
+			if m.model != "" {
+				m.model = ""
+			} else if len(m.runs) > 0 {
+				m.model = m.runs[m.selected].Model
+			}
+			m.selected = 0
+			m.reload()
+		case "r":
+			m.reload()
+		case "t":
+			m.structured = !m.structured
+			m.loadSelected()
+		case "tab":
+			m.focusOnLogs = !m.focusOnLogs
+		}
+	}
+	return m, cmd
+}
+
+func (m logHistoryModel) View() string {
+	contentWidth := m.width - 4
+	var b strings.Builder
+
+	b.WriteString("\n\n\n")
+	title := "Server Logs (all servers)"
+	if m.model != "" {
+		title = "Server Logs: " + m.model
+	}
+	b.WriteString(subtitleStyle.Render(title))
+	b.WriteString("\n")
+
+	// Run list, scrolled to keep the selection visible
+	var list strings.Builder
+	if len(m.runs) == 0 {
+		list.WriteString(statusMutedStyle.Render("No recorded runs"))
+	}
+	start := 0
+	if m.selected >= logHistoryRows {
+		start = m.selected - logHistoryRows + 1
+	}
+	for i := start; i < len(m.runs) && i < start+logHistoryRows; i++ {
+		line := formatRun(m.runs[i])
+		if i == m.selected {
+			list.WriteString(optionSelectedStyle.Render(">" + line[1:]))
+		} else {
+			list.WriteString(optionNormalStyle.Render(line))
+		}
+		if i < len(m.runs)-1 {
+			list.WriteString("\n")
+		}
+	}
+
+	listBorder, logBorder := primary, muted
+	if m.focusOnLogs {
+		listBorder, logBorder = muted, primary
+	}
+	b.WriteString(lipgloss.NewStyle().
+		Width(contentWidth-2).
+		Height(logHistoryRows).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(listBorder).
+		Padding(0, 1).
+		Render(list.String()))
+	b.WriteString("\n")
+
+	var logs strings.Builder
+	if len(m.runs) > 0 {
+		run := m.runs[m.selected]
+		logs.WriteString(panelTitleStyle.Render(fmt.Sprintf("Run #%d: %s :%d", run.ID, run.Model, run.Port)))
+		logs.WriteString("\n")
+		if m.err != nil {
+			logs.WriteString(errorStyle.Render(m.err.Error()))
+		} else {
+			logs.WriteString(m.viewport.View())
+		}
+	}
+	b.WriteString(lipgloss.NewStyle().
+		Width(contentWidth-2).
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(logBorder).
+		Padding(0, 1).
+		Render(logs.String()))
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("[↑/↓] select run  [a] this server/all  [r] refresh  [t] times  [tab] focus log  [g/G] top/bottom  [esc] back"))
+
+	return appStyle.Render(b.String())
+}
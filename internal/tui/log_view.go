@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// logLevel is the severity of a server log line
+type logLevel int
+
+const (
+	levelNone logLevel = iota // no level found
+	levelDebug
+	levelInfo
+	levelWarning
+	levelError
+)
+
+// logFilterLevels are the minimum levels the log filter cycles through
+var logFilterLevels = []logLevel{levelNone, levelInfo, levelWarning, levelError}
+
+// filterLabel describes the lines a minimum level keeps
+func (l logLevel) filterLabel() string {
+	switch l {
+	case levelInfo:
+		return "INFO+"
+	case levelWarning:
+		return "WARNING+"
+	case levelError:
+		return "ERROR"
+	}
+	return "all"
+}
+
+// statusCodePattern finds the status code in HTTP access log lines
+// (`"GET /v1/models HTTP/1.1" 200 OK`)
+var statusCodePattern = regexp.MustCompile(`HTTP/\d(?:\.\d)?" ([1-5]\d\d)\b`)
+
+// entryLevel converts the level of a log entry
+func entryLevel(level string) logLevel {
+	switch level {
+	case server.LevelDebug:
+		return levelDebug
+	case server.LevelInfo:
+		return levelInfo
+	case server.LevelWarning:
+		return levelWarning
+	case server.LevelError:
+		return levelError
+	}
+	return levelNone
+}
+
+// logLine is a log entry as shown in a log panel
+type logLine struct {
+	text  string
+	level logLevel
+}
+
+// classifyLogLines prepares entries for display, raw or structured (see
+// server.LogEntry.Format), without terminal escapes
+func classifyLogLines(entries []server.LogEntry, structured bool) []logLine {
+	lines := make([]logLine, len(entries))
+	for i, entry := range entries {
+		lines[i] = logLine{text: server.StripANSI(entry.Format(structured)), level: entryLevel(entry.Level)}
+	}
+	return lines
+}
+
+var (
+	logDebugStyle   = lipgloss.NewStyle().Foreground(muted)
+	logWarningStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#F59E0B"))
+	logErrorStyle   = lipgloss.NewStyle().Foreground(danger)
+	logMatchStyle   = lipgloss.NewStyle().Background(lipgloss.Color("#F59E0B")).Foreground(lipgloss.Color("#000000"))
+	logCurrentStyle = lipgloss.NewStyle().Background(primary).Foreground(lipgloss.Color("#FFFFFF")).Bold(true)
+)
+
+// statusStyle colors an HTTP status code by its class
+func statusStyle(code string) lipgloss.Style {
+	switch code[0] {
+	case '2':
+		return lipgloss.NewStyle().Foreground(secondary)
+	case '4':
+		return logWarningStyle
+	case '5':
+		return logErrorStyle.Bold(true)
+	}
+	return logDebugStyle
+}
+
+// style returns the style of the line's text, nil when it is left plain
+func (l logLine) style() *lipgloss.Style {
+	switch {
+	case l.level == levelError:
+		return &logErrorStyle
+	case l.level == levelWarning:
+		return &logWarningStyle
+	case l.level == levelDebug:
+		return &logDebugStyle
+	}
+	return nil
+}
+
+// logSpan is a styled part of a line
+type logSpan struct {
+	start, end int
+	style      lipgloss.Style
+}
+
+// render styles the line, highlighting the matches of search (a
+// case-insensitive pattern, nil for none) and HTTP status codes. current
+// marks the line holding the selected match.
+func (l logLine) render(search *regexp.Regexp, current bool) string {
+	var spans []logSpan
+	if search != nil {
+		matchStyle := logMatchStyle
+		if current {
+			matchStyle = logCurrentStyle
+		}
+		for _, loc := range search.FindAllStringIndex(l.text, -1) {
+			if loc[1] > loc[0] {
+				spans = append(spans, logSpan{loc[0], loc[1], matchStyle})
+			}
+		}
+	}
+	if loc := statusCodePattern.FindStringSubmatchIndex(l.text); loc != nil {
+		overlaps := false
+		for _, s := range spans {
+			if loc[2] < s.end && s.start < loc[3] {
+				overlaps = true
+			}
+		}
+		if !overlaps {
+			spans = append(spans, logSpan{loc[2], loc[3], statusStyle(l.text[loc[2]:loc[3]])})
+		}
+	}
+
+	base := l.style()
+	if len(spans) == 0 {
+		if base == nil {
+			return l.text
+		}
+		return base.Render(l.text)
+	}
+
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	plain := func(s string) string {
+		if base == nil || s == "" {
+			return s
+		}
+		return base.Render(s)
+	}
+	var b strings.Builder
+	pos := 0
+	for _, s := range spans {
+		b.WriteString(plain(l.text[pos:s.start]))
+		b.WriteString(s.style.Render(l.text[s.start:s.end]))
+		pos = s.end
+	}
+	b.WriteString(plain(l.text[pos:]))
+	return b.String()
+}
+
+// searchPattern compiles a search query into a case-insensitive literal
+// pattern, nil for an empty query
+func searchPattern(query string) *regexp.Regexp {
+	if query == "" {
+		return nil
+	}
+	return regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+}
@@ -0,0 +1,349 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/api"
+	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/daemon"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// LogsOptions configures RunLogs
+type LogsOptions struct {
+	Target string // port or model name
+	Run    int    // run ID, 0 for the current or latest run
+	List   bool   // list runs instead of printing one
+	Follow bool   // keep printing new output
+	Since  string // only lines newer than a duration ago (10m) or an RFC 3339 time
+	Lines  int    // lines of earlier output to print, -1 for all
+	Grep   string // only lines matching this regular expression
+	JSON   bool   // print one JSON object per line
+
+	Structured bool // prefix lines with their receive time, source and level
+}
+
+// followPollInterval is how often a followed log file is checked for output
+const followPollInterval = 250 * time.Millisecond
+
+// RunLogs prints the output of a server. A server running on the daemon is
+// read from the daemon's buffer, one run by another efx-face process from its
+// log file. Past runs are read from their log files. Without a target, the
+// recorded runs are listed.
+func RunLogs(opts LogsOptions) error {
+	cfg, _ := config.Load()
+	logs := server.NewLogStore(server.LogDir(), cfg)
+
+	if opts.List || (opts.Target == "" && opts.Run == 0) {
+		return listRuns(logs, opts.Target)
+	}
+
+	printer, err := newLogPrinter(opts)
+	if err != nil {
+		return err
+	}
+
+	// Live output of a running server, unless a past run was asked for
+	if opts.Run == 0 {
+		if client, err := daemon.Dial(daemon.SocketPath()); err == nil {
+			for _, inst := range client.List() {
+				if matchesTarget(opts.Target, inst.Port, inst.Model) {
+					printer.port = inst.Port
+					return followDaemon(client, inst.Port, printer, opts.Lines, opts.Follow)
+				}
+			}
+		}
+		for _, tracked := range server.TrackedServers() {
+			if !matchesTarget(opts.Target, tracked.Port, tracked.Model) {
+				continue
+			}
+			for _, run := range logs.FindRuns(strconv.Itoa(tracked.Port)) {
+				if run.PID == tracked.PID {
+					printer.port = run.Port
+					return tailRun(logs, run, printer, opts.Lines, opts.Follow)
+				}
+			}
+		}
+	}
+
+	run, err := selectRun(logs, opts.Target, opts.Run)
+	if err != nil {
+		return err
+	}
+	printer.port = run.Port
+	return tailRun(logs, run, printer, opts.Lines, opts.Follow && run.Running())
+}
+
+// listRuns prints the recorded runs of target, or all runs
+func listRuns(logs *server.LogStore, target string) error {
+	runs := logs.Runs()
+	if target != "" {
+		runs = logs.FindRuns(target)
+	}
+	if len(runs) == 0 {
+		fmt.Println("No server logs")
+		return nil
+	}
+	for _, run := range runs {
+		fmt.Println(formatRun(run))
+	}
+	return nil
+}
+
+// selectRun returns run id of target, or the latest run of target when id
+// is 0
+func selectRun(logs *server.LogStore, target string, id int) (server.RunInfo, error) {
+	if target == "" {
+		if run, ok := logs.Run(id); ok {
+			return run, nil
+		}
+		return server.RunInfo{}, fmt.Errorf("no run %d", id)
+	}
+
+	runs := logs.FindRuns(target)
+	if id == 0 {
+		if len(runs) == 0 {
+			return server.RunInfo{}, fmt.Errorf("no logs for %s", target)
+		}
+		return runs[len(runs)-1], nil
+	}
+	for _, run := range runs {
+		if run.ID == id {
+			return run, nil
+		}
+	}
+	return server.RunInfo{}, fmt.Errorf("no run %d for %s", id, target)
+}
+
+// matchesTarget reports whether a server on port running modelName is the
+// one target names
+func matchesTarget(target string, port int, modelName string) bool {
+	if p, err := strconv.Atoi(target); err == nil {
+		return p == port
+	}
+	return modelName == target || strings.EqualFold(filepath.Base(modelName), filepath.Base(target))
+}
+
+// followDaemon prints the buffered output of a daemon server and, when
+// following, the output it streams afterwards
+func followDaemon(client *daemon.Client, port int, printer *logPrinter, lines int, follow bool) error {
+	// Open the stream before reading the buffer so no line falls between
+	// the two; lines seen in both are skipped by their sequence number
+	var updates <-chan server.Update
+	if follow {
+		updates = client.UpdateChan()
+	}
+
+	backlog := client.GetLogEntries(port)
+	printer.printBacklog(backlog, lines)
+	if !follow {
+		return nil
+	}
+
+	var last uint64
+	for _, entry := range backlog {
+		last = max(last, entry.Seq)
+	}
+	for update := range updates {
+		entry := update.Entry
+		if update.Port != port || update.Type != server.UpdateNewOutput || entry == nil || entry.Partial || entry.Seq <= last {
+			continue
+		}
+		printer.print(*entry)
+	}
+	return nil
+}
+
+// tailRun prints the log file of a run and, when following, the output
+// appended to it, moving on to the next run when the server restarts
+func tailRun(logs *server.LogStore, run server.RunInfo, printer *logPrinter, lines int, follow bool) error {
+	for {
+		if _, err := os.Stat(logs.LogPath(run.ID)); err != nil {
+			return fmt.Errorf("no log for run %d", run.ID)
+		}
+
+		backlog, _ := logs.Read(run.ID)
+		printer.printBacklog(backlog, lines)
+		if !follow {
+			return nil
+		}
+
+		next := followFile(logs, run, printer)
+		if next == nil {
+			return nil
+		}
+		run, lines = *next, -1
+	}
+}
+
+// followFile prints lines appended to a run's log until the run ends and
+// returns the run that replaced it, or nil if the server is gone
+func followFile(logs *server.LogStore, run server.RunInfo, printer *logPrinter) *server.RunInfo {
+	path := logs.LogPath(run.ID)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer func() { file.Close() }()
+	file.Seek(0, io.SeekEnd)
+
+	var partial string
+	buf := make([]byte, 32*1024)
+	checked := time.Now()
+
+	for {
+		n, _ := file.Read(buf)
+		if n > 0 {
+			text := partial + string(buf[:n])
+			complete := strings.Split(text, "\n")
+			partial = complete[len(complete)-1]
+			for _, line := range complete[:len(complete)-1] {
+				printer.print(server.ParseLogLine(line))
+			}
+			continue
+		}
+
+		// The file was rotated: finish the old one, then read the new one
+		if current, err := os.Stat(path); err == nil {
+			if info, err := file.Stat(); err == nil && !os.SameFile(current, info) {
+				if reopened, err := os.Open(path); err == nil {
+					file.Close()
+					file = reopened
+					continue
+				}
+			}
+		}
+
+		if time.Since(checked) > 2*time.Second {
+			checked = time.Now()
+			latest, ok := logs.Run(run.ID)
+			if !ok || !latest.Running() || !isTracked(latest.PID) {
+				if partial != "" {
+					printer.print(server.ParseLogLine(partial))
+				}
+				return nextRun(logs, run)
+			}
+		}
+		time.Sleep(followPollInterval)
+	}
+}
+
+// nextRun waits briefly for a restarted server to start a new run on the
+// same port and returns it
+func nextRun(logs *server.LogStore, run server.RunInfo) *server.RunInfo {
+	deadline := time.Now().Add(2 * time.Minute)
+	for time.Now().Before(deadline) {
+		for _, candidate := range logs.FindRuns(strconv.Itoa(run.Port)) {
+			if candidate.ID > run.ID && candidate.Model == run.Model {
+				return &candidate
+			}
+		}
+		time.Sleep(time.Second)
+	}
+	return nil
+}
+
+// isTracked reports whether pid is a live server of a running efx-face
+// process, so a run left open by one that died is not followed forever
+func isTracked(pid int) bool {
+	for _, tracked := range server.TrackedServers() {
+		if tracked.PID == pid {
+			return true
+		}
+	}
+	return false
+}
+
+// logPrinter filters log lines and prints them as text or JSON
+type logPrinter struct {
+	port       int
+	since      time.Time
+	grep       *regexp.Regexp
+	json       bool
+	structured bool
+}
+
+func newLogPrinter(opts LogsOptions) (*logPrinter, error) {
+	p := &logPrinter{json: opts.JSON, structured: opts.Structured}
+	if opts.Grep != "" {
+		re, err := regexp.Compile(opts.Grep)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+		p.grep = re
+	}
+	if opts.Since != "" {
+		if d, err := time.ParseDuration(opts.Since); err == nil {
+			p.since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, opts.Since); err == nil {
+			p.since = t
+		} else {
+			return nil, fmt.Errorf("invalid --since %q: use a duration like 10m or an RFC 3339 time", opts.Since)
+		}
+	}
+	return p, nil
+}
+
+// match reports whether an entry passes the filters. Entries without a
+// timestamp are not filtered by time.
+func (p *logPrinter) match(entry server.LogEntry) bool {
+	if !p.since.IsZero() && !entry.Time.IsZero() && entry.Time.Before(p.since) {
+		return false
+	}
+	return p.grep == nil || p.grep.MatchString(entry.Text)
+}
+
+// printBacklog prints the last lines entries passing the filters, all of
+// them when lines is negative. Unfinished lines are left out.
+func (p *logPrinter) printBacklog(entries []server.LogEntry, lines int) {
+	var matched []server.LogEntry
+	for _, entry := range entries {
+		if !entry.Partial && p.match(entry) {
+			matched = append(matched, entry)
+		}
+	}
+	if lines >= 0 && len(matched) > lines {
+		matched = matched[len(matched)-lines:]
+	}
+	for _, entry := range matched {
+		p.write(entry)
+	}
+}
+
+// print prints a new entry if it passes the filters
+func (p *logPrinter) print(entry server.LogEntry) {
+	if p.match(entry) {
+		p.write(entry)
+	}
+}
+
+func (p *logPrinter) write(entry server.LogEntry) {
+	if !p.json {
+		fmt.Println(entry.Format(p.structured))
+		return
+	}
+	data, _ := json.Marshal(api.NewLogLine(p.port, entry))
+	fmt.Println(string(data))
+}
+
+// formatRun renders a one-line summary of a server run
+func formatRun(run server.RunInfo) string {
+	status := "running"
+	if run.ExitCode != nil {
+		status = fmt.Sprintf("exit %d", *run.ExitCode)
+	}
+	duration := ""
+	if run.EndedAt != nil {
+		duration = formatDuration(run.EndedAt.Sub(run.StartedAt))
+	}
+	return fmt.Sprintf("  #%-4d :%-5d %-40s %s  %-8s %s", run.ID, run.Port, truncateStr(run.Model, 40),
+		run.StartedAt.Format("2006-01-02 15:04:05"), status, duration)
+}
@@ -18,6 +18,7 @@ const (
 	menuUninstall
 	menuServerManager
 	menuConfigStorage
+	menuDownloads
 	menuExit
 )
 
@@ -31,10 +32,10 @@ var box1Grid = [][]int{
 
 // Box 2: 2-col grid
 // Row 0: [Server Manager, Setup]
-// Row 1: [Exit]
+// Row 1: [Downloads, Exit]
 var box2Grid = [][]int{
 	{menuServerManager, menuConfigStorage},
-	{menuExit},
+	{menuDownloads, menuExit},
 }
 
 var menuItems = []string{
@@ -44,6 +45,7 @@ var menuItems = []string{
 	"✕  Remove a model",
 	"◉  Server Manager",
 	"⚙  Setup model path",
+	"⇣  Downloads",
 	"✖  Exit",
 }
 
@@ -58,6 +60,7 @@ type menuModel struct {
 	store       *model.Store
 	modelCount  int
 	serverCount int
+	attached    bool // connected to a background daemon
 }
 
 func newMenuModel(cfg *config.Config, store *model.Store) menuModel {
@@ -171,7 +174,7 @@ func (m menuModel) Update(msg tea.Msg) (menuModel, tea.Cmd) {
 		case "tab":
 			// TAB cycles within current column only
 			if m.col == 0 {
-				// Col 0 cycle: Run template -> Run model -> Server Manager -> Exit -> back
+				// Col 0 cycle: Run template -> Run model -> Server Manager -> Downloads -> back
 				currentIdx := m.getSelectedIndex()
 				switch currentIdx {
 				case menuRunTemplate:
@@ -186,7 +189,7 @@ func (m menuModel) Update(msg tea.Msg) (menuModel, tea.Cmd) {
 					m.box = 1
 					m.row = 1
 					m.col = 0
-				case menuExit:
+				case menuDownloads:
 					m.box = 0
 					m.row = 0
 					m.col = 0
@@ -196,7 +199,7 @@ func (m menuModel) Update(msg tea.Msg) (menuModel, tea.Cmd) {
 					m.col = 0
 				}
 			} else {
-				// Col 1 cycle: Install -> Remove -> Setup -> back
+				// Col 1 cycle: Install -> Remove -> Setup -> Exit -> back
 				currentIdx := m.getSelectedIndex()
 				switch currentIdx {
 				case menuInstall:
@@ -208,6 +211,10 @@ func (m menuModel) Update(msg tea.Msg) (menuModel, tea.Cmd) {
 					m.row = 0
 					m.col = 1
 				case menuConfigStorage:
+					m.box = 1
+					m.row = 1
+					m.col = 1
+				case menuExit:
 					m.box = 0
 					m.row = 0
 					m.col = 1
@@ -220,10 +227,10 @@ func (m menuModel) Update(msg tea.Msg) (menuModel, tea.Cmd) {
 		case "shift+tab":
 			// Reverse TAB cycles within current column only
 			if m.col == 0 {
-				// Col 0 reverse: Exit -> Server Manager -> Run model -> Run template
+				// Col 0 reverse: Downloads -> Server Manager -> Run model -> Run template
 				currentIdx := m.getSelectedIndex()
 				switch currentIdx {
-				case menuExit:
+				case menuDownloads:
 					m.box = 1
 					m.row = 0
 					m.col = 0
@@ -245,9 +252,13 @@ func (m menuModel) Update(msg tea.Msg) (menuModel, tea.Cmd) {
 					m.col = 0
 				}
 			} else {
-				// Col 1 reverse: Setup -> Remove -> Install
+				// Col 1 reverse: Exit -> Setup -> Remove -> Install
 				currentIdx := m.getSelectedIndex()
 				switch currentIdx {
+				case menuExit:
+					m.box = 1
+					m.row = 0
+					m.col = 1
 				case menuConfigStorage:
 					m.box = 0
 					m.row = 1
@@ -258,7 +269,7 @@ func (m menuModel) Update(msg tea.Msg) (menuModel, tea.Cmd) {
 					m.col = 1
 				case menuInstall:
 					m.box = 1
-					m.row = 0
+					m.row = 1
 					m.col = 1
 				default:
 					m.box = 0
@@ -281,12 +292,17 @@ func (m menuModel) Update(msg tea.Msg) (menuModel, tea.Cmd) {
 				return m, func() tea.Msg { return openServerManagerMsg{} }
 			case menuConfigStorage:
 				return m, func() tea.Msg { return openStorageConfigMsg{} }
+			case menuDownloads:
+				return m, func() tea.Msg { return openDownloadsMsg{} }
 			case menuExit:
 				return m, tea.Quit
 			}
 		case "s":
 			// Quick access to server manager
 			return m, func() tea.Msg { return openServerManagerMsg{} }
+		case "d":
+			// Quick access to downloads
+			return m, func() tea.Msg { return openDownloadsMsg{} }
 		}
 	}
 	return m, nil
@@ -383,7 +399,10 @@ func (m menuModel) View() string {
 
 	b.WriteString(infoLineStyle.Render(fmt.Sprintf("Models: %s (%d installed)", modelDir, m.modelCount)))
 	b.WriteString("\n")
-	if m.serverCount > 0 {
+	if m.attached {
+		b.WriteString(infoLineStyle.Render(fmt.Sprintf("Servers: %d running (daemon)", m.serverCount)))
+		b.WriteString("\n")
+	} else if m.serverCount > 0 {
 		b.WriteString(infoLineStyle.Render(fmt.Sprintf("Servers: %d running", m.serverCount)))
 		b.WriteString("\n")
 	}
@@ -396,7 +415,7 @@ func (m menuModel) View() string {
 	b.WriteString(strings.Repeat("\n", padding))
 
 	// Footer
-	helpText := "[↵] select  [←→↑↓] navigate  [tab] cycle column  [s] servers  [q] quit"
+	helpText := "[↵] select  [←→↑↓] navigate  [tab] cycle column  [s] servers  [d] downloads  [q] quit"
 	b.WriteString("\n" + helpStyle.Render(helpText))
 
 	return appStyle.Render(b.String())
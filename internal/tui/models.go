@@ -18,16 +18,28 @@ type templatesModel struct {
 	height    int
 	cfg       *config.Config
 	store     *model.Store
+
+	confirmDelete bool   // waiting for y to delete the selected template
+	status        string // result of the last edit
+	err           string
+	loadErr       string // why templates.yaml could not be loaded
+
+	problems map[string][]*model.TemplateError // by template name
 }
 
 func newTemplatesModel(cfg *config.Config, store *model.Store) templatesModel {
-	templates, _ := model.LoadTemplates()
-	return templatesModel{
+	templates, err := model.LoadTemplates()
+	m := templatesModel{
 		templates: templates,
 		selected:  0,
 		cfg:       cfg,
 		store:     store,
 	}
+	if err != nil {
+		m.loadErr = err.Error()
+	}
+	m.validate()
+	return m
 }
 
 func (m templatesModel) Init() tea.Cmd {
@@ -37,6 +49,14 @@ func (m templatesModel) Init() tea.Cmd {
 func (m templatesModel) Update(msg tea.Msg) (templatesModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		if m.confirmDelete {
+			m.confirmDelete = false
+			if msg.String() == "y" {
+				m.deleteSelected()
+			}
+			return m, nil
+		}
+		m.status, m.err = "", ""
 		switch msg.String() {
 		case "up", "k":
 			if m.selected > 0 {
@@ -64,14 +84,57 @@ func (m templatesModel) Update(msg tea.Msg) (templatesModel, tea.Cmd) {
 				// Create config from template
 				cfg := server.FromTemplate(&template, m.cfg.ModelDir)
 				return m, func() tea.Msg {
-					return openConfigPanelMsg{config: cfg}
+					return openConfigPanelMsg{config: cfg, template: &template}
 				}
 			}
+		case "e":
+			if m.selected < len(m.templates) {
+				template := m.templates[m.selected]
+				cfg := server.FromTemplate(&template, m.cfg.ModelDir)
+				return m, func() tea.Msg {
+					return openConfigPanelMsg{config: cfg, template: &template, edit: true}
+				}
+			}
+		case "d":
+			if m.selected < len(m.templates) {
+				m.confirmDelete = true
+			}
 		}
 	}
 	return m, nil
 }
 
+// deleteSelected removes the selected template from templates.yaml
+func (m *templatesModel) deleteSelected() {
+	name := m.templates[m.selected].Name
+	remaining := make([]model.Template, 0, len(m.templates))
+	for i, t := range m.templates {
+		if i != m.selected {
+			remaining = append(remaining, t)
+		}
+	}
+	if err := model.SaveTemplates(remaining); err != nil {
+		m.err = "Failed to delete template: " + err.Error()
+		return
+	}
+	m.templates = remaining
+	if m.selected > len(m.templates) {
+		m.selected = len(m.templates)
+	}
+	m.status = "✓ Deleted template " + name
+	m.validate()
+}
+
+// validate checks templates.yaml for the warning badges. Missing models
+// are left out, the installed column shows them.
+func (m *templatesModel) validate() {
+	m.problems = make(map[string][]*model.TemplateError)
+	problems, _ := model.ValidateTemplates(nil)
+	for _, p := range problems {
+		m.problems[p.Template] = append(m.problems[p.Template], p)
+	}
+}
+
 func (m templatesModel) View() string {
 	contentWidth := getContentWidth(m.width)
 	var b strings.Builder
@@ -99,9 +162,13 @@ func (m templatesModel) View() string {
 			installed = "✓"
 		}
 		
+		name := truncateStr(t.Name, col1Width)
+		if len(m.problems[t.Name]) > 0 {
+			name = "⚠ " + truncateStr(t.Name, col1Width-2)
+		}
 		line := fmt.Sprintf("%s %-*s %-*s %s", 
 			installed, 
-			col1Width, truncateStr(t.Name, col1Width), 
+			col1Width, name, 
 			col2Width, t.ModelType, 
 			truncateStr(t.Description, col3Width))
 
@@ -120,6 +187,27 @@ func (m templatesModel) View() string {
 		b.WriteString(menuItemStyle.Render("  [Back]"))
 	}
 
+	// Delete confirmation or the result of the last edit
+	b.WriteString("\n\n")
+	switch {
+	case m.confirmDelete:
+		b.WriteString(warningStyle.Render(fmt.Sprintf("Delete template %s? [y/n]", m.templates[m.selected].Name)))
+	case m.err != "":
+		b.WriteString(errorStyle.Render(m.err))
+	case m.status != "":
+		b.WriteString(successStyle.Render(m.status))
+	case m.loadErr != "":
+		b.WriteString(errorStyle.Render(m.loadErr))
+	case m.selected < len(m.templates) && len(m.problems[m.templates[m.selected].Name]) > 0:
+		problems := m.problems[m.templates[m.selected].Name]
+		msg := fmt.Sprintf("⚠ line %d: %s", problems[0].Line, problems[0].Msg)
+		if len(problems) > 1 {
+			// The rest are listed by efx-face templates validate
+			msg = fmt.Sprintf("⚠ 1 of %d problems, line %d: %s", len(problems), problems[0].Line, problems[0].Msg)
+		}
+		b.WriteString(warningStyle.Render(truncateStr(msg, contentWidth-4)))
+	}
+
 	// Calculate padding to push footer to bottom
 	content := b.String()
 	contentLines := strings.Count(content, "\n") + 1
@@ -127,7 +215,7 @@ func (m templatesModel) View() string {
 	b.WriteString(strings.Repeat("\n", padding))
 
 	// Footer
-	helpText := "[↵] run  [tab] models  [esc] back  [q] home"
+	helpText := "[↵] run  [e] edit  [d] delete  [tab] models  [esc] back  [q] home"
 	b.WriteString("\n" + helpStyle.Render(helpText))
 
 	return appStyle.Render(b.String())
@@ -0,0 +1,175 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// orphansModel offers to adopt or kill servers left running by an earlier
+// efx-face session
+type orphansModel struct {
+	mgr      *server.Manager
+	orphans  []server.Orphan
+	selected int
+	status   string
+	err      error
+	busy     bool
+	width    int
+	height   int
+}
+
+// orphanHandledMsg reports the outcome of adopting or killing orphans
+type orphanHandledMsg struct {
+	pids   []int
+	action string
+	errs   []error
+}
+
+func newOrphansModel(mgr *server.Manager, orphans []server.Orphan) orphansModel {
+	return orphansModel{mgr: mgr, orphans: orphans}
+}
+
+func (m orphansModel) Update(msg tea.Msg) (orphansModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case orphanHandledMsg:
+		m.busy = false
+		m.status = ""
+		m.err = nil
+		handled := make(map[int]bool)
+		for _, pid := range msg.pids {
+			handled[pid] = true
+		}
+		var remaining []server.Orphan
+		for _, o := range m.orphans {
+			if !handled[o.PID] {
+				remaining = append(remaining, o)
+			}
+		}
+		m.orphans = remaining
+		if m.selected >= len(m.orphans) {
+			m.selected = max(len(m.orphans)-1, 0)
+		}
+		if len(msg.errs) > 0 {
+			m.err = fmt.Errorf("failed to %s %w", msg.action, msg.errs[0])
+		}
+		if len(m.orphans) == 0 && len(msg.errs) == 0 {
+			return m, func() tea.Msg { return goBackMsg{} }
+		}
+
+	case tea.KeyMsg:
+		if m.busy {
+			return m, nil
+		}
+		switch msg.String() {
+		case "up", "k":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "down", "j":
+			if m.selected < len(m.orphans)-1 {
+				m.selected++
+			}
+		case "a":
+			if len(m.orphans) > 0 {
+				return m.handle("adopt", m.orphans[m.selected:m.selected+1])
+			}
+		case "A":
+			return m.handle("adopt", m.orphans)
+		case "x":
+			if len(m.orphans) > 0 {
+				m.status = fmt.Sprintf("Stopping %s...", m.orphans[m.selected].Model)
+				return m.handle("kill", m.orphans[m.selected:m.selected+1])
+			}
+		case "X":
+			m.status = "Stopping all orphaned servers..."
+			return m.handle("kill", m.orphans)
+		case "i", "enter":
+			return m, func() tea.Msg { return goBackMsg{} }
+		}
+	}
+	return m, nil
+}
+
+// handle adopts or kills orphans in the background, since killing waits for
+// the processes to exit
+func (m orphansModel) handle(action string, orphans []server.Orphan) (orphansModel, tea.Cmd) {
+	if len(orphans) == 0 {
+		return m, nil
+	}
+	m.busy = true
+	mgr := m.mgr
+	targets := append([]server.Orphan(nil), orphans...)
+	return m, func() tea.Msg {
+		result := orphanHandledMsg{action: action}
+		for _, o := range targets {
+			var err error
+			if action == "adopt" {
+				_, err = mgr.Adopt(o)
+			} else {
+				err = mgr.KillOrphan(o)
+			}
+			if err != nil {
+				result.errs = append(result.errs, fmt.Errorf("%s (pid %d): %w", o.Model, o.PID, err))
+				continue
+			}
+			result.pids = append(result.pids, o.PID)
+		}
+		return result
+	}
+}
+
+func (m orphansModel) View() string {
+	contentWidth := getContentWidth(m.width)
+	var b strings.Builder
+
+	b.WriteString(renderHeader(version, m.width))
+	b.WriteString("\n\n")
+
+	b.WriteString(subtitleStyle.Render(fmt.Sprintf("Orphaned Servers (%d)", len(m.orphans))))
+	b.WriteString("\n")
+	b.WriteString(sectionTitleStyle.Render(strings.Repeat("─", contentWidth-4)))
+	b.WriteString("\n")
+	b.WriteString(infoLineStyle.Render("These mlx-openai-server processes are still running but no efx-face session manages them."))
+	b.WriteString("\n")
+	b.WriteString(infoLineStyle.Render("Adopted servers can be monitored and stopped, but their earlier output is lost."))
+	b.WriteString("\n\n")
+
+	nameWidth := contentWidth - 40
+	if nameWidth < 20 {
+		nameWidth = 20
+	}
+	for i, o := range m.orphans {
+		age := ""
+		if !o.StartedAt.IsZero() {
+			age = "up " + formatDuration(time.Since(o.StartedAt))
+		}
+		line := fmt.Sprintf("%-*s :%-5d pid %-7d %s", nameWidth, truncateStr(o.Model, nameWidth), o.Port, o.PID, age)
+		if i == m.selected {
+			b.WriteString(menuItemSelectedStyle.Width(contentWidth - 4).Render("> " + line))
+		} else {
+			b.WriteString(menuItemStyle.Render("  " + line))
+		}
+		b.WriteString("\n")
+	}
+
+	if m.status != "" {
+		b.WriteString("\n" + infoLineStyle.Render(m.status))
+	}
+	if m.err != nil {
+		b.WriteString("\n" + errorStyle.Render(fmt.Sprintf("Error: %v", m.err)))
+	}
+
+	content := b.String()
+	contentLines := strings.Count(content, "\n") + 1
+	padding := calculatePadding(contentLines, 1, m.height)
+	b.WriteString(strings.Repeat("\n", padding))
+
+	helpText := "[↑/↓] select  [a] adopt  [A] adopt all  [x] kill  [X] kill all  [i] ignore"
+	b.WriteString("\n" + helpStyle.Render(helpText))
+
+	return appStyle.Render(b.String())
+}
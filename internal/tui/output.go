@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat selects how CLI commands print their results
+type OutputFormat string
+
+const (
+	OutputTable OutputFormat = "table" // human-readable text
+	OutputJSON  OutputFormat = "json"
+	OutputYAML  OutputFormat = "yaml"
+)
+
+// ParseOutputFormat validates the value of the --output flag
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch format := OutputFormat(s); format {
+	case OutputTable, OutputJSON, OutputYAML:
+		return format, nil
+	}
+	return "", fmt.Errorf("invalid output format %q: use json, yaml or table", s)
+}
+
+// structured reports whether results are printed as data rather than text
+func (f OutputFormat) structured() bool {
+	return f == OutputJSON || f == OutputYAML
+}
+
+// printOutput prints v as JSON or YAML. YAML has the same field names and
+// order as JSON, so both formats are driven by the json tags of v.
+func printOutput(format OutputFormat, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if format == OutputJSON {
+		_, err = fmt.Fprintln(os.Stdout, string(data))
+		return err
+	}
+
+	// JSON is valid YAML: decoding it into a node keeps the key order,
+	// and clearing the flow style prints it as block YAML
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle resets the style of a node tree so strings are only quoted
+// where YAML needs it
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
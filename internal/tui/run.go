@@ -0,0 +1,249 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/daemon"
+	"github.com/lmarques/efx-face-manager/internal/model"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// RunOptions configures RunModel. Zero fields of Config, and empty Type and
+// Restart, keep the value of the template or the default.
+type RunOptions struct {
+	Target  string // installed model or template name
+	Type    string
+	Restart string
+	Config  server.Config
+	Detach  bool // start the server in the daemon instead of the foreground
+	Format  OutputFormat
+}
+
+// RunModel launches a model or template without the TUI. In the foreground
+// it prints the server's output until the server exits or is interrupted;
+// detached, the daemon hosts the server and RunModel returns once it started.
+func RunModel(opts RunOptions) error {
+	if opts.Format.structured() && !opts.Detach {
+		return fmt.Errorf("--output %s is only supported with --detach", opts.Format)
+	}
+
+	appCfg, _ := config.Load()
+	cfg, err := runConfig(opts, appCfg)
+	if err != nil {
+		return err
+	}
+
+	if opts.Detach {
+		client, err := daemon.StartDetached(daemon.SocketPath())
+		if err != nil {
+			return err
+		}
+		if cfg.Port == 0 {
+			cfg.Port = client.NextAvailablePort(appCfg.DefaultPort)
+		}
+		inst, err := client.Start(cfg)
+		if err != nil {
+			return err
+		}
+		if opts.Format.structured() {
+			return printOutput(opts.Format, inst.Info())
+		}
+		fmt.Printf("Started %s on %s (pid %d)\n", inst.Model, serverURL(inst.Host, inst.Port), inst.PID)
+		fmt.Printf("Follow its output with `efx-face logs %d -f`\n", inst.Port)
+		return nil
+	}
+
+	mgr := server.NewManager()
+	mgr.SetGracePeriod(appCfg.StopGracePeriod())
+	mgr.TrackState(server.StateDir())
+	mgr.Logs = server.NewLogStore(server.LogDir(), appCfg)
+	if cfg.Port == 0 {
+		cfg.Port = mgr.NextAvailablePort(appCfg.DefaultPort)
+	}
+	return runForeground(mgr, cfg)
+}
+
+// runConfig builds the server config of opts from a template or an
+// installed model and checks that the model is installed
+func runConfig(opts RunOptions, appCfg *config.Config) (server.Config, error) {
+	store := model.NewStore(appCfg.ModelDir)
+
+	var cfg server.Config
+	templates, err := model.LoadTemplates()
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load templates: %w", err)
+	}
+	var tmpl *model.Template
+	for i := range templates {
+		if templates[i].Name == opts.Target {
+			tmpl = &templates[i]
+			break
+		}
+	}
+
+	switch {
+	case tmpl != nil:
+		if !store.Exists(tmpl.ModelName) {
+			return cfg, fmt.Errorf("model %s of template %s is not installed, install it with `efx-face install`", tmpl.ModelName, tmpl.Name)
+		}
+		cfg = server.FromTemplate(tmpl, appCfg.ModelDir)
+	case store.Exists(opts.Target):
+		cfg = server.NewConfig()
+		cfg.Model = opts.Target
+		cfg.ModelPath = filepath.Join(appCfg.ModelDir, opts.Target)
+		cfg.Host = appCfg.DefaultHost
+		cfg.Port = 0
+	default:
+		return cfg, fmt.Errorf("%s is neither an installed model nor a template, see `efx-face list`", opts.Target)
+	}
+
+	applyOverrides(&cfg, opts)
+	if !cfg.Type.Valid() {
+		return cfg, fmt.Errorf("invalid model type %q", cfg.Type)
+	}
+	if cfg.Host == "" {
+		cfg.Host = appCfg.DefaultHost
+	}
+	if _, err := server.ParseRestartPolicy(string(cfg.Restart)); err != nil {
+		return cfg, err
+	}
+	return cfg, nil
+}
+
+// applyOverrides copies the options that were set over cfg
+func applyOverrides(cfg *server.Config, opts RunOptions) {
+	o := opts.Config
+	if opts.Type != "" {
+		cfg.Type = model.ModelType(opts.Type)
+	}
+	if opts.Restart != "" {
+		cfg.Restart = server.RestartPolicy(opts.Restart)
+	}
+	setInt := func(dst *int, v int) {
+		if v != 0 {
+			*dst = v
+		}
+	}
+	setString := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	setInt(&cfg.Port, o.Port)
+	setString(&cfg.Host, o.Host)
+	setInt(&cfg.ContextLength, o.ContextLength)
+	setString(&cfg.ToolCallParser, o.ToolCallParser)
+	setString(&cfg.ReasoningParser, o.ReasoningParser)
+	setString(&cfg.MessageConverter, o.MessageConverter)
+	setString(&cfg.ChatTemplateFile, o.ChatTemplateFile)
+	setString(&cfg.LogLevel, strings.ToUpper(o.LogLevel))
+	setString(&cfg.ConfigName, o.ConfigName)
+	setInt(&cfg.Quantize, o.Quantize)
+	setString(&cfg.LoraPaths, o.LoraPaths)
+	setString(&cfg.LoraScales, o.LoraScales)
+	setInt(&cfg.MaxConcurrency, o.MaxConcurrency)
+	setInt(&cfg.QueueTimeout, o.QueueTimeout)
+	setInt(&cfg.QueueSize, o.QueueSize)
+	setInt(&cfg.MaxRetries, o.MaxRetries)
+	cfg.TrustRemoteCode = cfg.TrustRemoteCode || o.TrustRemoteCode
+	cfg.Debug = cfg.Debug || o.Debug
+	cfg.DisableAutoResize = cfg.DisableAutoResize || o.DisableAutoResize
+	cfg.ExtraArgs = append(cfg.ExtraArgs, o.ExtraArgs...)
+}
+
+// runForeground starts cfg on mgr and prints its output until it exits for
+// good. SIGINT and SIGTERM stop it gracefully, like quitting the TUI.
+func runForeground(mgr *server.Manager, cfg server.Config) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	inst, err := mgr.Start(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "efx-face: started %s on %s (pid %d), Ctrl-C to stop\n", inst.Model, serverURL(inst.Host, inst.Port), inst.PID)
+
+	for {
+		select {
+		case <-sigCh:
+			fmt.Fprintf(os.Stderr, "efx-face: stopping %s\n", inst.Model)
+			// A second signal skips the grace period
+			go func() {
+				<-sigCh
+				os.Exit(130)
+			}()
+			go drainUpdates(mgr.Updates)
+			return mgr.Stop(inst.Port)
+
+		case update := <-mgr.Updates:
+			switch update.Type {
+			case server.UpdateNewOutput:
+				if update.Entry != nil && !update.Entry.Partial {
+					printEntry(*update.Entry)
+				}
+			case server.UpdateState:
+				switch server.State(update.Data) {
+				case server.StateReady:
+					fmt.Fprintf(os.Stderr, "efx-face: %s is ready at %s\n", inst.Model, serverURL(inst.Host, inst.Port))
+				case server.StateUnhealthy, server.StateRestarting:
+					msg := fmt.Sprintf("efx-face: %s is %s", inst.Model, update.Data)
+					if reason := inst.Info().HealthError; reason != "" {
+						msg += " (" + reason + ")"
+					}
+					fmt.Fprintln(os.Stderr, msg)
+				}
+			case server.UpdateError:
+				fmt.Fprintf(os.Stderr, "efx-face: %s\n", update.Data)
+			case server.UpdateStopped:
+				if exited, err := finalExit(inst); exited {
+					mgr.StopAll()
+					return err
+				}
+			}
+		}
+	}
+}
+
+// finalExit reports whether the process of inst exited without a restart
+// pending, and an error when it failed
+func finalExit(inst *server.Instance) (bool, error) {
+	// The restart decision follows the stopped update closely
+	deadline := time.Now().Add(500 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		// A restart requested with efx-face servers restart is immediate
+		if info := inst.Info(); info.State == server.StateRestarting || info.Running {
+			return false, nil
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	info := inst.Info()
+	if info.LastExit != nil && info.LastExit.Code != 0 {
+		return true, fmt.Errorf("%s exited with code %d", inst.Model, info.LastExit.Code)
+	}
+	return true, nil
+}
+
+// printEntry prints server output to the stream it was written to
+func printEntry(entry server.LogEntry) {
+	out := os.Stdout
+	if entry.Source != server.SourceStdout {
+		out = os.Stderr
+	}
+	fmt.Fprintln(out, entry.Text)
+}
+
+// serverURL returns the OpenAI base URL of a server
+func serverURL(host string, port int) string {
+	if host == "0.0.0.0" || host == "" {
+		host = "localhost"
+	}
+	return fmt.Sprintf("http://%s:%d/v1", host, port)
+}
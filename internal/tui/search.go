@@ -2,15 +2,14 @@ package tui
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 
 	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/downloads"
 	"github.com/lmarques/efx-face-manager/internal/hf"
 	"github.com/lmarques/efx-face-manager/internal/model"
 )
@@ -33,6 +32,7 @@ type searchModel struct {
 	cfg          *config.Config
 	store        *model.Store
 	hfClient     *hf.Client
+	queue        downloads.Backend
 	width        int
 	height       int
 	sourceIdx    int
@@ -53,7 +53,7 @@ type searchModel struct {
 	spinner      spinner.Model
 }
 
-func newSearchModel(cfg *config.Config, store *model.Store) searchModel {
+func newSearchModel(cfg *config.Config, store *model.Store, queue downloads.Backend) searchModel {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
 	s.Style = spinnerStyle
@@ -62,6 +62,7 @@ func newSearchModel(cfg *config.Config, store *model.Store) searchModel {
 		cfg:          cfg,
 		store:        store,
 		hfClient:     hf.NewClient(),
+		queue:        queue,
 		sourceIdx:    0,
 		loadedSource: -1,            // Not loaded yet
 		loadingMode:  loadModeAll,   // Default to All mode (load models on startup)
@@ -89,8 +90,10 @@ type loadErrorMsg struct {
 	err error
 }
 
-type installCompleteMsg struct {
+// installQueuedMsg reports that a model was added to the download queue
+type installQueuedMsg struct {
 	modelID string
+	jobID   int
 	err     error
 }
 
@@ -120,15 +123,13 @@ func (m searchModel) Update(msg tea.Msg) (searchModel, tea.Cmd) {
 		m.loading = false
 		m.err = msg.err
 
-	case installCompleteMsg:
+	case installQueuedMsg:
 		m.installing = false
 		if msg.err != nil {
 			m.err = msg.err
 			m.installMsg = fmt.Sprintf("Install failed: %v", msg.err)
 		} else {
-			m.installMsg = fmt.Sprintf("Successfully installed %s", msg.modelID)
-			// Refresh store
-			m.store = model.NewStore(m.cfg.ModelDir)
+			m.installMsg = fmt.Sprintf("Successfully queued %s (job #%d) - see Downloads", msg.modelID, msg.jobID)
 		}
 
 	case tea.KeyMsg:
@@ -309,7 +310,7 @@ func (m searchModel) Update(msg tea.Msg) (searchModel, tea.Cmd) {
 			if len(m.filtered) > 0 && m.cursor < len(m.filtered) && !m.installing {
 				selectedModel := m.filtered[m.cursor]
 				m.installing = true
-				m.installMsg = fmt.Sprintf("Installing %s...", selectedModel.ID)
+				m.installMsg = fmt.Sprintf("Queueing %s...", selectedModel.ID)
 				return m, tea.Batch(m.spinner.Tick, m.performInstall(selectedModel.ID))
 			}
 
@@ -386,57 +387,14 @@ func (m searchModel) getTotalPages() int {
 }
 
 func (m searchModel) performInstall(modelID string) tea.Cmd {
-	return func() tea.Msg {
-		// Create cache directory if needed
-		cacheDir := m.cfg.ModelDir
-		os.MkdirAll(filepath.Join(cacheDir, "cache"), 0755)
-
-		// Parse model name from ID
-		parts := strings.Split(modelID, "/")
-		modelName := parts[len(parts)-1]
-
-		// Check which hf command is available
-		hfCmd := "hf"
-		if _, err := exec.LookPath("hf"); err != nil {
-			if _, err := exec.LookPath("huggingface-cli"); err != nil {
-				return installCompleteMsg{modelID: modelID, err: fmt.Errorf("hf CLI not found. Install with: pip install huggingface_hub")}
-			}
-			hfCmd = "huggingface-cli"
-		}
-
-		// Download using hf
-		var cmd *exec.Cmd
-		if hfCmd == "hf" {
-			cmd = exec.Command(hfCmd, "download", modelID, "--cache-dir", filepath.Join(cacheDir, "cache"), "--no-quiet")
-		} else {
-			cmd = exec.Command(hfCmd, "download", modelID, "--cache-dir", filepath.Join(cacheDir, "cache"))
-		}
-
-		output, err := cmd.CombinedOutput()
-		if err != nil {
-			return installCompleteMsg{modelID: modelID, err: fmt.Errorf("%v: %s", err, string(output))}
-		}
-
-		// Find the downloaded snapshot path
-		orgName := strings.ReplaceAll(modelID, "/", "--")
-		modelCacheDir := filepath.Join(cacheDir, "cache", "models--"+orgName, "snapshots")
-
-		entries, err := os.ReadDir(modelCacheDir)
-		if err != nil || len(entries) == 0 {
-			return installCompleteMsg{modelID: modelID, err: fmt.Errorf("could not find downloaded model in cache")}
-		}
-
-		snapshotPath := filepath.Join(modelCacheDir, entries[0].Name())
-
-		// Create symlink
-		symlinkPath := filepath.Join(cacheDir, modelName)
-		os.Remove(symlinkPath)
-
-		if err := os.Symlink(snapshotPath, symlinkPath); err != nil {
-			return installCompleteMsg{modelID: modelID, err: fmt.Errorf("failed to create symlink: %v", err)}
-		}
+	return queueInstall(m.queue, modelID)
+}
 
-		return installCompleteMsg{modelID: modelID, err: nil}
+// queueInstall adds a model to the download queue
+func queueInstall(queue downloads.Backend, modelID string) tea.Cmd {
+	return func() tea.Msg {
+		job, err := queue.Add(modelID)
+		return installQueuedMsg{modelID: modelID, jobID: job.ID, err: err}
 	}
 }
 
@@ -3,6 +3,7 @@ package tui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
@@ -12,16 +13,32 @@ import (
 
 // serverManagerModel handles the multi-server management view
 type serverManagerModel struct {
-	servers      *server.Manager
+	servers      server.Backend
 	width        int
 	height       int
 	selectedIdx  int
 	selectedPort int
 	viewport     viewport.Model
 	focusOnLogs  bool
+	status       string // progress or error of the last stop
+
+	// Log panel state
+	logLines    []logLine // shown output of the selected server
+	minLevel    logLevel  // hide lines below this level
+	paused      bool      // keep the view in place when output arrives
+	structured  bool      // show receive time, source and level of each line
+	searching   bool      // typing a search query
+	searchQuery string
+	matches     []int // indices into logLines of lines matching searchQuery
+	matchIdx    int   // selected match
+}
+
+// serversStoppedMsg reports the result of stopping one or all servers
+type serversStoppedMsg struct {
+	err error
 }
 
-func newServerManagerModel(servers *server.Manager, width, height int) serverManagerModel {
+func newServerManagerModel(servers server.Backend, width, height int) serverManagerModel {
 	// Calculate proper viewport dimensions to prevent crash
 	contentWidth := width - 4
 	logPanelHeight := height - 8 - 10  // control height (8) + title/borders/footer/leading newlines (10)
@@ -41,7 +58,7 @@ func newServerManagerModel(servers *server.Manager, width, height int) serverMan
 	list := servers.List()
 	if len(list) > 0 {
 		m.selectedPort = list[0].Port
-		m.viewport.SetContent(servers.GetLogs(m.selectedPort))
+		m.loadLogs()
 	}
 	
 	return m
@@ -55,15 +72,29 @@ func (m serverManagerModel) Update(msg tea.Msg) (serverManagerModel, tea.Cmd) {
 	var cmd tea.Cmd
 	
 	switch msg := msg.(type) {
+	case serversStoppedMsg:
+		m.status = ""
+		if msg.err != nil {
+			m.status = fmt.Sprintf("Stop failed: %v", msg.err)
+		}
+		m.selectFirst()
+		return m, nil
+
 	case serverUpdateMsg:
-		// Refresh logs if it's for the selected server
-		if msg.Port == m.selectedPort {
-			m.viewport.SetContent(m.servers.GetLogs(m.selectedPort))
-			m.viewport.GotoBottom()
+		// Refresh logs if it's for the selected server. Scrolling to new
+		// output is held while paused or browsing search matches.
+		if msg.Port == m.selectedPort && msg.Type != server.UpdateResources {
+			m.loadLogs()
+			if !m.paused && m.searchQuery == "" {
+				m.viewport.GotoBottom()
+			}
 		}
 		return m, nil
 		
 	case tea.KeyMsg:
+		if m.searching {
+			return m.updateSearch(msg), nil
+		}
 		switch msg.String() {
 		case "up", "k":
 			if m.focusOnLogs {
@@ -74,7 +105,7 @@ func (m serverManagerModel) Update(msg tea.Msg) (serverManagerModel, tea.Cmd) {
 					m.selectedIdx--
 					if len(list) > m.selectedIdx {
 						m.selectedPort = list[m.selectedIdx].Port
-						m.viewport.SetContent(m.servers.GetLogs(m.selectedPort))
+						m.loadLogs()
 					}
 				}
 			}
@@ -86,40 +117,82 @@ func (m serverManagerModel) Update(msg tea.Msg) (serverManagerModel, tea.Cmd) {
 				if m.selectedIdx < len(list)-1 {
 					m.selectedIdx++
 					m.selectedPort = list[m.selectedIdx].Port
-					m.viewport.SetContent(m.servers.GetLogs(m.selectedPort))
+					m.loadLogs()
 				}
 			}
 		case "s":
-			// Stop selected server
+			// Stop selected server; waits for the process to exit
 			if m.selectedPort > 0 {
-				m.servers.Stop(m.selectedPort)
-				// Select another server
-				list := m.servers.List()
-				if len(list) > 0 {
-					m.selectedIdx = 0
-					m.selectedPort = list[0].Port
-					m.viewport.SetContent(m.servers.GetLogs(m.selectedPort))
-				} else {
-					m.selectedPort = 0
-					m.viewport.SetContent("")
+				port := m.selectedPort
+				servers := m.servers
+				m.status = fmt.Sprintf("Stopping server on :%d...", port)
+				return m, func() tea.Msg {
+					return serversStoppedMsg{err: servers.Stop(port)}
 				}
 			}
 		case "S":
 			// Stop all servers
-			m.servers.StopAll()
-			m.selectedPort = 0
-			m.selectedIdx = 0
-			m.viewport.SetContent("")
+			servers := m.servers
+			m.status = "Stopping all servers..."
+			return m, func() tea.Msg {
+				return serversStoppedMsg{err: servers.StopAll()}
+			}
+		case "h":
+			// Browse logs of this server's current and past runs
+			modelName := ""
+			if inst := m.servers.Get(m.selectedPort); inst != nil {
+				modelName = inst.Model
+			}
+			return m, func() tea.Msg { return openLogHistoryMsg{model: modelName} }
 		case "n":
-			// Open new server dialog
+			// Next search match, or open new server dialog
+			if m.searchQuery != "" {
+				m.jumpToMatch(m.matchIdx + 1)
+				return m, nil
+			}
 			return m, func() tea.Msg { return openNewServerMsg{} }
+		case "N":
+			// Previous search match
+			if m.searchQuery != "" {
+				m.jumpToMatch(m.matchIdx - 1)
+			}
+			return m, nil
+		case "/":
+			// Search the log
+			m.searching = true
+			m.searchQuery = ""
+			m.loadLogs()
+			return m, nil
+		case "l":
+			// Cycle the minimum level shown
+			for i, level := range logFilterLevels {
+				if level == m.minLevel {
+					m.minLevel = logFilterLevels[(i+1)%len(logFilterLevels)]
+					break
+				}
+			}
+			m.loadLogs()
+			if !m.paused && m.searchQuery == "" {
+				m.viewport.GotoBottom()
+			}
+			return m, nil
+		case "t":
+			// Toggle between raw and structured output
+			m.structured = !m.structured
+			m.loadLogs()
+			return m, nil
+		case "p":
+			// Pause or resume scrolling to new output
+			m.paused = !m.paused
+			if !m.paused {
+				m.viewport.GotoBottom()
+			}
+			return m, nil
 		case "c":
 			// Clear logs
 			if m.selectedPort > 0 {
-				if inst := m.servers.Get(m.selectedPort); inst != nil {
-					inst.Output.Clear()
-					m.viewport.SetContent("")
-				}
+				m.servers.ClearLogs(m.selectedPort)
+				m.loadLogs()
 			}
 		case "g":
 			m.viewport.GotoTop()
@@ -134,9 +207,15 @@ func (m serverManagerModel) Update(msg tea.Msg) (serverManagerModel, tea.Cmd) {
 			if idx < len(list) {
 				m.selectedIdx = idx
 				m.selectedPort = list[idx].Port
-				m.viewport.SetContent(m.servers.GetLogs(m.selectedPort))
+				m.loadLogs()
 			}
 		case "m", "esc":
+			// Esc first clears an active search
+			if msg.String() == "esc" && m.searchQuery != "" {
+				m.searchQuery = ""
+				m.loadLogs()
+				return m, nil
+			}
 			return m, func() tea.Msg { return goBackMsg{} }
 		}
 	
@@ -245,11 +324,117 @@ func (m serverManagerModel) View() string {
 
 	// Footer with shortcuts
 	b.WriteString("\n")
-	b.WriteString(helpStyle.Render("[↑/↓] select server  [s] stop  [S] stop all  [n] new  [c] clear  [tab] focus logs  [esc] menu"))
+	b.WriteString(helpStyle.Render("[↑/↓] select server  [s] stop  [S] stop all  [n] new  [c] clear  [h] log history  [tab] focus logs  [esc] menu"))
 
 	return appStyle.Render(b.String())
 }
 
+// selectFirst selects the first server, or none when the list is empty
+func (m *serverManagerModel) selectFirst() {
+	list := m.servers.List()
+	if len(list) > 0 {
+		m.selectedIdx = 0
+		m.selectedPort = list[0].Port
+	} else {
+		m.selectedIdx = 0
+		m.selectedPort = 0
+	}
+	m.loadLogs()
+}
+
+// capturesEsc reports whether esc belongs to the log search rather than
+// leaving the view
+func (m serverManagerModel) capturesEsc() bool {
+	return m.searching || m.searchQuery != ""
+}
+
+// updateSearch handles keys while typing a search query. Matches are
+// highlighted as the query is typed.
+func (m serverManagerModel) updateSearch(msg tea.KeyMsg) serverManagerModel {
+	switch msg.String() {
+	case "enter":
+		m.searching = false
+	case "esc":
+		m.searching = false
+		m.searchQuery = ""
+		m.loadLogs()
+	case "backspace":
+		if len(m.searchQuery) > 0 {
+			m.searchQuery = m.searchQuery[:len(m.searchQuery)-1]
+			m.loadLogs()
+			m.jumpToMatch(m.firstVisibleMatch())
+		}
+	default:
+		if len(msg.String()) == 1 {
+			m.searchQuery += msg.String()
+			m.loadLogs()
+			m.jumpToMatch(m.firstVisibleMatch())
+		}
+	}
+	return m
+}
+
+// loadLogs reads the selected server's output into the log panel, keeping
+// the lines at or above the minimum level and finding search matches
+func (m *serverManagerModel) loadLogs() {
+	m.logLines = nil
+	m.matches = nil
+	if m.selectedPort > 0 {
+		search := searchPattern(m.searchQuery)
+		for _, line := range classifyLogLines(m.servers.GetLogEntries(m.selectedPort), m.structured) {
+			if m.minLevel != levelNone && line.level < m.minLevel {
+				continue
+			}
+			if search != nil && search.MatchString(line.text) {
+				m.matches = append(m.matches, len(m.logLines))
+			}
+			m.logLines = append(m.logLines, line)
+		}
+	}
+	if m.matchIdx >= len(m.matches) {
+		m.matchIdx = max(len(m.matches)-1, 0)
+	}
+	m.renderLogs()
+}
+
+// renderLogs sets the viewport content from the shown lines
+func (m *serverManagerModel) renderLogs() {
+	search := searchPattern(m.searchQuery)
+	current := -1
+	if len(m.matches) > 0 {
+		current = m.matches[m.matchIdx]
+	}
+	rendered := make([]string, len(m.logLines))
+	for i, line := range m.logLines {
+		rendered[i] = line.render(search, i == current)
+	}
+	m.viewport.SetContent(strings.Join(rendered, "\n"))
+}
+
+// firstVisibleMatch returns the first match at or below the top of the
+// view, or the last match when all are above it
+func (m serverManagerModel) firstVisibleMatch() int {
+	for i, line := range m.matches {
+		if line >= m.viewport.YOffset {
+			return i
+		}
+	}
+	return len(m.matches) - 1
+}
+
+// jumpToMatch selects match i, wrapping around, and scrolls it into view
+func (m *serverManagerModel) jumpToMatch(i int) {
+	if len(m.matches) == 0 {
+		return
+	}
+	m.matchIdx = (i%len(m.matches) + len(m.matches)) % len(m.matches)
+	line := m.matches[m.matchIdx]
+	if line < m.viewport.YOffset || line >= m.viewport.YOffset+m.viewport.Height {
+		m.viewport.SetYOffset(line - m.viewport.Height/2)
+	}
+	m.renderLogs()
+}
+
 // renderServerList renders the left column: running servers list
 func (m serverManagerModel) renderServerList(list []*server.Instance) string {
 	var b strings.Builder
@@ -260,6 +445,15 @@ func (m serverManagerModel) renderServerList(list []*server.Instance) string {
 	if len(list) == 0 {
 		b.WriteString(statusMutedStyle.Render("No servers running"))
 	} else {
+		// The model name gets what the fixed columns leave; resource use is
+		// dropped first when the panel is narrow
+		available := m.width*50/100 - 6
+		showUsage := available-serverListFixedWidth-serverListUsageWidth >= 12
+		nameWidth := available - serverListFixedWidth
+		if showUsage {
+			nameWidth -= serverListUsageWidth
+		}
+		nameWidth = min(max(nameWidth, 12), 24)
 		for i, inst := range list {
 			typeShort := string(inst.Type)
 			if len(typeShort) > 4 {
@@ -269,7 +463,15 @@ func (m serverManagerModel) renderServerList(list []*server.Instance) string {
 			if i < 9 {
 				shortcut = fmt.Sprintf(" [%d]", i+1)
 			}
-			line := fmt.Sprintf("● %-30s :%d %s%s", truncateStr(inst.Model, 30), inst.Port, typeShort, shortcut)
+			usage := ""
+			if showUsage {
+				usage = strings.Repeat(" ", serverListUsageWidth)
+				if inst.Running && !inst.Usage.At.IsZero() {
+					usage = fmt.Sprintf(" %3.0f%% %6s", inst.Usage.CPU, formatMemory(inst.Usage.RSS))
+				}
+			}
+			line := fmt.Sprintf("%s %-*s :%-5d %-4s %-9s%s%s", stateSymbol(inst.State), nameWidth, truncateStr(inst.Model, nameWidth),
+				inst.Port, typeShort, inst.State, usage, shortcut)
 			if inst.Port == m.selectedPort {
 				b.WriteString(optionSelectedStyle.Render(fmt.Sprintf("> %s", line)))
 			} else {
@@ -293,21 +495,141 @@ func (m serverManagerModel) renderServerDetails() string {
 		if inst := m.servers.Get(m.selectedPort); inst != nil {
 			b.WriteString(fmt.Sprintf("Model: %s\n", truncateStr(inst.Model, 35)))
 			b.WriteString(fmt.Sprintf("Type: %s  Port: %d  Host: %s\n", inst.Type, inst.Port, inst.Host))
+			b.WriteString(fmt.Sprintf("State: %s\n", renderState(inst)))
+			if inst.Running && !inst.Usage.At.IsZero() {
+				b.WriteString(renderUsage(inst))
+				b.WriteString("\n")
+			}
+			if inst.Adopted {
+				b.WriteString(statusMutedStyle.Render("Adopted from an earlier session, no log history"))
+				b.WriteString("\n")
+			}
+			if inst.LastExit != nil {
+				b.WriteString(statusMutedStyle.Render(fmt.Sprintf("Restarts: %d  Last exit: code %d at %s",
+					inst.Restarts, inst.LastExit.Code, inst.LastExit.At.Format("15:04:05"))))
+				b.WriteString("\n")
+			}
 		}
 	} else {
 		b.WriteString(statusMutedStyle.Render("No server selected\n"))
 	}
 	b.WriteString(sectionTitleStyle.Render("Actions") + " [s]Stop [S]ALL [n]New [m]Menu")
+	if m.status != "" {
+		b.WriteString("\n" + infoLineStyle.Render(m.status))
+	}
 	return b.String()
 }
 
+const (
+	serverListFixedWidth = 30 // selection marker, state symbol, port, type, state and shortcut columns
+	serverListUsageWidth = 12 // CPU and memory columns
+	usageHistoryWidth    = 6  // samples shown in each sparkline
+)
+
+// renderUsage renders the resource use of a server with sparklines of its
+// recent history
+func renderUsage(inst *server.Instance) string {
+	history := inst.UsageHistory
+	if len(history) > usageHistoryWidth {
+		history = history[len(history)-usageHistoryWidth:]
+	}
+	cpu := make([]float64, len(history))
+	rss := make([]float64, len(history))
+	for i, usage := range history {
+		cpu[i] = usage.CPU
+		rss[i] = float64(usage.RSS)
+	}
+	return fmt.Sprintf("CPU %s %3.0f%%  RSS %s %s  %d thr  up %s",
+		sparklineStyle.Render(sparkline(cpu, 100)), inst.Usage.CPU,
+		sparklineStyle.Render(sparkline(rss, 0)), formatMemory(inst.Usage.RSS),
+		inst.Usage.Threads, formatDuration(time.Since(inst.StartedAt)))
+}
+
+var sparklineStyle = lipgloss.NewStyle().Foreground(primary)
+
+// sparkline draws values as block characters scaled to the largest value,
+// or to ceiling when that is larger
+func sparkline(values []float64, ceiling float64) string {
+	const blocks = "▁▂▃▄▅▆▇█"
+	levels := []rune(blocks)
+	for _, v := range values {
+		ceiling = max(ceiling, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if ceiling > 0 {
+			i = int(v / ceiling * float64(len(levels)-1))
+		}
+		b.WriteRune(levels[min(max(i, 0), len(levels)-1)])
+	}
+	return b.String()
+}
+
+// formatMemory renders a byte count compactly, in MB below 1 GB
+func formatMemory(bytes uint64) string {
+	if bytes < 1<<30 {
+		return fmt.Sprintf("%dM", bytes>>20)
+	}
+	return fmt.Sprintf("%.1fG", float64(bytes)/(1<<30))
+}
+
+// stateSymbol returns the list marker for a server state
+func stateSymbol(state server.State) string {
+	switch state {
+	case server.StateStarting, server.StateRestarting:
+		return "◐"
+	case server.StateUnhealthy:
+		return "✕"
+	case server.StateStopped:
+		return "○"
+	}
+	return "●"
+}
+
+// renderState renders the colored state of a server and how long it has held it
+func renderState(inst *server.Instance) string {
+	style := statusMutedStyle
+	switch inst.State {
+	case server.StateReady:
+		style = lipgloss.NewStyle().Foreground(secondary)
+	case server.StateStarting, server.StateRestarting:
+		style = lipgloss.NewStyle().Foreground(primary)
+	case server.StateUnhealthy:
+		style = lipgloss.NewStyle().Foreground(danger)
+	}
+	state := style.Render(string(inst.State))
+	if inst.CrashLoop {
+		state = errorStyle.Render("crash loop")
+	}
+	if !inst.StateSince.IsZero() {
+		state += statusMutedStyle.Render(fmt.Sprintf(" for %s", formatDuration(time.Since(inst.StateSince))))
+	}
+	if inst.HealthError != "" {
+		state += statusMutedStyle.Render(" (" + truncateStr(inst.HealthError, 30) + ")")
+	}
+	return state
+}
+
 func (m serverManagerModel) renderLogPanel() string {
 	var b strings.Builder
 	
 	// Title with server info - no truncation, no newlines, no separator
 	if m.selectedPort > 0 {
 		if inst := m.servers.Get(m.selectedPort); inst != nil {
-			b.WriteString(panelTitleStyle.Render(fmt.Sprintf("Server Output: %s :%d", inst.Model, inst.Port)))
+			// Labels join the title's first line, above its bottom margin
+			labels := ""
+			if inst.Adopted {
+				labels += statusMutedStyle.Render("  adopted: output written before adoption was not captured")
+			}
+			if m.minLevel != levelNone {
+				labels += warningStyle.Render("  level: " + m.minLevel.filterLabel())
+			}
+			if m.paused {
+				labels += warningStyle.Render("  paused")
+			}
+			title := panelTitleStyle.Render(fmt.Sprintf("Server Output: %s :%d", inst.Model, inst.Port))
+			b.WriteString(lipgloss.JoinHorizontal(lipgloss.Top, title, labels))
 		} else {
 			b.WriteString(panelTitleStyle.Render("Server Output"))
 		}
@@ -323,11 +645,26 @@ func (m serverManagerModel) renderLogPanel() string {
 		b.WriteString(statusMutedStyle.Render("No server selected"))
 	}
 	
-	// Scroll indicator - no extra newline
+	// Scroll indicator, or the search being typed - no extra newline
 	if m.selectedPort > 0 {
 		b.WriteString(" ")
-		b.WriteString(infoLineStyle.Render(fmt.Sprintf("%.0f%% [↑/↓] scroll  [g/G] top/bottom", m.viewport.ScrollPercent()*100)))
+		switch {
+		case m.searching:
+			b.WriteString(infoLineStyle.Render(fmt.Sprintf("/%s▏ %s  [enter] done  [esc] cancel", m.searchQuery, m.matchCount())))
+		case m.searchQuery != "":
+			b.WriteString(infoLineStyle.Render(fmt.Sprintf("%.0f%% /%s %s  [n/N] next/prev  [esc] clear", m.viewport.ScrollPercent()*100, m.searchQuery, m.matchCount())))
+		default:
+			b.WriteString(infoLineStyle.Render(fmt.Sprintf("%.0f%% [↑/↓] scroll  [g/G] top/bottom  [/] search  [l] level  [t] times  [p] pause", m.viewport.ScrollPercent()*100)))
+		}
 	}
 	
 	return b.String()
 }
+
+// matchCount describes the selected match and the number of matches
+func (m serverManagerModel) matchCount() string {
+	if len(m.matches) == 0 {
+		return "(no matches)"
+	}
+	return fmt.Sprintf("(%d/%d)", m.matchIdx+1, len(m.matches))
+}
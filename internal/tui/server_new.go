@@ -15,7 +15,7 @@ import (
 type serverNewModel struct {
 	cfg         *config.Config
 	store       *model.Store
-	servers     *server.Manager
+	servers     server.Backend
 	models      []model.Model
 	width       int
 	height      int
@@ -27,7 +27,7 @@ type serverNewModel struct {
 	portBuffer  string
 }
 
-func newServerNewModel(cfg *config.Config, store *model.Store, servers *server.Manager) serverNewModel {
+func newServerNewModel(cfg *config.Config, store *model.Store, servers server.Backend) serverNewModel {
 	models, _ := store.List()
 	
 	return serverNewModel{
@@ -292,7 +292,8 @@ func (m serverNewModel) renderRunningPanel(width int) string {
 		b.WriteString(statusMutedStyle.Render("No servers running"))
 	} else {
 		for _, inst := range list {
-			line := fmt.Sprintf("● %-20s :%d  %s",
+			line := fmt.Sprintf("%s %-20s :%d  %s",
+				stateSymbol(inst.State),
 				truncateStr(inst.Model, 20),
 				inst.Port,
 				inst.Type)
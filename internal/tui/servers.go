@@ -0,0 +1,297 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/lmarques/efx-face-manager/internal/api"
+	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/daemon"
+	"github.com/lmarques/efx-face-manager/internal/server"
+)
+
+// ExitNotFound is the exit status of server commands whose target matches
+// no running server
+const ExitNotFound = 3
+
+// exitError is an error that sets the exit status of the process
+type exitError struct {
+	err  error
+	code int
+}
+
+func (e *exitError) Error() string { return e.err.Error() }
+func (e *exitError) Unwrap() error { return e.err }
+
+// ExitCode returns the exit status for the error
+func (e *exitError) ExitCode() int { return e.code }
+
+// notFound reports that target matches no running server
+func notFound(target string) error {
+	return &exitError{err: fmt.Errorf("no running server matches %s", target), code: ExitNotFound}
+}
+
+// cliServer is a running server as seen by the servers commands: hosted by
+// the daemon, or recorded in the state file of another efx-face process
+type cliServer struct {
+	info    api.Instance
+	client  *daemon.Client       // set for daemon servers
+	tracked server.TrackedServer // set for the servers of other processes
+}
+
+// findServers returns the running servers ordered by port. Servers outside
+// the daemon are probed for their state and resource use.
+func findServers() []cliServer {
+	var servers []cliServer
+	daemonPorts := make(map[int]bool)
+	if client, err := daemon.Dial(daemon.SocketPath()); err == nil {
+		for _, inst := range client.List() {
+			servers = append(servers, cliServer{info: inst.Info(), client: client})
+			daemonPorts[inst.Port] = true
+		}
+	}
+
+	// The daemon records its servers in a state file as well
+	var tracked []server.TrackedServer
+	for _, srv := range server.TrackedServers() {
+		if !daemonPorts[srv.Port] {
+			tracked = append(tracked, srv)
+		}
+	}
+	probed := make([]cliServer, len(tracked))
+	health := server.NewHealthChecker()
+	var wg sync.WaitGroup
+	for i, srv := range tracked {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			probed[i] = cliServer{info: srv.Info(health), tracked: srv}
+		}()
+	}
+	wg.Wait()
+	servers = append(servers, probed...)
+
+	sort.Slice(servers, func(i, j int) bool { return servers[i].info.Port < servers[j].info.Port })
+	return servers
+}
+
+// cliInstances lists the running servers for printing
+func cliInstances() []api.Instance {
+	instances := []api.Instance{}
+	for _, srv := range findServers() {
+		instances = append(instances, srv.info)
+	}
+	return instances
+}
+
+// findServer returns the server on the port given as target
+func findServer(target string) (cliServer, error) {
+	port, err := strconv.Atoi(target)
+	if err != nil {
+		return cliServer{}, fmt.Errorf("invalid port: %s", target)
+	}
+	for _, srv := range findServers() {
+		if srv.info.Port == port {
+			return srv, nil
+		}
+	}
+	return cliServer{}, notFound("port " + target)
+}
+
+// owner describes who runs a server
+func (s cliServer) owner() string {
+	if s.client != nil {
+		return "daemon"
+	}
+	return fmt.Sprintf("pid %d", s.tracked.Owner)
+}
+
+// cliManager returns a manager for stopping the servers of other processes
+// with the configured grace period
+func cliManager() *server.Manager {
+	cfg, _ := config.Load()
+	mgr := server.NewManager()
+	mgr.SetGracePeriod(cfg.StopGracePeriod())
+	return mgr
+}
+
+// RunServersList prints the running servers (CLI mode)
+func RunServersList(format OutputFormat) error {
+	if format.structured() {
+		return printOutput(format, cliInstances())
+	}
+	servers := findServers()
+	if len(servers) == 0 {
+		fmt.Println("No running servers")
+		return nil
+	}
+
+	fmt.Printf("  %-6s %-36s %-12s %-10s %-8s %-8s %6s %8s  %s\n", "PORT", "MODEL", "TYPE", "STATE", "PID", "UPTIME", "CPU", "MEM", "OWNER")
+	for _, srv := range servers {
+		info := srv.info
+		cpu, mem := "-", "-"
+		if !info.Usage.At.IsZero() {
+			cpu = fmt.Sprintf("%.0f%%", info.Usage.CPU)
+			mem = fmt.Sprintf("%d MB", info.Usage.RSS>>20)
+		}
+		fmt.Printf("  %-6d %-36s %-12s %-10s %-8d %-8s %6s %8s  %s\n", info.Port, truncateStr(info.Model, 36), info.Type,
+			info.State, info.PID, uptime(info), cpu, mem, srv.owner())
+	}
+	return nil
+}
+
+// uptime returns how long the current process of a server has run
+func uptime(info api.Instance) string {
+	if !info.Running || info.StartedAt.IsZero() {
+		return "-"
+	}
+	return formatDuration(time.Since(info.StartedAt))
+}
+
+// RunServersStop stops the servers on a port or running a model, or every
+// server with all (CLI mode)
+func RunServersStop(target string, all bool, format OutputFormat) error {
+	var matched []cliServer
+	for _, srv := range findServers() {
+		if all || matchesTarget(target, srv.info.Port, srv.info.Model) {
+			matched = append(matched, srv)
+		}
+	}
+	if len(matched) == 0 {
+		if all {
+			return &exitError{err: errors.New("no running servers"), code: ExitNotFound}
+		}
+		return notFound(target)
+	}
+
+	mgr := cliManager()
+	errs := make([]error, len(matched))
+	var wg sync.WaitGroup
+	for i, srv := range matched {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if srv.client != nil {
+				errs[i] = srv.client.Stop(srv.info.Port)
+			} else {
+				errs[i] = mgr.StopTracked(srv.tracked)
+			}
+			if errs[i] != nil {
+				errs[i] = fmt.Errorf("failed to stop %s on port %d: %w", srv.info.Model, srv.info.Port, errs[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	stopped := []api.Instance{}
+	for i, srv := range matched {
+		if errs[i] != nil {
+			continue
+		}
+		srv.info.Running = false
+		srv.info.State = server.StateStopped
+		stopped = append(stopped, srv.info)
+	}
+	if format.structured() {
+		if err := printOutput(format, stopped); err != nil {
+			return err
+		}
+	} else {
+		for _, info := range stopped {
+			fmt.Printf("Stopped %s on port %d\n", info.Model, info.Port)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// RunServersRestart restarts the server on a port with the same
+// configuration (CLI mode)
+func RunServersRestart(target string, format OutputFormat) error {
+	srv, err := findServer(target)
+	if err != nil {
+		return err
+	}
+
+	var info api.Instance
+	if srv.client != nil {
+		inst, err := srv.client.Restart(srv.info.Port)
+		if err != nil {
+			return err
+		}
+		info = inst.Info()
+	} else {
+		tracked, err := cliManager().RestartTracked(srv.tracked)
+		if err != nil {
+			return err
+		}
+		info = tracked.Info(server.NewHealthChecker())
+	}
+
+	if format.structured() {
+		return printOutput(format, info)
+	}
+	fmt.Printf("Restarted %s on port %d (pid %d)\n", info.Model, info.Port, info.PID)
+	return nil
+}
+
+// RunServersInspect prints the details of the server on a port (CLI mode)
+func RunServersInspect(target string, format OutputFormat) error {
+	srv, err := findServer(target)
+	if err != nil {
+		return err
+	}
+	info := srv.info
+	details := api.ServerDetails{
+		Instance: info,
+		Command:  info.Instance().GetCommandString(),
+		URL:      serverURL(info.Host, info.Port),
+		Daemon:   srv.client != nil,
+		OwnerPID: srv.tracked.Owner,
+	}
+	if format.structured() {
+		return printOutput(format, details)
+	}
+
+	row := func(label, value string) {
+		fmt.Printf("  %-10s %s\n", label+":", value)
+	}
+	fmt.Printf("%s on port %d\n", info.Model, info.Port)
+	row("Command", details.Command)
+	row("PID", strconv.Itoa(info.PID))
+	row("Owner", srv.owner())
+	state := string(info.State)
+	if info.HealthError != "" {
+		state += " (" + info.HealthError + ")"
+	}
+	row("State", state)
+	if !info.StartedAt.IsZero() {
+		row("Uptime", fmt.Sprintf("%s, since %s", uptime(info), info.StartedAt.Format("2006-01-02 15:04:05")))
+	}
+	if !info.Usage.At.IsZero() {
+		row("Resources", fmt.Sprintf("cpu %.0f%%, rss %d MB, %d threads, %d processes",
+			info.Usage.CPU, info.Usage.RSS>>20, info.Usage.Threads, info.Usage.Processes))
+	}
+	if info.Restart != "" {
+		policy := string(info.Restart)
+		if info.Restarts > 0 {
+			policy += fmt.Sprintf(", %d restarts", info.Restarts)
+		}
+		if info.CrashLoop {
+			policy += ", crash loop"
+		}
+		row("Restart", policy)
+	}
+	if info.LastExit != nil {
+		row("Last exit", fmt.Sprintf("code %d at %s", info.LastExit.Code, info.LastExit.At.Format("15:04:05")))
+	}
+	if info.State == server.StateReady {
+		row("URL", details.URL)
+	} else {
+		row("URL", details.URL+" (not ready)")
+	}
+	return nil
+}
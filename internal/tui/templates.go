@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/lmarques/efx-face-manager/internal/config"
+	"github.com/lmarques/efx-face-manager/internal/model"
+)
+
+// RunTemplatesValidate checks templates.yaml and prints its problems as
+// file:line (CLI mode). It fails when there are any.
+func RunTemplatesValidate(format OutputFormat) error {
+	cfg, _ := config.Load()
+	problems, err := model.ValidateTemplates(model.NewStore(cfg.ModelDir))
+	if err != nil {
+		return err
+	}
+
+	file := model.TemplatesPath()
+	if format.structured() {
+		if problems == nil {
+			problems = []*model.TemplateError{}
+		}
+		if err := printOutput(format, problems); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range problems {
+			if p.Template != "" {
+				fmt.Printf("%s:%d: %s: %s\n", config.DisplayPath(p.File), p.Line, p.Template, p.Msg)
+			} else {
+				fmt.Printf("%s:%d: %s\n", config.DisplayPath(p.File), p.Line, p.Msg)
+			}
+		}
+	}
+
+	switch {
+	case len(problems) == 1:
+		return fmt.Errorf("1 problem in %s", config.DisplayPath(file))
+	case len(problems) > 1:
+		return fmt.Errorf("%d problems in %s", len(problems), config.DisplayPath(file))
+	}
+	if !format.structured() {
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			fmt.Printf("No templates file at %s\n", config.DisplayPath(file))
+			return nil
+		}
+		templates, _ := model.LoadTemplates()
+		fmt.Printf("✓ %d templates in %s are valid\n", len(templates), config.DisplayPath(file))
+	}
+	return nil
+}